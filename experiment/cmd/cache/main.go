@@ -0,0 +1,144 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"speckit-study/internal/llm"
+)
+
+// cache 는 응답 캐시(.specify/_cache)를 조회하고 정리합니다.
+//
+//	go run ./cmd/cache list                          # 항목 목록 (최신순)
+//	go run ./cmd/cache show 3fa2                     # 키(앞부분)로 항목 내용
+//	go run ./cmd/cache stats
+//	go run ./cmd/cache prune -expired                # TTL 지난 항목
+//	go run ./cmd/cache prune -older-than 72h -max-mb 50
+//	go run ./cmd/cache prune -all
+//	go run ./cmd/cache -dir msaproj/.specify/_cache list   # specgen 의 캐시
+func main() {
+	dir := flag.String("dir", llm.DefaultCacheDir, "cache directory")
+	ttl := flag.Duration("ttl", llm.DefaultCacheTTL, "entries older than this are expired (0 = never)")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: cache [-dir path] [-ttl d] list | show KEY | stats | prune [flags]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	c := &llm.ResponseCache{Dir: *dir, TTL: *ttl}
+	now := time.Now()
+
+	switch args[0] {
+	case "list":
+		entries, err := c.Entries()
+		if err != nil {
+			log.Fatalf("cache: %v", err)
+		}
+		for _, e := range entries {
+			mark := ""
+			if e.Expired(c.TTL, now) {
+				mark = " (expired)"
+			}
+			fmt.Printf("%s  %-8s %-10s %-28s %7s  %s ago%s\n    %s\n",
+				e.Key, e.Tag, e.Provider, e.Model, formatBytes(e.Size),
+				now.Sub(e.CreatedAt).Round(time.Second), mark, oneLine(e.Prompt, 100))
+		}
+	case "show":
+		if len(args) != 2 {
+			flag.Usage()
+			os.Exit(2)
+		}
+		entries, err := c.Entries()
+		if err != nil {
+			log.Fatalf("cache: %v", err)
+		}
+		var found []llm.CacheEntry
+		for _, e := range entries {
+			if strings.HasPrefix(e.Key, args[1]) {
+				found = append(found, e)
+			}
+		}
+		switch len(found) {
+		case 0:
+			log.Fatalf("cache: no entry with key %s", args[1])
+		case 1:
+			b, _ := json.MarshalIndent(found[0], "", "  ")
+			fmt.Printf("%s\n%s\n", found[0].Path, b)
+		default:
+			log.Fatalf("cache: key %s is ambiguous (%d entries)", args[1], len(found))
+		}
+	case "stats":
+		entries, err := c.Entries()
+		if err != nil {
+			log.Fatalf("cache: %v", err)
+		}
+		var total int64
+		expired := 0
+		byTag := map[string]int{}
+		for _, e := range entries {
+			total += e.Size
+			if e.Expired(c.TTL, now) {
+				expired++
+			}
+			byTag[e.Tag]++
+		}
+		fmt.Printf("%s: %d entries, %s, %d expired\n", *dir, len(entries), formatBytes(total), expired)
+		tags := make([]string, 0, len(byTag))
+		for tag := range byTag {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		for _, tag := range tags {
+			fmt.Printf("  %-10s %d\n", tag, byTag[tag])
+		}
+	case "prune":
+		fs := flag.NewFlagSet("prune", flag.ExitOnError)
+		all := fs.Bool("all", false, "remove every entry")
+		expired := fs.Bool("expired", false, "remove entries older than -ttl")
+		olderThan := fs.Duration("older-than", 0, "remove entries older than this")
+		maxMB := fs.Int64("max-mb", 0, "remove the oldest entries until the cache fits in this many MB")
+		maxEntries := fs.Int("max-entries", 0, "keep at most this many (newest) entries")
+		fs.Parse(args[1:])
+		opts := llm.PruneOptions{All: *all, Expired: *expired, OlderThan: *olderThan, MaxBytes: *maxMB << 20, MaxEntries: *maxEntries}
+		if opts == (llm.PruneOptions{}) {
+			log.Fatalf("cache: prune needs -all, -expired, -older-than, -max-mb or -max-entries")
+		}
+		removed, freed, err := c.Prune(opts)
+		fmt.Printf("🧹 removed %d entries (%s)\n", removed, formatBytes(freed))
+		if err != nil {
+			log.Fatalf("cache: %v", err)
+		}
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func formatBytes(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%dB", n)
+}
+
+func oneLine(s string, n int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len([]rune(s)) > n {
+		return string([]rune(s)[:n]) + "…"
+	}
+	return s
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"speckit-study/internal/fakellm"
+)
+
+// fakellm 은 OpenAI / Anthropic / Gemini HTTP API 를 흉내 내는 로컬 서버입니다.
+// 키 없이 specgen / RunSmokeTest 를 끝까지 돌려볼 때 사용합니다.
+//
+//	go run ./cmd/fakellm -addr :8089 -script fake.yaml
+//	export OPENAI_BASE_URL=http://localhost:8089/v1 ...
+func main() {
+	addr := flag.String("addr", "127.0.0.1:8089", "listen address")
+	scriptPath := flag.String("script", "", "YAML script file (rules/default); empty = echo prompt")
+	latency := flag.Duration("latency", 0, "default reply latency (when script has none)")
+	flag.Parse()
+
+	script := fakellm.DefaultScript()
+	if *scriptPath != "" {
+		s, err := fakellm.LoadScript(*scriptPath)
+		if err != nil {
+			log.Fatalf("script: %v", err)
+		}
+		script = s
+	}
+	if script.Default.Latency == 0 {
+		script.Default.Latency = *latency
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           logRequests(fakellm.New(script)),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	base := "http://" + *addr
+	fmt.Println("fake LLM server listening on", base)
+	fmt.Printf("  export OPENAI_BASE_URL=%s%s\n", base, fakellm.OpenAIPrefix)
+	fmt.Printf("  export ANTHROPIC_BASE_URL=%s%s\n", base, fakellm.AnthropicPrefix)
+	fmt.Printf("  export GEMINI_BASE_URL=%s%s\n", base, fakellm.GeminiPrefix)
+	log.Fatal(srv.ListenAndServe())
+}
+
+func logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		log.Printf("%s %s (%s)", r.Method, r.URL.Path, time.Since(start).Round(time.Millisecond))
+	})
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"speckit-study/internal/llm"
+)
+
+// models 는 models.yaml 에 등록된 태그를 보여주고, 로컬 서버(Ollama, vLLM 등)가
+// 제공하는 모델을 조회합니다.
+//
+//	go run ./cmd/models                  # 등록된 태그 목록
+//	go run ./cmd/models -discover local  # local 태그 서버의 모델 목록
+//	go run ./cmd/models -embed embed "send email" "send e-mail"  # 임베딩 차원과 유사도
+func main() {
+	modelsFile := flag.String("models", "models.yaml", "model registry config")
+	discover := flag.String("discover", "", "list models offered by the server behind this tag")
+	embed := flag.String("embed", "", "embed the remaining arguments with this embedder tag and print their similarities")
+	timeout := flag.Duration("timeout", 10*time.Second, "discovery/embedding timeout")
+	flag.Parse()
+
+	reg, err := llm.LoadRegistry(*modelsFile)
+	if err != nil {
+		log.Fatalf("models: %v", err)
+	}
+
+	if *discover == "" {
+		tags := reg.ListModels()
+		sort.Strings(tags)
+		for _, tag := range tags {
+			c, _ := reg.GetModel(tag)
+			mark := ""
+			if tag == reg.DefaultTag() {
+				mark = " (default)"
+			}
+			fmt.Printf("%-12s %s%s\n", tag, c.Name(), mark)
+		}
+		for _, tag := range reg.ListEmbedders() {
+			e, _ := reg.GetEmbedder(tag)
+			dims := "dims=auto"
+			if d := e.Dimensions(); d > 0 {
+				dims = fmt.Sprintf("dims=%d", d)
+			}
+			fmt.Printf("%-12s %s (embedding, %s)\n", tag, e.Name(), dims)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	if *embed != "" {
+		e, ok := reg.GetEmbedder(*embed)
+		if !ok {
+			log.Fatalf("embed: no embedder registered for tag %q", *embed)
+		}
+		texts := flag.Args()
+		if len(texts) == 0 {
+			log.Fatalf("embed: pass the texts to embed as arguments")
+		}
+		res, err := e.Embed(ctx, texts)
+		if err != nil {
+			log.Fatalf("embed: %v", err)
+		}
+		fmt.Printf("%s: %d vectors, dims=%d, tokens=%d\n", res.Model, len(res.Vectors), res.Dimensions, res.Usage.InputTokens)
+		for _, p := range llm.SimilarPairs(res.Vectors, -1) {
+			fmt.Printf("%.3f  %q ~ %q\n", p.Score, texts[p.A], texts[p.B])
+		}
+		return
+	}
+	infos, err := reg.Discover(ctx, *discover)
+	if err != nil {
+		log.Fatalf("discover: %v", err)
+	}
+	for _, m := range infos {
+		if m.Size > 0 {
+			fmt.Printf("%-40s %6.1f GB\n", m.ID, float64(m.Size)/1e9)
+		} else {
+			fmt.Printf("%-40s %s\n", m.ID, m.OwnedBy)
+		}
+	}
+}
@@ -1,9 +1,169 @@
-﻿package main
+package main
 
 import (
-    "fmt"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"speckit-study/internal/llm"
+	"speckit-study/internal/runner"
 )
 
+// run_task 는 기능 디렉터리의 tasks.yaml 을 실행합니다.
+// 태스크마다 specify.md + plan.md + 태스크 입력으로 프롬프트를 만들어 모델을 호출하고,
+// 출력의 필수 섹션을 검사해 출력 파일과 통과/실패 보고서(report.md, report.json)를 씁니다.
+// 섹션이 빠지거나 잘못되면 이전 초안과 피드백으로 -max-attempts 번까지 다시 요청합니다
+// (태스크별 max_attempts / feedback 이 있으면 그쪽이 우선).
+// -judge 를 주면 rubric 이 있는 태스크의 최종 출력을 그 모델이 기준별로 채점하고, 기준 점수에 못 미치면 실패로 봅니다.
+// -resume 은 이전 실행 디렉터리를 이어 쓰며, 통과했고 입력이 같은 태스크는 건너뛰고 실패했거나 바뀐 태스크만 다시 실행합니다.
+// -tools 는 스펙 본문을 프롬프트에 넣지 않고 모델이 list_specs / read_file / validate_tasks 도구로 직접 읽게 합니다.
+//
+//	go run ./cmd/run_task                                   # 기본 기능, 기본 모델, 모든 태스크
+//	go run ./cmd/run_task -feature .specify/notification-service -tasks basic_test -model claude
+//	go run ./cmd/run_task -out /tmp/run1
+//	go run ./cmd/run_task -max-attempts 1                   # 재프롬프트 없이 한 번만
+//	go run ./cmd/run_task -feedback feedback.tmpl           # 기본 피드백 템플릿 교체
+//	go run ./cmd/run_task -judge gpt                        # tasks.yaml rubric 으로 심사
+//	go run ./cmd/run_task -resume 20250101_120000           # 이전 실행 이어 하기 (같은 -model/-judge 로)
+//	go run ./cmd/run_task -tools -model claude              # 도구 호출로 스펙 읽기
 func main() {
-    fmt.Println("Running SpecKit task runner...")
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout))
+}
+
+// run 은 run_task 를 args 로 실행하고 종료 코드를 돌려줍니다 (0 = 모든 태스크 통과).
+func run(ctx context.Context, args []string, stdout io.Writer) int {
+	fs := flag.NewFlagSet("run_task", flag.ContinueOnError)
+	fs.SetOutput(stdout)
+	modelsPath := fs.String("models", "models.yaml", "model registry config (overridden by $"+llm.EnvModelsFile+")")
+	feature := fs.String("feature", ".specify/notification-service", "feature directory with specify.md, plan.md and tasks.yaml")
+	taskNames := fs.String("tasks", "", "comma-separated task names to run (empty = all)")
+	modelTag := fs.String("model", "", "model tag or alias (empty = registry default)")
+	outDir := fs.String("out", "", "output directory (empty = <feature>/../_runs/<feature>/<timestamp>)")
+	maxAttempts := fs.Int("max-attempts", runner.DefaultMaxAttempts, "generation attempts per task including re-prompts (tasks.yaml max_attempts wins)")
+	feedbackPath := fs.String("feedback", "", "text/template file for re-prompt feedback (tasks.yaml feedback wins)")
+	judgeTag := fs.String("judge", "", "model tag or alias that scores outputs against tasks.yaml rubrics (empty = no judge)")
+	resume := fs.String("resume", "", "continue an earlier run of this feature (run ID, unique prefix or directory): passed tasks with unchanged inputs are skipped, failed or stale ones run again")
+	tools := fs.Bool("tools", false, "let the model read the spec through tools (list_specs, read_file, validate_tasks) instead of inlining it")
+	timeout := fs.Duration("timeout", 10*time.Minute, "overall timeout")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	reg, err := llm.LoadRegistry(*modelsPath)
+	if err != nil {
+		fmt.Fprintf(stdout, "❌ models config: %v\n", err)
+		return 1
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	opts := runner.TaskRunOptions{FeatureDir: *feature, ModelTag: *modelTag, OutDir: *outDir, MaxAttempts: *maxAttempts, JudgeTag: *judgeTag, Tools: *tools}
+	if *resume != "" {
+		if *outDir != "" {
+			fmt.Fprintln(stdout, "❌ -resume continues in the earlier run directory; drop -out")
+			return 1
+		}
+		dir, err := resumeDir(*feature, *resume)
+		if err != nil {
+			fmt.Fprintf(stdout, "❌ resume: %v\n", err)
+			return 1
+		}
+		opts.OutDir, opts.Resume = dir, true
+		fmt.Fprintf(stdout, "⏯ resuming %s\n", dir)
+	}
+	if *feedbackPath != "" {
+		b, err := os.ReadFile(*feedbackPath)
+		if err != nil {
+			fmt.Fprintf(stdout, "❌ feedback template: %v\n", err)
+			return 1
+		}
+		opts.Feedback = string(b)
+	}
+	for _, name := range strings.Split(*taskNames, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			opts.Tasks = append(opts.Tasks, name)
+		}
+	}
+
+	fmt.Fprintf(stdout, "▶ %s (model %s", *feature, reg.Resolve(firstNonEmpty(*modelTag, reg.DefaultTag())))
+	if *judgeTag != "" {
+		fmt.Fprintf(stdout, ", judge %s", reg.Resolve(*judgeTag))
+	}
+	fmt.Fprintln(stdout, ")")
+	report, err := runner.RunTasks(ctx, reg, opts)
+	if report != nil {
+		for _, res := range report.Results {
+			if res.Resumed {
+				fmt.Fprintf(stdout, "⏭ %-20s skipped (finished in the earlier run)\n", res.Name)
+				continue
+			}
+			if res.ToolCalls > 0 {
+				fmt.Fprintf(stdout, "🔧 %-20s %d tool call(s)\n", res.Name, res.ToolCalls)
+			}
+			if len(res.Drafts) > 1 {
+				fmt.Fprintf(stdout, "🔁 %-20s %d drafts, using #%d\n", res.Name, len(res.Drafts), res.Best)
+			}
+			if ev := res.Judgement; ev != nil {
+				fmt.Fprintf(stdout, "⚖️ %-20s judge %.2f (pass %.2f)\n", res.Name, ev.Score, ev.PassScore)
+			}
+			switch {
+			case res.Error != "":
+				fmt.Fprintf(stdout, "❌ %-20s error: %s\n", res.Name, res.Error)
+			case len(res.Missing) > 0:
+				fmt.Fprintf(stdout, "❌ %-20s missing sections: %s → %s\n", res.Name, strings.Join(res.Missing, ", "), res.Output)
+			case res.JudgeError != "":
+				fmt.Fprintf(stdout, "❌ %-20s judge error: %s → %s\n", res.Name, res.JudgeError, res.Output)
+			case !res.Passed:
+				fmt.Fprintf(stdout, "❌ %-20s %s → %s\n", res.Name, res.Judgement.Problem(), res.Output)
+			default:
+				fmt.Fprintf(stdout, "✅ %-20s %s (latency %s)\n", res.Name, res.Output, res.Latency.Round(time.Millisecond))
+			}
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(stdout, "❌ run_task: %v\n", err)
+		if report != nil && report.OutDir != "" {
+			fmt.Fprintf(stdout, "⏯ resume with: -resume %s\n", report.OutDir)
+		}
+		return 1
+	}
+	fmt.Fprintf(stdout, "📝 report: %s/report.md (%d/%d passed)\n", report.OutDir, len(report.Results)-report.Failed(), len(report.Results))
+	if report.Failed() > 0 {
+		fmt.Fprintf(stdout, "⏯ retry failed tasks with: -resume %s\n", report.OutDir)
+		return 1
+	}
+	return 0
+}
+
+// resumeDir : 이어 할 run_task 실행 디렉터리를 <feature 의 상위>/_runs 에서 찾습니다 (같은 기능의 태스크 실행만).
+func resumeDir(feature, ref string) (string, error) {
+	feature = filepath.Clean(feature)
+	runs, scanErr := runner.ScanRuns(filepath.Join(filepath.Dir(feature), "_runs"))
+	r, err := runner.FindRun(runs, ref)
+	if err != nil {
+		return "", errors.Join(err, scanErr)
+	}
+	if r.Manifest.Kind != runner.RunKindTask || r.Manifest.Name != filepath.Base(feature) {
+		return "", fmt.Errorf("%s is not a task run of %s", r.Manifest.RunID, filepath.Base(feature))
+	}
+	return r.Dir, nil
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
 }
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"speckit-study/internal/fakellm"
+	"speckit-study/internal/llm"
+)
+
+const (
+	tasksYAML = `tasks:
+  - name: retention
+    description: Describe the retention job
+    required_sections: ["Steps", "Failure Handling"]
+    max_attempts: 1
+  - name: rollout
+    description: Describe the rollout
+    required_sections: ["Steps"]
+    max_attempts: 1
+`
+	fullDraft = "## Steps\n1. copy the partition\n\n## Failure Handling\n- retry the copy\n"
+	halfDraft = "## Steps\n1. copy the partition\n"
+)
+
+// fixture 는 <tmp>/.specify/retention 기능 디렉터리와, script 로 답하는 가짜 서버를 가리키는 models.yaml 을 만듭니다.
+func fixture(t *testing.T, script fakellm.Script) (feature, models string) {
+	t.Helper()
+	srv := fakellm.NewTestServer(script)
+	t.Cleanup(srv.Close)
+	t.Setenv(llm.EnvModelsFile, "")
+	t.Setenv(llm.EnvDefaultModel, "")
+
+	root := t.TempDir()
+	feature = filepath.Join(root, ".specify", "retention")
+	if err := os.MkdirAll(feature, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	write := func(path, content string) {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(filepath.Join(feature, "tasks.yaml"), tasksYAML)
+	write(filepath.Join(feature, "specify.md"), "keep 30 days of events\n")
+	models = filepath.Join(root, "models.yaml")
+	write(models, `default: writer
+models:
+  - tag: writer
+    provider: openai-compatible
+    model: writer-model
+    base_url: `+srv.OpenAIBaseURL()+`
+`)
+	return feature, models
+}
+
+func runCmd(t *testing.T, args ...string) (int, string) {
+	t.Helper()
+	var out strings.Builder
+	code := run(context.Background(), args, &out)
+	return code, out.String()
+}
+
+func TestRunWritesReport(t *testing.T) {
+	feature, models := fixture(t, fakellm.Script{Default: fakellm.Reply{Text: fullDraft}})
+	outDir := t.TempDir()
+
+	code, out := runCmd(t, "-models", models, "-feature", feature, "-out", outDir, "-tasks", "retention")
+	if code != 0 {
+		t.Fatalf("exit %d:\n%s", code, out)
+	}
+	for _, want := range []string{"▶ " + feature + " (model writer)", "✅ retention", "(1/1 passed)"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output misses %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "rollout") {
+		t.Errorf("-tasks retention also ran rollout:\n%s", out)
+	}
+	b, err := os.ReadFile(filepath.Join(outDir, "retention.md"))
+	if err != nil || string(b) != fullDraft {
+		t.Errorf("retention.md = %q, %v", b, err)
+	}
+	if _, err := os.Stat(filepath.Join(outDir, "report.md")); err != nil {
+		t.Error(err)
+	}
+}
+
+// 실패한 태스크가 있으면 종료 코드 1 과 함께 이어 하기 방법을 알려 주고, -resume 은 실패한 태스크만 다시 실행합니다.
+func TestRunFailureAndResume(t *testing.T) {
+	feature, models := fixture(t, fakellm.Script{
+		Rules:   []fakellm.Rule{{Match: "Describe the retention job", Times: 1, Reply: fakellm.Reply{Text: halfDraft}}},
+		Default: fakellm.Reply{Text: fullDraft},
+	})
+
+	code, out := runCmd(t, "-models", models, "-feature", feature)
+	if code != 1 {
+		t.Fatalf("exit %d, want 1 for a failed task:\n%s", code, out)
+	}
+	if !strings.Contains(out, "❌ retention") || !strings.Contains(out, "missing sections: Failure Handling") ||
+		!strings.Contains(out, "✅ rollout") || !strings.Contains(out, "⏯ retry failed tasks with: -resume ") {
+		t.Fatalf("output:\n%s", out)
+	}
+	runs, err := filepath.Glob(filepath.Join(filepath.Dir(feature), "_runs", "retention", "*"))
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("run directories = %v, %v", runs, err)
+	}
+
+	code, out = runCmd(t, "-models", models, "-feature", feature, "-resume", filepath.Base(runs[0]))
+	if code != 0 {
+		t.Fatalf("resume: exit %d:\n%s", code, out)
+	}
+	if !strings.Contains(out, "⏯ resuming "+runs[0]) || !strings.Contains(out, "⏭ rollout") || !strings.Contains(out, "✅ retention") {
+		t.Errorf("resume output:\n%s", out)
+	}
+}
+
+func TestRunUsageErrors(t *testing.T) {
+	feature, models := fixture(t, fakellm.Script{Default: fakellm.Reply{Text: fullDraft}})
+	cases := []struct {
+		args []string
+		code int
+		want string
+	}{
+		{[]string{"-no-such-flag"}, 2, "flag provided but not defined"},
+		{[]string{"-models", filepath.Join(t.TempDir(), "missing.yaml")}, 1, "❌ models config"},
+		{[]string{"-models", models, "-feature", feature, "-resume", "x", "-out", t.TempDir()}, 1, "drop -out"},
+		{[]string{"-models", models, "-feature", feature, "-resume", "20990101"}, 1, "❌ resume"},
+		{[]string{"-models", models, "-feature", feature, "-tasks", "nope"}, 1, `task "nope" not found`},
+		{[]string{"-models", models, "-feature", feature, "-model", "ghost"}, 1, "model not registered: ghost"},
+	}
+	for _, c := range cases {
+		code, out := runCmd(t, c.args...)
+		if code != c.code || !strings.Contains(out, c.want) {
+			t.Errorf("run_task %s: exit %d, want %d with %q:\n%s", strings.Join(c.args, " "), code, c.code, c.want, out)
+		}
+	}
+}
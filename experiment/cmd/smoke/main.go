@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"speckit-study/internal/llm"
+	"speckit-study/internal/runner"
+)
+
+// smoke 는 같은 프롬프트를 후보 모델 태그마다 -n 번씩 보내 출력과 leaderboard 를 .specify/_runs/<id>/<타임스탬프> 에 씁니다.
+// Ctrl-C(SIGINT)를 받으면 새 호출을 멈추고, 끝난 호출만으로 보고서와 manifest.json 을 남긴 뒤 종료합니다.
+//
+//	go run ./cmd/smoke -id notif-smoke -prompt prompt.md
+//	go run ./cmd/smoke -id notif-smoke -prompt prompt.md -tags gpt,claude -n 5 -concurrency 2
+//	go run ./cmd/smoke -id notif-smoke -prompt prompt.md -sections Steps,Rollback -max-chars 4000
+func main() {
+	modelsPath := flag.String("models", "models.yaml", "model registry config (overridden by $"+llm.EnvModelsFile+")")
+	pricingPath := flag.String("pricing", "pricing.yaml", "pricing table (USD per 1M tokens by model tag); missing file = tokens only")
+	id := flag.String("id", "smoke", "smoke task ID (runs go to .specify/_runs/<id>/<timestamp>)")
+	promptPath := flag.String("prompt", "", "file with the prompt to send (required)")
+	tags := flag.String("tags", "gpt,claude,gemini", "comma-separated candidate model tags")
+	iterations := flag.Int("n", 3, "calls per tag")
+	concurrency := flag.Int("concurrency", runner.DefaultSmokeConcurrency, "calls in flight at once")
+	sections := flag.String("sections", "", "comma-separated required \"## <Section>\" headings")
+	minChars := flag.Int("min-chars", 0, "minimum output length in characters (0 = no minimum)")
+	maxChars := flag.Int("max-chars", 0, "maximum output length in characters (0 = no maximum)")
+	timeout := flag.Duration("timeout", 10*time.Minute, "overall timeout")
+	flag.Parse()
+
+	if *promptPath == "" {
+		fmt.Println("❌ -prompt is required")
+		os.Exit(2)
+	}
+	prompt, err := os.ReadFile(*promptPath)
+	if err != nil {
+		fmt.Printf("❌ prompt: %v\n", err)
+		os.Exit(1)
+	}
+	var prices llm.PriceTable
+	if p, err := llm.LoadPriceTable(*pricingPath); err == nil {
+		prices = p
+	} else if !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("❌ pricing: %v\n", err)
+		os.Exit(1)
+	}
+	reg, err := llm.LoadRegistry(*modelsPath)
+	if err != nil {
+		fmt.Printf("❌ models config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// SIGINT 는 여기서 ctx 취소로 바꿔 넘깁니다 (RunSmokeTest 는 ctx 만 봅니다).
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+
+	err = runner.RunSmokeTest(ctx, reg, runner.SmokeTaskInput{
+		ID:                 *id,
+		Prompt:             string(prompt),
+		CandidateModelTags: splitList(*tags),
+		IterationsPerTier:  *iterations,
+		Prices:             prices,
+		RequiredSections:   splitList(*sections),
+		MinChars:           *minChars,
+		MaxChars:           *maxChars,
+		Concurrency:        *concurrency,
+	})
+	if errors.Is(err, context.Canceled) {
+		fmt.Println("⏹ interrupted; finished calls are in the run directory")
+		os.Exit(130)
+	}
+	if err != nil {
+		fmt.Printf("❌ smoke: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			out = append(out, v)
+		}
+	}
+	return out
+}
@@ -2,30 +2,90 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"speckit-study/internal/llm"
+	"speckit-study/internal/runner"
+	"speckit-study/internal/speckit"
 )
 
 func main() {
 	root := "msaproj" // 필요시 인자/환경변수로 치환 가능
 
-	// 1) 모델 레지스트리 구성
-	reg := llm.NewModelRegistry()
-	reg.RegisterModel("gpt", llm.NewOpenAIClient())
-	reg.RegisterModel("claude", llm.NewAnthropicClient())
-	reg.RegisterModel("gemini", llm.NewGeminiClient())
-	// optional default:
-	reg.RegisterModel("default", llm.NewOpenAIClient())
+	modelsPath := flag.String("models", "models.yaml", "model registry config (overridden by $"+llm.EnvModelsFile+")")
+	cassettePath := flag.String("cassette", "", "record/replay model calls with this cassette file (YAML)")
+	cassetteMode := flag.String("cassette-mode", string(llm.ModeReplay), "cassette mode: record | replay | record_new")
+	fallback := flag.String("fallback", "gpt,claude,gemini", "comma-separated tags to try after a target's own model fails (empty = no fallback)")
+	pricingPath := flag.String("pricing", "pricing.yaml", "pricing table (USD per 1M tokens by model tag); missing file = tokens only")
+	repairs := flag.Int("repairs", llm.DefaultMaxRepairs, "re-prompts allowed when structured output (tasks.yaml) fails schema validation")
+	cacheMode := flag.String("cache", "on", "response cache under <root>/"+llm.DefaultCacheDir+": on | refresh (ignore hits, store results) | off")
+	cacheTTL := flag.Duration("cache-ttl", llm.DefaultCacheTTL, "how long cached responses stay valid (0 = forever)")
+	resume := flag.String("resume", "", "continue an earlier specgen run (run ID, unique prefix or directory): finished targets with unchanged inputs are skipped, failed or stale ones run again")
+	flag.Parse()
+
+	var prices llm.PriceTable
+	if p, err := llm.LoadPriceTable(*pricingPath); err == nil {
+		prices = p
+	} else if !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("❌ pricing: %v\n", err)
+		os.Exit(1)
+	}
+	costs := llm.NewCostTracker(prices)
+
+	// 1) 모델 레지스트리 구성 (models.yaml)
+	reg, err := llm.LoadRegistry(*modelsPath)
+	if err != nil {
+		fmt.Printf("❌ models config: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 카세트: 실제 호출을 기록하거나 기록된 응답으로 재현
+	if *cassettePath != "" {
+		cassette, err := llm.OpenCassette(*cassettePath, llm.CassetteMode(*cassetteMode))
+		if err != nil {
+			fmt.Printf("❌ cassette: %v\n", err)
+			os.Exit(1)
+		}
+		reg.WrapAll(cassette.Wrap)
+		fmt.Printf("📼 cassette %s (%s, %d recorded)\n", *cassettePath, *cassetteMode, cassette.Len())
+	}
+
+	// 응답 캐시: 프롬프트가 바뀌지 않은 호출은 다시 과금하지 않습니다
+	var cache *llm.ResponseCache
+	switch *cacheMode {
+	case "off":
+	case "on", "refresh":
+		cache, err = llm.OpenCache(filepath.Join(root, llm.DefaultCacheDir))
+		if err != nil {
+			fmt.Printf("❌ cache: %v\n", err)
+			os.Exit(1)
+		}
+		cache.TTL = *cacheTTL
+		cache.Bypass = *cacheMode == "refresh"
+		reg.WrapAll(cache.Wrap)
+	default:
+		fmt.Printf("❌ -cache must be on, refresh or off (got %q)\n", *cacheMode)
+		os.Exit(1)
+	}
 
 	// 2) 생성 대상과 모델 매핑
 	type target struct {
 		RelPath    string
 		ModelTag   string
 		SeedPrompt string
+		// TaskFile 이면 자유 텍스트 대신 speckit.TaskFile 스키마로 구조화 출력을 받아 YAML 로 씁니다.
+		TaskFile bool
+		// RequiredSections 는 매니페스트의 검사 결과에만 쓰입니다 (실패해도 실행은 계속).
+		RequiredSections []string
 	}
 
 	targets := []target{
@@ -40,40 +100,320 @@ Include milestones and testing strategy like a concise project plan.`,
 			ModelTag: "gpt",
 			SeedPrompt: `Create a *Specification* for "notification-service".
 Include Goal, Context, Success Criteria. Keep it practical.`,
+			RequiredSections: []string{"Goal", "Context", "Success Criteria"},
 		},
 		{
 			RelPath:    ".specify/notification-service/tasks.yaml",
 			ModelTag:   "claude",
 			SeedPrompt: `Write a minimal tasks.yaml with one task "basic_test" that includes inputs: service="notification-service" and required_sections: ["Goal","Success Criteria"].`,
+			TaskFile:   true,
 		},
 	}
 
-	// 3) 각 파일을 해당 모델로 생성
-	ctx := context.Background()
-	for _, t := range targets {
-		model, ok := reg.GetModel(t.ModelTag)
-		if !ok {
-			fmt.Printf("❌ model not registered: %s\n", t.ModelTag)
+	// 3) 각 파일을 해당 모델로 생성 (스트리밍 지원 모델은 토큰을 도착하는 대로 출력)
+	// 실행 기록: <root>/.specify/_runs/specgen/<타임스탬프>/ 에 manifest.json, checkpoint.json, 프롬프트와 출력 사본
+	// -resume 이면 그 실행 디렉터리를 이어 쓰며, 체크포인트로 끝난 대상을 건너뜁니다.
+	runsDir := filepath.Join(root, ".specify", "_runs")
+	var man *runner.RunManifest
+	var runDir string
+	var checkpoint *runner.Checkpoint
+	if *resume != "" {
+		man, runDir, checkpoint, err = resumeRun(runsDir, *resume)
+		if err != nil {
+			fmt.Printf("❌ resume: %v\n", err)
 			os.Exit(1)
 		}
-		content, err := model.Generate(ctx, t.SeedPrompt)
+		fmt.Printf("⏯ resuming %s (%d/%d steps recorded)\n", man.RunID, len(man.Steps), man.Planned)
+	} else {
+		man, runDir, err = runner.NewRunManifest(runsDir, runner.RunKindSpecgen, "specgen")
 		if err != nil {
-			fmt.Printf("❌ generation error (%s): %v\n", t.RelPath, err)
+			fmt.Printf("❌ run directory: %v\n", err)
 			os.Exit(1)
 		}
-		if err := writeFile(filepath.Join(root, t.RelPath), content); err != nil {
-			fmt.Printf("❌ write error (%s): %v\n", t.RelPath, err)
-			os.Exit(1)
+		checkpoint = runner.NewCheckpoint()
+	}
+	man.Planned = len(targets)
+	// fail : 매니페스트에 실패를 남기고 종료 (끝난 대상은 체크포인트에 남아 -resume 으로 이어 할 수 있음)
+	fail := func(format string, args ...any) {
+		err := fmt.Errorf(format, args...)
+		fmt.Printf("❌ %v\n", err)
+		man.Finish(err, false)
+		if werr := man.Write(runDir); werr != nil {
+			fmt.Printf("❌ manifest write error: %v\n", werr)
+		}
+		fmt.Printf("⏯ resume with: -resume %s\n", man.RunID)
+		os.Exit(1)
+	}
+	// record : 대상 결과를 체크포인트에 남깁니다 (output 은 실행 디렉터리 안의 출력 사본, 없으면 "")
+	record := func(artifact, inputHash, status, output string) {
+		if err := checkpoint.Record(runDir, artifact, inputHash, status, output); err != nil {
+			fail("checkpoint error (%s): %v", artifact, err)
+		}
+		if err := checkpoint.Write(runDir); err != nil {
+			fail("checkpoint write error: %v", err)
 		}
-		fmt.Printf("✅ generated by %-7s → %s\n", t.ModelTag, t.RelPath)
 	}
 
-	// 4) _runs 폴더에 실행 로그 남기기 (타임스탬프 파일)
-	runsDir := filepath.Join(root, ".specify", "_runs")
-	_ = os.MkdirAll(runsDir, 0o755)
-	logPath := filepath.Join(runsDir, time.Now().Format("20060102_150405")+"_specgen.log")
-	_ = os.WriteFile(logPath, []byte("specgen completed"), 0o644)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	var runLog strings.Builder // 대상별 한 줄 (캐시 적중 표시)
+	for _, t := range targets {
+		artifact := filepath.Base(t.RelPath)
+		// 시드 프롬프트는 user 메시지로, SpecKit 시스템 지시문은 GenerateRequest.System 으로 보냅니다.
+		req := llm.PromptRequest(t.SeedPrompt)
+		req.System = speckit.SystemPrompt
+		prompt := req.Prompt()
+		base, ok := reg.GetModel(t.ModelTag)
+		if !ok {
+			fail("model not registered: %s", t.ModelTag)
+		}
+		// 대상 모델이 장애면 -fallback 순서대로 다른 모델을 시도
+		chain := fallbackChain(t.ModelTag, *fallback)
+		resolved := make([]string, len(chain))
+		for i, tag := range chain {
+			resolved[i] = reg.Resolve(tag)
+		}
+		// 입력 해시: 출력 내용을 정하는 값 (검사 조건은 빼고, 바뀌면 이어 하기에서 다시 생성).
+		// 태그가 가리키는 실제 모델, 폴백 순서, models.yaml 내용이 바뀌어도 다시 생성합니다.
+		inputHash := runner.InputHash("specgen", t.RelPath, t.ModelTag, strconv.FormatBool(t.TaskFile), prompt,
+			base.Name(), strings.Join(resolved, ","), reg.ConfigDigest())
+		if *resume != "" {
+			if done, reason := checkpoint.Reusable(runDir, artifact, inputHash); reason != "" {
+				fmt.Printf("🔁 %s: %s\n", t.RelPath, reason)
+			} else {
+				step, _ := man.Step(artifact)
+				if err := restoreTarget(filepath.Join(root, t.RelPath), filepath.Join(runDir, filepath.FromSlash(done.Output))); err != nil {
+					fail("restore error (%s): %v", t.RelPath, err)
+				}
+				// 이 실행의 cost.json 이 실행 전체를 나타내도록 앞서 과금된 사용량을 다시 더합니다.
+				if !step.Cached && step.Usage.TotalTokens() > 0 {
+					costs.Record(artifact, step.Tag, step.Model, step.Usage)
+				}
+				fmt.Fprintf(&runLog, "%s\ttag=%s\tmodel=%s\tsource=checkpoint\n", t.RelPath, step.Tag, step.Model)
+				fmt.Printf("⏭ %s already generated by %s in %s, skipped\n", t.RelPath, step.Tag, man.RunID)
+				continue
+			}
+		}
+		model := llm.NewFallbackClient(reg, chain...)
+		callCtx := llm.WithCallInfo(ctx, llm.CallInfo{Task: "specgen", Artifact: artifact})
+
+		promptPath := filepath.Join(runDir, "prompts", artifact+".txt")
+		if err := writeFile(promptPath, prompt); err != nil {
+			fail("write error (%s): %v", promptPath, err)
+		}
+		step := runner.RunStep{
+			Artifact:   artifact,
+			Tag:        t.ModelTag,
+			PromptHash: runner.PromptHash(prompt),
+			Prompt:     filepath.ToSlash(filepath.Join("prompts", artifact+".txt")),
+			Target:     filepath.ToSlash(t.RelPath),
+			Model:      model.Name(),
+		}
+
+		fmt.Printf("▶ %s (%s)\n", t.RelPath, t.ModelTag)
+		var resp *llm.Response
+		start := time.Now()
+		if t.TaskFile {
+			resp, err = generateTaskFile(callCtx, model, req, *repairs)
+		} else {
+			resp, err = llm.StreamTo(callCtx, model, req, os.Stdout)
+			fmt.Println()
+		}
+		step.Latency = time.Since(start)
+		if err != nil {
+			if ctx.Err() != nil {
+				// SIGINT: 끝난 대상까지만 기록하고 중단
+				fmt.Printf("⏹ cancelled during %s\n", t.RelPath)
+				man.Finish(nil, true)
+				if werr := man.Write(runDir); werr != nil {
+					fmt.Printf("❌ manifest write error: %v\n", werr)
+				}
+				fmt.Printf("⏯ resume with: -resume %s\n", man.RunID)
+				os.Exit(130)
+			}
+			step.Status, step.Error = runner.StepError, llm.RedactSecrets(err.Error())
+			var re *llm.RetryError
+			if errors.As(err, &re) {
+				step.Attempts, step.Retried = re.Attempts, re.Retried
+			}
+			man.SetStep(step)
+			record(artifact, inputHash, step.Status, "")
+			fail("generation error (%s): %v", t.RelPath, err)
+		}
+		step.Tag, step.Model, step.Usage, step.Cached = resp.Tag, resp.Model, resp.Usage, resp.Cached
+		step.Attempts, step.Retried, step.Repairs = max(resp.Attempts, 1), resp.RetriedErrors, resp.Repairs
+
+		outPath := filepath.Join(root, t.RelPath)
+		if err := writeFile(outPath, resp.Text); err != nil {
+			fail("write error (%s): %v", t.RelPath, err)
+		}
+		step.Output = filepath.ToSlash(filepath.Join("outputs", artifact))
+		if err := writeFile(filepath.Join(runDir, step.Output), resp.Text); err != nil {
+			fail("write error (%s): %v", step.Output, err)
+		}
+		step.Validation = validateTarget(outPath, resp.Text, t.TaskFile, t.RequiredSections)
+		switch {
+		case step.Validation != nil && !step.Validation.Passed:
+			step.Status = runner.StepFail
+			fmt.Printf("   ⚠️ validation: %s\n", strings.Join(step.Validation.Problems, "; "))
+		case resp.Cached:
+			step.Status = runner.StepCached
+		default:
+			step.Status = runner.StepOK
+		}
+		man.SetStep(step)
+		record(artifact, inputHash, step.Status, filepath.Join(runDir, step.Output))
+		if err := man.Write(runDir); err != nil {
+			fail("manifest write error: %v", err)
+		}
+
+		source := "model"
+		if resp.Cached {
+			source = "cache"
+		}
+		fmt.Fprintf(&runLog, "%s\ttag=%s\tmodel=%s\tsource=%s\tlatency=%s\n",
+			t.RelPath, resp.Tag, resp.Model, source, step.Latency.Round(time.Millisecond))
+		if resp.Cached {
+			fmt.Printf("♻️ cache hit  %-7s → %s (latency %s)\n", resp.Tag, t.RelPath, step.Latency.Round(time.Millisecond))
+		} else {
+			fmt.Printf("✅ generated by %-7s → %s (latency %s, queued %s)\n",
+				resp.Tag, t.RelPath, step.Latency.Round(time.Millisecond), resp.QueueWait.Round(time.Millisecond))
+		}
+		if resp.Tag != reg.Resolve(t.ModelTag) {
+			fmt.Printf("   ⚠️ fell back from %s to %s\n", t.ModelTag, resp.Tag)
+		}
+		if resp.Repairs > 0 {
+			fmt.Printf("   🔧 schema repairs: %d\n", resp.Repairs)
+		}
+		if resp.Cached {
+			fmt.Printf("   tokens: in=%d out=%d (cached, not billed)\n", resp.Usage.InputTokens, resp.Usage.OutputTokens)
+		} else if resp.Usage.TotalTokens() > 0 {
+			cost := costs.Record(artifact, resp.Tag, resp.Model, resp.Usage)
+			fmt.Printf("   tokens: in=%d out=%d ($%.4f)\n", resp.Usage.InputTokens, resp.Usage.OutputTokens, cost)
+		}
+	}
+
+	// 4) 실행 디렉터리에 실행 로그와 매니페스트 남기기
+	logPath := filepath.Join(runDir, "run.log")
+	if cache != nil {
+		hits, misses := cache.Stats()
+		fmt.Fprintf(&runLog, "cache\thits=%d\tmisses=%d\n", hits, misses)
+	}
+	runLog.WriteString("specgen completed\n")
+	if err := writeFile(logPath, runLog.String()); err != nil {
+		fail("write error (%s): %v", logPath, err)
+	}
 	fmt.Printf("📝 run log: %s\n", logPath)
+
+	// 5) 비용 요약
+	summary := costs.Summary()
+	fmt.Print(summary.String())
+	costPath := filepath.Join(runDir, "cost.json")
+	if err := summary.WriteFile(costPath); err != nil {
+		fail("cost summary write error: %v", err)
+	}
+	fmt.Printf("💰 cost summary: %s\n", costPath)
+
+	man.Finish(nil, false)
+	if err := man.Write(runDir); err != nil {
+		fmt.Printf("❌ manifest write error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("🗂 manifest: %s (run %s)\n", filepath.Join(runDir, runner.ManifestFile), man.RunID)
+}
+
+// resumeRun : 이어 할 specgen 실행을 찾아 매니페스트를 running 상태로 되돌리고 체크포인트를 읽습니다.
+func resumeRun(runsDir, ref string) (*runner.RunManifest, string, *runner.Checkpoint, error) {
+	runs, scanErr := runner.ScanRuns(runsDir)
+	r, err := runner.FindRun(runs, ref)
+	if err != nil {
+		return nil, "", nil, errors.Join(err, scanErr)
+	}
+	if r.Legacy || r.Manifest.Kind != runner.RunKindSpecgen {
+		return nil, "", nil, fmt.Errorf("%s is not a specgen run", r.Manifest.RunID)
+	}
+	cp, err := runner.ReadCheckpoint(r.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, "", nil, fmt.Errorf("%s has no %s (it was recorded before runs could be resumed)", r.Manifest.RunID, runner.CheckpointFile)
+	}
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("%s: %w", r.Manifest.RunID, err)
+	}
+	r.Manifest.Resume()
+	return r.Manifest, r.Dir, cp, nil
+}
+
+// restoreTarget : 건너뛴 대상 파일이 없으면 실행 디렉터리의 출력 사본으로 되살립니다.
+// 이미 있으면 손으로 고쳤을 수 있으므로 내용이 달라도 덮어쓰지 않고 알리기만 합니다.
+func restoreTarget(target, copyPath string) error {
+	want, err := os.ReadFile(copyPath)
+	if err != nil {
+		return err
+	}
+	got, err := os.ReadFile(target)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		fmt.Printf("   ↩ restored %s from the run directory\n", target)
+		return writeFile(target, string(want))
+	case err != nil:
+		return err
+	case string(got) != string(want):
+		fmt.Printf("   ⚠️ %s differs from the recorded output; left as is\n", target)
+	}
+	return nil
+}
+
+// validateTarget : 매니페스트에 남길 검사 결과 (검사할 것이 없으면 nil)
+// tasks.yaml 은 speckit.ValidateTasks, 마크다운은 필수 섹션을 검사합니다.
+func validateTarget(path, text string, taskFile bool, required []string) *runner.StepValidation {
+	var problems []string
+	switch {
+	case taskFile:
+		tf, err := speckit.LoadTasks(path)
+		if err != nil {
+			problems = []string{err.Error()}
+		} else {
+			problems = speckit.ValidateTasks(tf)
+		}
+	case len(required) > 0:
+		for _, sec := range runner.ValidateRequiredSections(runner.NormalizeNewlines(text), required) {
+			problems = append(problems, fmt.Sprintf("missing section %q", sec))
+		}
+	default:
+		return nil
+	}
+	return &runner.StepValidation{Passed: len(problems) == 0, Problems: problems}
+}
+
+// generateTaskFile : 구조화 출력으로 speckit.TaskFile 을 받아 YAML 텍스트로 바꿉니다.
+// 스키마 검증에 실패하면 오류 내용을 알려 주며 최대 repairs 번 다시 요청합니다.
+func generateTaskFile(ctx context.Context, model llm.LLMClient, req llm.GenerateRequest, repairs int) (*llm.Response, error) {
+	tf, resp, err := llm.CompleteAs[speckit.TaskFile](ctx, model, req, repairs)
+	if err != nil {
+		return nil, err
+	}
+	if len(tf.Tasks) == 0 {
+		return nil, fmt.Errorf("structured output has no tasks")
+	}
+	b, err := speckit.MarshalTasks(&tf)
+	if err != nil {
+		return nil, err
+	}
+	resp.Text = string(b)
+	fmt.Print(resp.Text)
+	return resp, nil
+}
+
+// fallbackChain : 대상 태그를 맨 앞에 두고, 나머지 fallback 태그를 중복 없이 이어 붙입니다.
+func fallbackChain(primary, fallback string) []string {
+	chain := []string{primary}
+	for _, tag := range strings.Split(fallback, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !slices.Contains(chain, tag) {
+			chain = append(chain, tag)
+		}
+	}
+	return chain
 }
 
 func writeFile(path string, content string) error {
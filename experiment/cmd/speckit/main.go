@@ -0,0 +1,401 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"speckit-study/internal/runner"
+)
+
+// speckit 은 SpecKit 작업 디렉터리를 다루는 도구입니다. 지금은 실행 기록(runs)만 있습니다.
+//
+//	go run ./cmd/speckit runs list                                   # 최신순 실행 목록
+//	go run ./cmd/speckit runs list -name notif-smoke -model gpt -since 7d -status completed
+//	go run ./cmd/speckit runs show specgen/20261017_182211 -outputs  # 매니페스트 + 출력 내용
+//	go run ./cmd/speckit runs diff specgen/20261016_090000 specgen/20261017_182211 plan.md
+//	go run ./cmd/speckit runs diff -models gpt,claude -try 2 notif-smoke/20261017_101500
+//	go run ./cmd/speckit runs prune -older-than 30d -keep 10 -dry-run
+//	go run ./cmd/speckit runs -dir msaproj/.specify/_runs list       # specgen 의 실행 기록
+//
+// 실행은 ID("<이름>/<타임스탬프>")나 그 앞부분으로 가리킵니다.
+func main() {
+	log.SetFlags(0)
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: speckit runs [-dir path] list | show RUN | diff RUN RUN [ARTIFACT] | diff -models A,B RUN | prune\n")
+	}
+	flag.Parse()
+	if flag.NArg() == 0 || flag.Arg(0) != "runs" {
+		flag.Usage()
+		os.Exit(2)
+	}
+	runsCmd(flag.Args()[1:])
+}
+
+func runsCmd(args []string) {
+	fs := flag.NewFlagSet("runs", flag.ExitOnError)
+	dir := fs.String("dir", filepath.Join(".specify", "_runs"), "runs directory")
+	fs.Usage = flag.Usage
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	runs, err := runner.ScanRuns(*dir)
+	if err != nil {
+		// 읽을 수 없는 실행은 알리고, 읽은 것으로 계속합니다.
+		fmt.Fprintf(os.Stderr, "⚠️ %v\n", err)
+	}
+	sub, rest := fs.Arg(0), fs.Args()[1:]
+
+	switch sub {
+	case "list":
+		listRuns(runs, rest)
+	case "show":
+		showRun(runs, rest)
+	case "diff":
+		diffRuns(runs, rest)
+	case "prune":
+		pruneRuns(runs, rest)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func listRuns(runs []runner.RunInfo, args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	name := fs.String("name", "", "only runs with this name (smoke task ID, feature, specgen)")
+	model := fs.String("model", "", "only runs that called this model tag or model name")
+	status := fs.String("status", "", "only runs with this status (completed, cancelled, failed, running, unknown)")
+	since := fs.String("since", "", "only runs started after this (2006-01-02, or an age like 36h / 7d)")
+	until := fs.String("until", "", "only runs started before this (same formats as -since)")
+	parseArgs(fs, args)
+
+	now := time.Now()
+	f := runner.RunFilter{Name: *name, Model: *model, Status: *status}
+	var err error
+	if f.Since, err = parseWhen(*since, now); err != nil {
+		log.Fatalf("runs: -since: %v", err)
+	}
+	if f.Until, err = parseWhen(*until, now); err != nil {
+		log.Fatalf("runs: -until: %v", err)
+	}
+
+	n := 0
+	for _, r := range runs {
+		if !f.Match(r) {
+			continue
+		}
+		n++
+		m := r.Manifest
+		legacy := ""
+		if r.Legacy {
+			legacy = " (legacy)"
+		}
+		fmt.Printf("%-40s %-8s %-10s %s  %d/%d  %-24s %s%s\n",
+			m.RunID, m.Kind, m.Status, m.StartedAt.Format("2006-01-02 15:04"),
+			len(m.Steps), m.Planned, strings.Join(runTags(m), ","), stepCounts(m), legacy)
+	}
+	if n == 0 {
+		fmt.Println("no runs")
+	}
+}
+
+func showRun(runs []runner.RunInfo, args []string) {
+	fs := flag.NewFlagSet("show", flag.ExitOnError)
+	outputs := fs.Bool("outputs", false, "print each step's output")
+	asJSON := fs.Bool("json", false, "print the (reconstructed) manifest as JSON")
+	pos := parseArgs(fs, args)
+	if len(pos) != 1 {
+		log.Fatalf("usage: speckit runs show [-outputs] [-json] RUN")
+	}
+	r, err := runner.FindRun(runs, pos[0])
+	if err != nil {
+		log.Fatalf("runs: %v", err)
+	}
+	m := r.Manifest
+	if *asJSON {
+		b, _ := json.MarshalIndent(m, "", "  ")
+		fmt.Println(string(b))
+		return
+	}
+
+	fmt.Printf("run:      %s (%s)\n", m.RunID, m.Kind)
+	fmt.Printf("dir:      %s\n", r.Dir)
+	fmt.Printf("status:   %s", m.Status)
+	if r.Legacy {
+		fmt.Print(" (legacy layout, reconstructed)")
+	}
+	fmt.Println()
+	fmt.Printf("started:  %s", m.StartedAt.Format(time.RFC3339))
+	if !m.FinishedAt.IsZero() {
+		fmt.Printf(" (took %s)", m.FinishedAt.Sub(m.StartedAt).Round(time.Millisecond))
+	}
+	fmt.Println()
+	if m.GitCommit != "" {
+		fmt.Printf("commit:   %s\n", m.GitCommit)
+	}
+	if len(m.Args) > 0 {
+		fmt.Printf("args:     %s\n", strings.Join(m.Args, " "))
+	}
+	if m.Error != "" {
+		fmt.Printf("error:    %s\n", m.Error)
+	}
+	fmt.Printf("steps:    %d/%d\n\n", len(m.Steps), m.Planned)
+
+	for _, s := range m.Steps {
+		fmt.Printf("  %-24s %-8s %-28s %-7s %8s  in=%-6d out=%-6d attempts=%d\n",
+			s.Artifact, s.Tag, s.Model, s.Status, s.Latency.Round(time.Millisecond),
+			s.Usage.InputTokens, s.Usage.OutputTokens, s.Attempts)
+		if s.Validation != nil {
+			if ev := s.Validation.Judge; ev != nil {
+				fmt.Printf("      judge %s (%s): %.2f (pass %.2f)\n", ev.Judge, ev.Model, ev.Score, ev.PassScore)
+			}
+			for _, p := range s.Validation.Problems {
+				fmt.Printf("      ✗ %s\n", p)
+			}
+		}
+		if s.Error != "" {
+			fmt.Printf("      error: %s\n", s.Error)
+		}
+		if s.Output != "" {
+			fmt.Printf("      output: %s\n", r.OutputPath(s))
+		}
+	}
+
+	if !*outputs {
+		return
+	}
+	for _, s := range m.Steps {
+		p := r.OutputPath(s)
+		if p == "" {
+			continue
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			fmt.Printf("\n===== %s: %v\n", s.Artifact, err)
+			continue
+		}
+		fmt.Printf("\n===== %s (%s, %s)\n%s\n", s.Artifact, s.Tag, s.Model, strings.TrimRight(string(b), "\n"))
+	}
+}
+
+func diffRuns(runs []runner.RunInfo, args []string) {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	models := fs.String("models", "", "compare two model tags within one run: A,B")
+	try := fs.Int("try", 0, "with -models: which try to compare (0 = first try of each tag)")
+	context := fs.Int("context", 3, "unchanged lines to show around each change")
+	pos := parseArgs(fs, args)
+
+	if *models != "" {
+		tags := strings.Split(*models, ",")
+		if len(pos) != 1 || len(tags) != 2 {
+			log.Fatalf("usage: speckit runs diff -models A,B [-try N] RUN")
+		}
+		r, err := runner.FindRun(runs, pos[0])
+		if err != nil {
+			log.Fatalf("runs: %v", err)
+		}
+		a, err := stepFor(r, strings.TrimSpace(tags[0]), *try)
+		if err != nil {
+			log.Fatalf("runs: %v", err)
+		}
+		b, err := stepFor(r, strings.TrimSpace(tags[1]), *try)
+		if err != nil {
+			log.Fatalf("runs: %v", err)
+		}
+		printDiff(r, a, r, b, *context)
+		return
+	}
+
+	if len(pos) < 2 || len(pos) > 3 {
+		log.Fatalf("usage: speckit runs diff RUN_A RUN_B [ARTIFACT]")
+	}
+	ra, err := runner.FindRun(runs, pos[0])
+	if err != nil {
+		log.Fatalf("runs: %v", err)
+	}
+	rb, err := runner.FindRun(runs, pos[1])
+	if err != nil {
+		log.Fatalf("runs: %v", err)
+	}
+	var artifacts []string
+	if len(pos) == 3 {
+		artifacts = []string{pos[2]}
+	} else {
+		// 산출물을 고르지 않으면 두 실행에 모두 있는 산출물을 전부 비교합니다.
+		for _, s := range ra.Manifest.Steps {
+			if _, ok := rb.Step(s.Artifact); ok {
+				artifacts = append(artifacts, s.Artifact)
+			}
+		}
+		if len(artifacts) == 0 {
+			log.Fatalf("runs: %s and %s have no artifact in common", ra.Manifest.RunID, rb.Manifest.RunID)
+		}
+	}
+	for _, art := range artifacts {
+		a, ok := ra.Step(art)
+		if !ok {
+			log.Fatalf("runs: %s has no artifact %s", ra.Manifest.RunID, art)
+		}
+		b, ok := rb.Step(art)
+		if !ok {
+			log.Fatalf("runs: %s has no artifact %s", rb.Manifest.RunID, art)
+		}
+		printDiff(ra, a, rb, b, *context)
+	}
+}
+
+// stepFor : 실행에서 tag 의 try 번째(0 이면 첫) 단계
+func stepFor(r runner.RunInfo, tag string, try int) (runner.RunStep, error) {
+	for _, s := range r.Manifest.Steps {
+		if s.Tag == tag && (try == 0 || s.Try == try) {
+			return s, nil
+		}
+	}
+	if try > 0 {
+		return runner.RunStep{}, fmt.Errorf("%s has no try %d for tag %s", r.Manifest.RunID, try, tag)
+	}
+	return runner.RunStep{}, fmt.Errorf("%s has no step for tag %s", r.Manifest.RunID, tag)
+}
+
+func printDiff(ra runner.RunInfo, a runner.RunStep, rb runner.RunInfo, b runner.RunStep, context int) {
+	textA, err := readOutput(ra, a)
+	if err != nil {
+		log.Fatalf("runs: %v", err)
+	}
+	textB, err := readOutput(rb, b)
+	if err != nil {
+		log.Fatalf("runs: %v", err)
+	}
+	nameA := fmt.Sprintf("%s:%s (%s)", ra.Manifest.RunID, a.Artifact, a.Tag)
+	nameB := fmt.Sprintf("%s:%s (%s)", rb.Manifest.RunID, b.Artifact, b.Tag)
+	if a.PromptHash != "" && b.PromptHash != "" && a.PromptHash != b.PromptHash {
+		fmt.Printf("# prompts differ (%s vs %s)\n", a.PromptHash, b.PromptHash)
+	}
+	if d := runner.UnifiedDiff(nameA, nameB, textA, textB, context); d != "" {
+		fmt.Print(d)
+	} else {
+		fmt.Printf("= %s and %s are identical\n", nameA, nameB)
+	}
+}
+
+func readOutput(r runner.RunInfo, s runner.RunStep) (string, error) {
+	p := r.OutputPath(s)
+	if p == "" {
+		return "", fmt.Errorf("%s: no saved output for %s", r.Manifest.RunID, s.Artifact)
+	}
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+func pruneRuns(runs []runner.RunInfo, args []string) {
+	fs := flag.NewFlagSet("prune", flag.ExitOnError)
+	olderThan := fs.String("older-than", "", "remove runs started longer ago than this (36h, 30d)")
+	keep := fs.Int("keep", 0, "keep only the newest N runs per name")
+	dryRun := fs.Bool("dry-run", false, "only print what would be removed")
+	parseArgs(fs, args)
+
+	opts := runner.RunPruneOptions{Keep: *keep}
+	if *olderThan != "" {
+		d, err := parseAge(*olderThan)
+		if err != nil {
+			log.Fatalf("runs: -older-than: %v", err)
+		}
+		opts.OlderThan = d
+	}
+	if opts == (runner.RunPruneOptions{}) {
+		log.Fatalf("runs: prune needs -older-than or -keep")
+	}
+	removed, err := runner.PruneRuns(runs, opts, time.Now(), *dryRun)
+	verb := "removed"
+	if *dryRun {
+		verb = "would remove"
+	}
+	for _, r := range removed {
+		fmt.Printf("  %s %s (%s)\n", verb, r.Manifest.RunID, r.Manifest.StartedAt.Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("🧹 %s %d runs\n", verb, len(removed))
+	if err != nil {
+		log.Fatalf("runs: %v", err)
+	}
+}
+
+// parseArgs : 위치 인자 뒤에 온 플래그도 읽습니다 (flag 패키지는 첫 위치 인자에서 멈춤).
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var pos []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return pos
+		}
+		pos = append(pos, args[0])
+		args = args[1:]
+	}
+}
+
+// parseWhen : "2006-01-02" 날짜(로컬) 또는 "36h" / "7d" 같은 나이 (빈 값이면 zero)
+func parseWhen(s string, now time.Time) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	d, err := parseAge(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a date (2006-01-02) nor an age (36h, 7d)", s)
+	}
+	return now.Add(-d), nil
+}
+
+// parseAge : time.ParseDuration 에 일 단위("7d")를 더한 것
+func parseAge(s string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// runTags : 실행에서 호출한 태그 (처음 나온 순서)
+func runTags(m *runner.RunManifest) []string {
+	var tags []string
+	for _, s := range m.Steps {
+		if s.Tag != "" && !slices.Contains(tags, s.Tag) {
+			tags = append(tags, s.Tag)
+		}
+	}
+	return tags
+}
+
+// stepCounts : "ok=3 fail=1 error=1" (0 인 상태는 생략)
+func stepCounts(m *runner.RunManifest) string {
+	counts := map[string]int{}
+	for _, s := range m.Steps {
+		counts[s.Status]++
+	}
+	var parts []string
+	for _, st := range []string{runner.StepOK, runner.StepCached, runner.StepFail, runner.StepError} {
+		if counts[st] > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", st, counts[st]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
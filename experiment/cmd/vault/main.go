@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"speckit-study/internal/llm"
+)
+
+// vault 는 암호화된 로컬 비밀 저장소(secret/vault.enc)를 관리합니다.
+// 패스프레이즈는 $SPECKIT_VAULT_PASSPHRASE 에서 읽고, 값은 표준 입력으로 받습니다 (셸 기록에 남지 않도록).
+//
+//	export SPECKIT_VAULT_PASSPHRASE=...
+//	echo "$KEY" | go run ./cmd/vault set OPENAI_API_KEY
+//	go run ./cmd/vault list
+//	go run ./cmd/vault delete OPENAI_API_KEY
+func main() {
+	path := flag.String("file", llm.DefaultVaultPath, "vault file")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: vault [-file path] set NAME | list | delete NAME\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	pass := os.Getenv(llm.EnvVaultPassphrase)
+	if pass == "" {
+		log.Fatalf("vault: set $%s", llm.EnvVaultPassphrase)
+	}
+	v, err := llm.OpenVault(*path, pass)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	args := flag.Args()
+	if len(args) == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	switch {
+	case args[0] == "list" && len(args) == 1:
+		for _, name := range v.Names() {
+			fmt.Println(name)
+		}
+		return
+	case args[0] == "set" && len(args) == 2:
+		value, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		value = strings.TrimSpace(value)
+		if value == "" {
+			log.Fatalf("vault: no value on stdin (%v)", err)
+		}
+		v.Set(args[1], value)
+	case args[0] == "delete" && len(args) == 2:
+		if _, ok := v.Get(args[1]); !ok {
+			log.Fatalf("vault: %s not found", args[1])
+		}
+		v.Delete(args[1])
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := v.Save(); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%s: %s %s\n", *path, args[0], args[1])
+}
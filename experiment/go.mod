@@ -2,4 +2,4 @@ module speckit-study
 
 go 1.24.5
 
-require gopkg.in/yaml.v3 v3.0.1 // indirect
+require gopkg.in/yaml.v3 v3.0.1
@@ -0,0 +1,127 @@
+// internal/fakellm/embed.go
+package fakellm
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultEmbedDims 는 요청에 차원이 없을 때 돌려주는 벡터 차원입니다.
+const DefaultEmbedDims = 16
+
+// embedText 는 단어를 해시해 차원에 더한 뒤 정규화한 결정적 벡터입니다.
+// 같은 단어를 많이 공유하는 텍스트일수록 코사인 유사도가 높습니다.
+func embedText(text string, dims int) []float32 {
+	if dims <= 0 {
+		dims = DefaultEmbedDims
+	}
+	v := make([]float64, dims)
+	for _, w := range strings.Fields(strings.ToLower(text)) {
+		h := fnv.New32a()
+		h.Write([]byte(strings.Trim(w, `.,;:!?"'()`)))
+		v[h.Sum32()%uint32(dims)]++
+	}
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	out := make([]float32, dims)
+	for i, x := range v {
+		if norm > 0 {
+			out[i] = float32(x / math.Sqrt(norm))
+		}
+	}
+	return out
+}
+
+// embedReply 는 임베딩 요청을 기록하고 스크립트의 오류/지연 응답을 적용합니다. 계속 진행하면 true 입니다.
+// 임베딩 요청의 Prompt 는 입력 텍스트를 줄바꿈으로 이은 값입니다.
+func (s *Server) embedReply(w http.ResponseWriter, r *http.Request, c Call) bool {
+	reply, _ := s.pick(&c)
+	if reply.Latency > 0 {
+		select {
+		case <-time.After(reply.Latency):
+		case <-r.Context().Done():
+			return false
+		}
+	}
+	if reply.Status != 0 && reply.Status != http.StatusOK {
+		writeError(w, c.Provider, reply.Status, fmt.Sprintf("scripted error %d", reply.Status))
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleOpenAIEmbeddings(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Model      string          `json:"model"`
+		Input      json.RawMessage `json:"input"` // 문자열 또는 문자열 배열
+		Dimensions int             `json:"dimensions"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, "openai", http.StatusBadRequest, err.Error())
+		return
+	}
+	var inputs []string
+	if err := json.Unmarshal(body.Input, &inputs); err != nil {
+		var one string
+		if err := json.Unmarshal(body.Input, &one); err != nil {
+			writeError(w, "openai", http.StatusBadRequest, "input must be a string or an array of strings")
+			return
+		}
+		inputs = []string{one}
+	}
+	if !s.embedReply(w, r, Call{Provider: "openai", Model: body.Model, Prompt: strings.Join(inputs, "\n")}) {
+		return
+	}
+	data := make([]map[string]interface{}, len(inputs))
+	tokens := 0
+	for i, in := range inputs {
+		data[i] = map[string]interface{}{"object": "embedding", "index": i, "embedding": embedText(in, body.Dimensions)}
+		tokens += countTokens(in)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"object": "list",
+		"model":  body.Model,
+		"data":   data,
+		"usage":  map[string]int{"prompt_tokens": tokens, "total_tokens": tokens},
+	})
+}
+
+func (s *Server) handleGeminiEmbeddings(w http.ResponseWriter, r *http.Request, model string) {
+	var body struct {
+		Requests []struct {
+			Content struct {
+				Parts []struct {
+					Text string `json:"text"`
+				} `json:"parts"`
+			} `json:"content"`
+			OutputDimensionality int `json:"outputDimensionality"`
+		} `json:"requests"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, "gemini", http.StatusBadRequest, err.Error())
+		return
+	}
+	inputs := make([]string, len(body.Requests))
+	for i, req := range body.Requests {
+		for _, p := range req.Content.Parts {
+			inputs[i] += p.Text
+		}
+	}
+	if !s.embedReply(w, r, Call{Provider: "gemini", Model: model, Prompt: strings.Join(inputs, "\n")}) {
+		return
+	}
+	embeddings := make([]map[string]interface{}, len(inputs))
+	for i, in := range inputs {
+		embeddings[i] = map[string]interface{}{"values": embedText(in, body.Requests[i].OutputDimensionality)}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"embeddings": embeddings})
+}
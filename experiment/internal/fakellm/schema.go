@@ -0,0 +1,66 @@
+// internal/fakellm/schema.go
+package fakellm
+
+import "sort"
+
+// exampleFor 는 JSON Schema 를 만족하는 최소 예시 값을 만듭니다.
+// 구조화 출력 요청에 스크립트가 JSON 을 주지 않았을 때 쓰입니다.
+// object 는 properties 를 모두 채우고, array 는 minItems(최소 1) 개의 항목을 넣습니다.
+func exampleFor(schema map[string]interface{}) interface{} {
+	if enum, ok := schema["enum"].([]interface{}); ok && len(enum) > 0 {
+		return enum[0]
+	}
+	switch schemaType(schema["type"]) {
+	case "object":
+		obj := map[string]interface{}{}
+		props, _ := schema["properties"].(map[string]interface{})
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if ps, ok := props[name].(map[string]interface{}); ok {
+				obj[name] = exampleFor(ps)
+			}
+		}
+		return obj
+	case "array":
+		n := 1
+		if m, ok := schema["minItems"].(float64); ok && int(m) > n {
+			n = int(m)
+		}
+		items, _ := schema["items"].(map[string]interface{})
+		arr := make([]interface{}, n)
+		for i := range arr {
+			arr[i] = exampleFor(items)
+		}
+		return arr
+	case "string":
+		return "example"
+	case "integer", "number":
+		if m, ok := schema["minimum"].(float64); ok {
+			return m
+		}
+		return 0
+	case "boolean":
+		return false
+	case "null":
+		return nil
+	}
+	return "example"
+}
+
+func schemaType(v interface{}) string {
+	switch t := v.(type) {
+	case string:
+		return t
+	case []interface{}:
+		for _, x := range t {
+			if s, ok := x.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,140 @@
+// internal/fakellm/script.go
+package fakellm
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Reply 는 가짜 서버가 돌려줄 응답 한 건입니다.
+// Text 는 text/template 이며 .Provider .Model .Prompt .System .Call 을 사용할 수 있습니다.
+type Reply struct {
+	Text       string        `yaml:"text"`
+	Latency    time.Duration `yaml:"latency"`     // 응답 전 지연
+	ChunkDelay time.Duration `yaml:"chunk_delay"` // 스트리밍 청크 사이 지연
+	Status     int           `yaml:"status"`      // 0 또는 200 이외면 공급자 형식의 오류 응답
+	RetryAfter time.Duration `yaml:"retry_after"` // Status 오류 응답에 붙일 Retry-After
+	Malformed  bool          `yaml:"malformed"`   // 200 이지만 깨진 JSON 본문
+	// ToolCalls 가 있으면 Text 와 함께 공급자 형식의 도구 호출을 돌려줍니다.
+	ToolCalls []ToolCall `yaml:"tool_calls"`
+}
+
+// ToolCall 은 스크립트로 지정하는 도구 호출입니다. Args 는 JSON 객체 문자열입니다 (비우면 {}).
+type ToolCall struct {
+	Name string `yaml:"name"`
+	Args string `yaml:"args"`
+}
+
+func (t ToolCall) args() json.RawMessage {
+	if strings.TrimSpace(t.Args) == "" {
+		return json.RawMessage("{}")
+	}
+	return json.RawMessage(t.Args)
+}
+
+// Rule 은 조건에 맞는 요청에 Reply 를 돌려주는 규칙입니다.
+// 빈 조건은 모든 요청에 맞습니다. Times 가 0 보다 크면 그 횟수만큼만 적용됩니다.
+type Rule struct {
+	Provider string `yaml:"provider"` // "openai", "anthropic", "gemini"
+	Model    string `yaml:"model"`
+	Match    string `yaml:"match"` // 프롬프트에 대한 정규식
+	Times    int    `yaml:"times"`
+	Reply    Reply  `yaml:"reply"`
+
+	re *regexp.Regexp
+}
+
+// Script 는 규칙 목록과 어떤 규칙에도 맞지 않을 때의 기본 응답입니다.
+type Script struct {
+	Rules   []Rule `yaml:"rules"`
+	Default Reply  `yaml:"default"`
+}
+
+// DefaultScript 는 프롬프트를 짧게 되돌려 주는 기본 스크립트입니다.
+func DefaultScript() Script {
+	return Script{Default: Reply{Text: "[{{.Provider}}/{{.Model}}] {{.Prompt}}"}}
+}
+
+// LoadScript 는 YAML 스크립트 파일을 읽습니다.
+func LoadScript(path string) (Script, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return Script{}, fmt.Errorf("read script: %w", err)
+	}
+	var s Script
+	if err := yaml.Unmarshal(b, &s); err != nil {
+		return Script{}, fmt.Errorf("parse script: %w", err)
+	}
+	return s, s.compile()
+}
+
+func (s *Script) compile() error {
+	for i := range s.Rules {
+		if s.Rules[i].Match == "" {
+			continue
+		}
+		re, err := regexp.Compile(s.Rules[i].Match)
+		if err != nil {
+			return fmt.Errorf("rules[%d].match: %w", i, err)
+		}
+		s.Rules[i].re = re
+	}
+	if err := s.Default.checkToolCalls(); err != nil {
+		return fmt.Errorf("default: %w", err)
+	}
+	for i := range s.Rules {
+		if err := s.Rules[i].Reply.checkToolCalls(); err != nil {
+			return fmt.Errorf("rules[%d].reply: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func (r Reply) checkToolCalls() error {
+	for _, tc := range r.ToolCalls {
+		if tc.Name == "" {
+			return fmt.Errorf("tool_calls: name is required")
+		}
+		if !json.Valid(tc.args()) {
+			return fmt.Errorf("tool_calls %s: args is not valid JSON", tc.Name)
+		}
+	}
+	return nil
+}
+
+func (r *Rule) matches(c Call) bool {
+	if r.Provider != "" && r.Provider != c.Provider {
+		return false
+	}
+	if r.Model != "" && r.Model != c.Model {
+		return false
+	}
+	if r.re != nil && !r.re.MatchString(c.Prompt) {
+		return false
+	}
+	return true
+}
+
+// render 는 Reply.Text 템플릿을 호출 정보로 채웁니다.
+func (r Reply) render(c Call) (string, error) {
+	if !strings.Contains(r.Text, "{{") {
+		return r.Text, nil
+	}
+	tmpl, err := template.New("reply").Parse(r.Text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, c); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
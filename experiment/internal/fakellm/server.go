@@ -0,0 +1,657 @@
+// internal/fakellm/server.go
+package fakellm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 각 공급자 API 의 경로 prefix 입니다. 클라이언트 BaseURL = 서버 주소 + prefix.
+const (
+	OpenAIPrefix    = "/v1"
+	AnthropicPrefix = "/v1"
+	GeminiPrefix    = "/v1beta"
+)
+
+// Call 은 서버가 받은 요청 한 건의 요약입니다. Reply 템플릿의 데이터로도 쓰입니다.
+type Call struct {
+	Provider string
+	Model    string
+	Prompt   string // 마지막 user 메시지
+	System   string
+	Stream   bool
+	Call     int // 1부터 시작하는 요청 순번
+
+	// Schema 는 구조화 출력 요청의 JSON Schema 입니다 (없으면 nil).
+	// 맞는 규칙이 없고 Default 응답이 JSON 이 아니면 스키마를 만족하는 최소 예시를 대신 돌려줍니다.
+	Schema map[string]interface{}
+	// Tool 은 Anthropic 의 강제 호출 도구 이름입니다 (응답을 tool_use 블록으로 보냄).
+	Tool string
+	// Tools 는 요청에 선언된 도구 이름, ToolResult 는 마지막 메시지가 도구 결과일 때 그 내용입니다.
+	Tools      []string
+	ToolResult string
+}
+
+// Server 는 OpenAI / Anthropic / Gemini HTTP API 를 흉내 내는 http.Handler 입니다.
+type Server struct {
+	mu     sync.Mutex
+	script Script
+	used   []int
+	calls  []Call
+	mux    *http.ServeMux
+}
+
+// New 는 script 로 응답하는 가짜 서버를 만듭니다.
+func New(script Script) *Server {
+	s := &Server{mux: http.NewServeMux()}
+	s.SetScript(script)
+	s.mux.HandleFunc("POST "+OpenAIPrefix+"/chat/completions", s.handleOpenAI)
+	s.mux.HandleFunc("POST "+OpenAIPrefix+"/embeddings", s.handleOpenAIEmbeddings)
+	s.mux.HandleFunc("POST "+AnthropicPrefix+"/messages", s.handleAnthropic)
+	s.mux.HandleFunc("POST "+GeminiPrefix+"/models/{action}", s.handleGemini)
+	return s
+}
+
+// SetScript 는 스크립트를 교체하고 규칙 사용 횟수를 초기화합니다.
+func (s *Server) SetScript(script Script) {
+	if err := script.compile(); err != nil {
+		panic(fmt.Sprintf("fakellm: %v", err))
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script = script
+	s.used = make([]int, len(script.Rules))
+}
+
+// Calls 는 지금까지 받은 요청 목록의 복사본을 반환합니다.
+func (s *Server) Calls() []Call {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Call(nil), s.calls...)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// pick 은 호출을 기록하고 첫 번째로 맞는 규칙의 Reply 를 고릅니다.
+// 맞는 규칙이 없으면 Default 를 고르고 scripted=false 를 반환합니다.
+func (s *Server) pick(c *Call) (reply Reply, scripted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.Call = len(s.calls) + 1
+	s.calls = append(s.calls, *c)
+	for i := range s.script.Rules {
+		rule := &s.script.Rules[i]
+		if !rule.matches(*c) {
+			continue
+		}
+		if rule.Times > 0 && s.used[i] >= rule.Times {
+			continue
+		}
+		s.used[i]++
+		return rule.Reply, true
+	}
+	return s.script.Default, false
+}
+
+// TestServer 는 httptest.Server 위에서 동작하는 가짜 서버입니다.
+type TestServer struct {
+	*httptest.Server
+	Fake *Server
+}
+
+// NewTestServer 는 로컬 포트에 가짜 서버를 띄웁니다. 사용 후 Close 를 호출하세요.
+func NewTestServer(script Script) *TestServer {
+	fake := New(script)
+	return &TestServer{Server: httptest.NewServer(fake), Fake: fake}
+}
+
+// OpenAIBaseURL 은 llm.OpenAIClient.BaseURL 에 넣을 주소입니다.
+func (t *TestServer) OpenAIBaseURL() string { return t.URL + OpenAIPrefix }
+
+// AnthropicBaseURL 은 llm.AnthropicClient.BaseURL 에 넣을 주소입니다.
+func (t *TestServer) AnthropicBaseURL() string { return t.URL + AnthropicPrefix }
+
+// GeminiBaseURL 은 llm.GeminiClient.BaseURL 에 넣을 주소입니다.
+func (t *TestServer) GeminiBaseURL() string { return t.URL + GeminiPrefix }
+
+// ---- 공급자별 핸들러 ----
+
+func (s *Server) handleOpenAI(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Model    string `json:"model"`
+		Stream   bool   `json:"stream"`
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"`
+		} `json:"messages"`
+		Tools []struct {
+			Function struct {
+				Name string `json:"name"`
+			} `json:"function"`
+		} `json:"tools"`
+		ResponseFormat struct {
+			JSONSchema struct {
+				Schema map[string]interface{} `json:"schema"`
+			} `json:"json_schema"`
+		} `json:"response_format"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, "openai", http.StatusBadRequest, err.Error())
+		return
+	}
+	c := Call{Provider: "openai", Model: body.Model, Stream: body.Stream, Schema: body.ResponseFormat.JSONSchema.Schema}
+	for _, t := range body.Tools {
+		c.Tools = append(c.Tools, t.Function.Name)
+	}
+	for _, m := range body.Messages {
+		text := contentText(m.Content)
+		c.ToolResult = ""
+		switch m.Role {
+		case "system":
+			c.System = text
+		case "user":
+			c.Prompt = text
+		case "tool":
+			c.ToolResult = text
+		}
+	}
+	s.respond(w, r, c)
+}
+
+func (s *Server) handleAnthropic(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Model    string `json:"model"`
+		System   string `json:"system"`
+		Stream   bool   `json:"stream"`
+		Messages []struct {
+			Role    string          `json:"role"`
+			Content json.RawMessage `json:"content"` // 문자열 또는 블록 배열
+		} `json:"messages"`
+		Tools []struct {
+			Name        string                 `json:"name"`
+			InputSchema map[string]interface{} `json:"input_schema"`
+		} `json:"tools"`
+		ToolChoice struct {
+			Type string `json:"type"`
+			Name string `json:"name"`
+		} `json:"tool_choice"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, "anthropic", http.StatusBadRequest, err.Error())
+		return
+	}
+	c := Call{Provider: "anthropic", Model: body.Model, System: body.System, Stream: body.Stream}
+	// input_schema 가 있는 도구를 강제 호출하는 요청은 구조화 출력으로 봅니다.
+	if body.ToolChoice.Type == "tool" && len(body.Tools) == 1 {
+		c.Tool, c.Schema = body.Tools[0].Name, body.Tools[0].InputSchema
+	} else {
+		for _, t := range body.Tools {
+			c.Tools = append(c.Tools, t.Name)
+		}
+	}
+	for _, m := range body.Messages {
+		c.ToolResult = ""
+		if m.Role != "user" {
+			continue
+		}
+		var blocks []struct {
+			Type    string          `json:"type"`
+			Content json.RawMessage `json:"content"`
+		}
+		if json.Unmarshal(m.Content, &blocks) == nil && len(blocks) > 0 && blocks[0].Type == "tool_result" {
+			for _, b := range blocks {
+				c.ToolResult += contentText(b.Content)
+			}
+			continue
+		}
+		c.Prompt = contentText(m.Content)
+	}
+	s.respond(w, r, c)
+}
+
+func (s *Server) handleGemini(w http.ResponseWriter, r *http.Request) {
+	model, method, _ := strings.Cut(r.PathValue("action"), ":")
+	if method == "batchEmbedContents" {
+		s.handleGeminiEmbeddings(w, r, model)
+		return
+	}
+	if method != "generateContent" && method != "streamGenerateContent" {
+		writeError(w, "gemini", http.StatusNotFound, "unknown method: "+method)
+		return
+	}
+	var body struct {
+		Contents []struct {
+			Role  string `json:"role"`
+			Parts []struct {
+				Text             string `json:"text"`
+				FunctionResponse *struct {
+					Response map[string]interface{} `json:"response"`
+				} `json:"functionResponse"`
+			} `json:"parts"`
+		} `json:"contents"`
+		Tools []struct {
+			FunctionDeclarations []struct {
+				Name string `json:"name"`
+			} `json:"functionDeclarations"`
+		} `json:"tools"`
+		SystemInstruction struct {
+			Parts []struct {
+				Text string `json:"text"`
+			} `json:"parts"`
+		} `json:"systemInstruction"`
+		GenerationConfig struct {
+			ResponseJSONSchema map[string]interface{} `json:"responseJsonSchema"`
+		} `json:"generationConfig"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, "gemini", http.StatusBadRequest, err.Error())
+		return
+	}
+	c := Call{Provider: "gemini", Model: model, Stream: method == "streamGenerateContent", Schema: body.GenerationConfig.ResponseJSONSchema}
+	for _, p := range body.SystemInstruction.Parts {
+		c.System += p.Text
+	}
+	for _, t := range body.Tools {
+		for _, d := range t.FunctionDeclarations {
+			c.Tools = append(c.Tools, d.Name)
+		}
+	}
+	for _, content := range body.Contents {
+		c.ToolResult = ""
+		if content.Role != "user" && content.Role != "" {
+			continue
+		}
+		if len(content.Parts) > 0 && content.Parts[0].FunctionResponse != nil {
+			for _, p := range content.Parts {
+				if p.FunctionResponse != nil {
+					for _, v := range p.FunctionResponse.Response {
+						c.ToolResult += fmt.Sprint(v)
+					}
+				}
+			}
+			continue
+		}
+		c.Prompt = ""
+		for _, p := range content.Parts {
+			c.Prompt += p.Text
+		}
+	}
+	s.respond(w, r, c)
+}
+
+// respond 는 스크립트에서 고른 Reply 를 공급자 형식으로 씁니다.
+func (s *Server) respond(w http.ResponseWriter, r *http.Request, c Call) {
+	reply, scripted := s.pick(&c)
+
+	if reply.Latency > 0 {
+		select {
+		case <-time.After(reply.Latency):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	if reply.Status != 0 && reply.Status != http.StatusOK {
+		if reply.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.FormatFloat(reply.RetryAfter.Seconds(), 'f', -1, 64))
+		}
+		writeError(w, c.Provider, reply.Status, fmt.Sprintf("scripted error %d", reply.Status))
+		return
+	}
+	if reply.Malformed {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices": [{"message": {"content": "trunc`))
+		return
+	}
+
+	text, err := reply.render(c)
+	if err != nil {
+		writeError(w, c.Provider, http.StatusInternalServerError, "template: "+err.Error())
+		return
+	}
+	if c.Schema != nil && !scripted && !json.Valid([]byte(strings.TrimSpace(text))) {
+		b, _ := json.Marshal(exampleFor(c.Schema))
+		text = string(b)
+	}
+	in, out := countTokens(c.System+" "+c.Prompt+" "+c.ToolResult), countTokens(text)
+
+	// 도구 호출은 도구를 선언한 요청에만 돌려줍니다.
+	var calls []ToolCall
+	if len(c.Tools) > 0 {
+		calls = reply.ToolCalls
+	}
+	if c.Stream {
+		streamReply(w, r, c, text, calls, reply.ChunkDelay, in, out)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(completion(c, text, calls, in, out))
+}
+
+// contentText 는 메시지 content 가 문자열이면 그대로, 블록 배열이면 텍스트를 이어 붙여 반환합니다.
+func contentText(raw json.RawMessage) string {
+	var s string
+	if json.Unmarshal(raw, &s) == nil {
+		return s
+	}
+	var blocks []struct {
+		Text string `json:"text"`
+	}
+	json.Unmarshal(raw, &blocks)
+	var sb strings.Builder
+	for _, b := range blocks {
+		sb.WriteString(b.Text)
+	}
+	return sb.String()
+}
+
+func toolCallID(c Call, i int) string { return fmt.Sprintf("call_fake_%d_%d", c.Call, i+1) }
+
+// completion 은 스트리밍이 아닌 응답 본문을 만듭니다.
+func completion(c Call, text string, calls []ToolCall, in, out int) interface{} {
+	switch c.Provider {
+	case "anthropic":
+		content := []map[string]interface{}{{"type": "text", "text": text}}
+		stop := "end_turn"
+		if c.Tool != "" && json.Valid([]byte(text)) {
+			content = []map[string]interface{}{{
+				"type": "tool_use", "id": fmt.Sprintf("toolu_fake_%d", c.Call),
+				"name": c.Tool, "input": json.RawMessage(text),
+			}}
+			stop = "tool_use"
+		}
+		if text == "" && len(calls) > 0 {
+			content = content[:0]
+		}
+		for i, tc := range calls {
+			content = append(content, map[string]interface{}{
+				"type": "tool_use", "id": toolCallID(c, i), "name": tc.Name, "input": tc.args(),
+			})
+			stop = "tool_use"
+		}
+		return map[string]interface{}{
+			"id":          fmt.Sprintf("msg_fake_%d", c.Call),
+			"type":        "message",
+			"role":        "assistant",
+			"model":       c.Model,
+			"content":     content,
+			"stop_reason": stop,
+			"usage":       map[string]int{"input_tokens": in, "output_tokens": out},
+		}
+	case "gemini":
+		return geminiChunk(text, calls, "STOP", map[string]int{
+			"promptTokenCount": in, "candidatesTokenCount": out, "totalTokenCount": in + out,
+		})
+	default:
+		message := map[string]interface{}{"role": "assistant", "content": text}
+		finish := "stop"
+		if len(calls) > 0 {
+			message["tool_calls"] = openAIToolCalls(c, calls)
+			finish = "tool_calls"
+		}
+		return map[string]interface{}{
+			"id":     fmt.Sprintf("chatcmpl-fake-%d", c.Call),
+			"object": "chat.completion",
+			"model":  c.Model,
+			"choices": []map[string]interface{}{{
+				"index":         0,
+				"message":       message,
+				"finish_reason": finish,
+			}},
+			"usage": map[string]int{"prompt_tokens": in, "completion_tokens": out, "total_tokens": in + out},
+		}
+	}
+}
+
+func openAIToolCalls(c Call, calls []ToolCall) []map[string]interface{} {
+	out := make([]map[string]interface{}, len(calls))
+	for i, tc := range calls {
+		out[i] = map[string]interface{}{
+			"index": i, "id": toolCallID(c, i), "type": "function",
+			"function": map[string]string{"name": tc.Name, "arguments": string(tc.args())},
+		}
+	}
+	return out
+}
+
+func geminiChunk(text string, calls []ToolCall, finish string, usage map[string]int) map[string]interface{} {
+	parts := []map[string]interface{}{}
+	if text != "" || len(calls) == 0 {
+		parts = append(parts, map[string]interface{}{"text": text})
+	}
+	for _, tc := range calls {
+		parts = append(parts, map[string]interface{}{
+			"functionCall": map[string]interface{}{"name": tc.Name, "args": tc.args()},
+		})
+	}
+	cand := map[string]interface{}{
+		"content": map[string]interface{}{
+			"role":  "model",
+			"parts": parts,
+		},
+	}
+	if finish != "" {
+		cand["finishReason"] = finish
+	}
+	chunk := map[string]interface{}{"candidates": []interface{}{cand}}
+	if usage != nil {
+		chunk["usageMetadata"] = usage
+	}
+	return chunk
+}
+
+var chunkRe = regexp.MustCompile(`\S+\s*|\s+`)
+
+// streamReply 는 text 를 단어 단위 청크로 나눠 공급자 SSE 형식으로 보냅니다.
+// 도구 호출은 텍스트 뒤에 한 번에 보냅니다.
+func streamReply(w http.ResponseWriter, r *http.Request, c Call, text string, calls []ToolCall, delay time.Duration, in, out int) {
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	flusher, _ := w.(http.Flusher)
+
+	send := func(event string, v interface{}) bool {
+		if event != "" {
+			fmt.Fprintf(w, "event: %s\n", event)
+		}
+		switch d := v.(type) {
+		case string:
+			fmt.Fprintf(w, "data: %s\n\n", d)
+		default:
+			b, _ := json.Marshal(d)
+			fmt.Fprintf(w, "data: %s\n\n", b)
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		return r.Context().Err() == nil
+	}
+	wait := func() bool {
+		if delay <= 0 {
+			return true
+		}
+		select {
+		case <-time.After(delay):
+			return true
+		case <-r.Context().Done():
+			return false
+		}
+	}
+	chunks := chunkRe.FindAllString(text, -1)
+
+	switch c.Provider {
+	case "anthropic":
+		send("message_start", map[string]interface{}{
+			"type": "message_start",
+			"message": map[string]interface{}{
+				"id": fmt.Sprintf("msg_fake_%d", c.Call), "type": "message", "role": "assistant",
+				"model": c.Model, "content": []interface{}{},
+				"usage": map[string]int{"input_tokens": in, "output_tokens": 0},
+			},
+		})
+		block := map[string]interface{}{"type": "text", "text": ""}
+		deltaType, deltaKey := "text_delta", "text"
+		if c.Tool != "" && json.Valid([]byte(text)) {
+			block = map[string]interface{}{"type": "tool_use", "id": fmt.Sprintf("toolu_fake_%d", c.Call), "name": c.Tool, "input": map[string]interface{}{}}
+			deltaType, deltaKey = "input_json_delta", "partial_json"
+		}
+		send("content_block_start", map[string]interface{}{
+			"type": "content_block_start", "index": 0,
+			"content_block": block,
+		})
+		for _, ch := range chunks {
+			if !wait() || !send("content_block_delta", map[string]interface{}{
+				"type": "content_block_delta", "index": 0,
+				"delta": map[string]string{"type": deltaType, deltaKey: ch},
+			}) {
+				return
+			}
+		}
+		send("content_block_stop", map[string]interface{}{"type": "content_block_stop", "index": 0})
+		stop := "end_turn"
+		for i, tc := range calls {
+			send("content_block_start", map[string]interface{}{
+				"type": "content_block_start", "index": i + 1,
+				"content_block": map[string]interface{}{"type": "tool_use", "id": toolCallID(c, i), "name": tc.Name, "input": map[string]interface{}{}},
+			})
+			send("content_block_delta", map[string]interface{}{
+				"type": "content_block_delta", "index": i + 1,
+				"delta": map[string]string{"type": "input_json_delta", "partial_json": string(tc.args())},
+			})
+			send("content_block_stop", map[string]interface{}{"type": "content_block_stop", "index": i + 1})
+			stop = "tool_use"
+		}
+		send("message_delta", map[string]interface{}{
+			"type":  "message_delta",
+			"delta": map[string]string{"stop_reason": stop},
+			"usage": map[string]int{"output_tokens": out},
+		})
+		send("message_stop", map[string]string{"type": "message_stop"})
+	case "gemini":
+		if len(chunks) == 0 {
+			chunks = []string{""}
+		}
+		for i, ch := range chunks {
+			var usage map[string]int
+			var last []ToolCall
+			finish := ""
+			if i == len(chunks)-1 {
+				usage = map[string]int{"promptTokenCount": in, "candidatesTokenCount": out, "totalTokenCount": in + out}
+				finish = "STOP"
+				last = calls
+			}
+			if !wait() || !send("", geminiChunk(ch, last, finish, usage)) {
+				return
+			}
+		}
+	default:
+		for _, ch := range chunks {
+			if !wait() || !send("", map[string]interface{}{
+				"object":  "chat.completion.chunk",
+				"model":   c.Model,
+				"choices": []map[string]interface{}{{"index": 0, "delta": map[string]string{"content": ch}}},
+			}) {
+				return
+			}
+		}
+		if len(calls) > 0 {
+			send("", map[string]interface{}{
+				"object": "chat.completion.chunk",
+				"model":  c.Model,
+				"choices": []map[string]interface{}{{
+					"index": 0, "delta": map[string]interface{}{"tool_calls": openAIToolCalls(c, calls)},
+					"finish_reason": "tool_calls",
+				}},
+			})
+		}
+		send("", map[string]interface{}{
+			"object":  "chat.completion.chunk",
+			"model":   c.Model,
+			"choices": []interface{}{},
+			"usage":   map[string]int{"prompt_tokens": in, "completion_tokens": out, "total_tokens": in + out},
+		})
+		send("", "[DONE]")
+	}
+}
+
+// writeError 는 공급자별 오류 본문 형식으로 status 응답을 씁니다.
+func writeError(w http.ResponseWriter, provider string, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	var body interface{}
+	switch provider {
+	case "anthropic":
+		body = map[string]interface{}{
+			"type":  "error",
+			"error": map[string]string{"type": anthropicErrorType(status), "message": msg},
+		}
+	case "gemini":
+		body = map[string]interface{}{
+			"error": map[string]interface{}{"code": status, "message": msg, "status": geminiStatus(status)},
+		}
+	default:
+		body = map[string]interface{}{
+			"error": map[string]interface{}{"message": msg, "type": openAIErrorType(status), "code": nil},
+		}
+	}
+	json.NewEncoder(w).Encode(body)
+}
+
+func anthropicErrorType(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return "authentication_error"
+	case status == http.StatusForbidden:
+		return "permission_error"
+	case status == http.StatusTooManyRequests:
+		return "rate_limit_error"
+	case status == 529:
+		return "overloaded_error"
+	case status >= 500:
+		return "api_error"
+	}
+	return "invalid_request_error"
+}
+
+func geminiStatus(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return "UNAUTHENTICATED"
+	case status == http.StatusForbidden:
+		return "PERMISSION_DENIED"
+	case status == http.StatusNotFound:
+		return "NOT_FOUND"
+	case status == http.StatusTooManyRequests:
+		return "RESOURCE_EXHAUSTED"
+	case status == http.StatusServiceUnavailable:
+		return "UNAVAILABLE"
+	case status >= 500:
+		return "INTERNAL"
+	}
+	return "INVALID_ARGUMENT"
+}
+
+func openAIErrorType(status int) string {
+	switch {
+	case status == http.StatusUnauthorized:
+		return "invalid_request_error"
+	case status == http.StatusTooManyRequests:
+		return "rate_limit_exceeded"
+	case status >= 500:
+		return "server_error"
+	}
+	return "invalid_request_error"
+}
+
+// countTokens 는 공백 기준 단어 수로 토큰 수를 대략 셉니다.
+func countTokens(s string) int {
+	return len(strings.Fields(s))
+}
@@ -0,0 +1,123 @@
+// internal/fakellm/server_test.go
+package fakellm_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"speckit-study/internal/fakellm"
+)
+
+// chat 은 OpenAI 형식 요청을 보내고 상태 코드와 응답 텍스트를 돌려줍니다.
+func chat(t *testing.T, srv *fakellm.TestServer, body string) (int, string) {
+	t.Helper()
+	resp, err := http.Post(srv.OpenAIBaseURL()+"/chat/completions", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var decoded struct {
+		Choices []struct {
+			Message struct {
+				Content string `json:"content"`
+			} `json:"message"`
+		} `json:"choices"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Choices) == 0 {
+		return resp.StatusCode, ""
+	}
+	return resp.StatusCode, decoded.Choices[0].Message.Content
+}
+
+func userMessage(model, prompt string) string {
+	b, _ := json.Marshal(map[string]any{
+		"model":    model,
+		"messages": []map[string]string{{"role": "user", "content": prompt}},
+	})
+	return string(b)
+}
+
+func TestScriptRules(t *testing.T) {
+	srv := fakellm.NewTestServer(fakellm.Script{
+		Rules: []fakellm.Rule{
+			{Match: "flaky", Times: 1, Reply: fakellm.Reply{Status: 503}},
+			{Model: "m-a", Match: "^plan", Reply: fakellm.Reply{Text: "plan for {{.Model}}"}},
+		},
+		Default: fakellm.Reply{Text: "default: {{.Prompt}}"},
+	})
+	defer srv.Close()
+
+	cases := []struct {
+		model, prompt string
+		status        int
+		text          string
+	}{
+		{"m-a", "plan the rollout", 200, "plan for m-a"},
+		{"m-b", "plan the rollout", 200, "default: plan the rollout"}, // 모델이 다르면 규칙에 맞지 않음
+		{"m-a", "flaky call", 503, ""},
+		{"m-a", "flaky call", 200, "default: flaky call"}, // Times 를 다 쓴 규칙은 건너뜀
+	}
+	for _, c := range cases {
+		status, text := chat(t, srv, userMessage(c.model, c.prompt))
+		if status != c.status || text != c.text {
+			t.Errorf("%s %q: got %d %q, want %d %q", c.model, c.prompt, status, text, c.status, c.text)
+		}
+	}
+	if got := len(srv.Fake.Calls()); got != len(cases) {
+		t.Errorf("recorded %d calls, want %d", got, len(cases))
+	}
+}
+
+// 규칙에 맞지 않은 구조화 출력 요청은 스키마를 만족하는 최소 예시로 답합니다.
+func TestSchemaExampleReply(t *testing.T) {
+	srv := fakellm.NewTestServer(fakellm.DefaultScript())
+	defer srv.Close()
+
+	body := `{"model":"m","messages":[{"role":"user","content":"score it"}],
+		"response_format":{"type":"json_schema","json_schema":{"name":"score","schema":{
+			"type":"object","required":["score","tags"],
+			"properties":{"score":{"type":"integer"},"tags":{"type":"array","items":{"type":"string"}}}}}}}`
+	_, text := chat(t, srv, body)
+	var out map[string]any
+	if err := json.Unmarshal([]byte(text), &out); err != nil {
+		t.Fatalf("reply %q is not JSON: %v", text, err)
+	}
+	if _, ok := out["score"].(float64); !ok {
+		t.Errorf("score missing or not a number: %v", out)
+	}
+	if _, ok := out["tags"].([]any); !ok {
+		t.Errorf("tags missing or not an array: %v", out)
+	}
+}
+
+func TestLoadScript(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.yaml")
+	os.WriteFile(good, []byte("rules:\n  - match: \"^hi\"\n    times: 2\n    reply:\n      status: 429\n      retry_after: 2s\ndefault:\n  text: ok\n"), 0o644)
+	s, err := fakellm.LoadScript(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Rules) != 1 || s.Rules[0].Times != 2 || s.Rules[0].Reply.Status != 429 || s.Rules[0].Reply.RetryAfter.Seconds() != 2 {
+		t.Errorf("parsed %+v", s)
+	}
+
+	for name, doc := range map[string]string{
+		"bad regex":     "rules:\n  - match: \"(\"\n",
+		"tool no name":  "default:\n  tool_calls:\n    - args: '{}'\n",
+		"tool bad args": "default:\n  tool_calls:\n    - name: f\n      args: '{'\n",
+	} {
+		p := filepath.Join(dir, strings.ReplaceAll(name, " ", "_")+".yaml")
+		os.WriteFile(p, []byte(doc), 0o644)
+		if _, err := fakellm.LoadScript(p); err == nil {
+			t.Errorf("%s: want an error", name)
+		}
+	}
+}
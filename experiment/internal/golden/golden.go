@@ -0,0 +1,57 @@
+// internal/golden/golden.go
+
+// Package golden 은 테스트 출력을 저장소에 체크인한 골든 파일과 비교합니다.
+// 출력이 바뀌면 unified diff 로 실패하고, -update 로 실행하면 골든 파일을 현재 출력으로 다시 씁니다.
+//
+//	go test ./internal/speckit ./internal/runner -update
+package golden
+
+import (
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"speckit-study/internal/runner"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files with the current output")
+
+// Updating 은 -update 로 실행 중인지 알려 줍니다.
+func Updating() bool { return *update }
+
+// Assert 는 got 을 path 의 골든 파일과 비교합니다 (개행 형식 차이는 무시).
+// -update 면 비교하지 않고 got 으로 파일을 씁니다. 골든 파일이 없으면 -update 로 만들라는 메시지로 실패합니다.
+func Assert(t testing.TB, path, got string) {
+	t.Helper()
+	got = runner.NormalizeNewlines(got)
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("golden file %s does not exist; run the test with -update to create it", path)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := runner.NormalizeNewlines(string(b))
+	if got == want {
+		return
+	}
+	diff := runner.UnifiedDiff(path, "got", want, got, 3)
+	if diff == "" { // 줄 내용은 같고 마지막 개행만 다름
+		diff = "(outputs differ only in the trailing newline)\n"
+	}
+	t.Errorf("output does not match golden file %s (run with -update if the change is intended):\n%s",
+		path, strings.TrimRight(diff, "\n"))
+}
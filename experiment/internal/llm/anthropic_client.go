@@ -5,64 +5,321 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
+	"fmt"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 )
 
+// AnthropicBaseURL 은 Anthropic API 의 기본 주소입니다. ANTHROPIC_BASE_URL 환경 변수로 바꿀 수 있습니다.
+const AnthropicBaseURL = "https://api.anthropic.com/v1"
+
 type AnthropicClient struct {
-	Model  string
-	apiKey string
-	client *http.Client
+	Model   string
+	BaseURL string // 예: "https://api.anthropic.com/v1", 테스트 시 fakellm 주소
+	Retry   RetryPolicy
+	apiKey  *secretRef
+	client  *http.Client
 }
 
 func NewAnthropicClient(model string) *AnthropicClient {
 	return &AnthropicClient{
-		Model:  model, // 예: "claude-3.5-sonnet-4.5"
-		apiKey: os.Getenv("ANTHROPIC_API_KEY"),
-		client: &http.Client{Timeout: 30 * time.Second},
+		Model:   model, // 예: "claude-3.5-sonnet-4.5"
+		BaseURL: envOr("ANTHROPIC_BASE_URL", AnthropicBaseURL),
+		Retry:   DefaultRetryPolicy,
+		apiKey:  secretNamed("ANTHROPIC_API_KEY"),
+		client:  &http.Client{Timeout: 30 * time.Second},
 	}
 }
 
 func (c *AnthropicClient) Name() string { return c.Model }
 
 func (c *AnthropicClient) Generate(ctx context.Context, prompt string) (string, error) {
+	resp, err := c.Complete(ctx, PromptRequest(prompt))
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// requestBody 는 GenerateRequest 를 Messages API 요청 본문으로 변환합니다.
+// 시스템 프롬프트는 최상위 "system" 필드로 보내며, Seed 는 지원되지 않아 무시합니다.
+// ResponseFormat 은 input_schema 가 그 스키마인 도구 하나를 강제 호출(tool_choice)하는 방식으로 보냅니다.
+func (c *AnthropicClient) requestBody(r GenerateRequest) map[string]interface{} {
+	messages := anthropicMessages(r.ChatMessages())
+
 	reqBody := map[string]interface{}{
 		"model":      c.Model,
-		"max_tokens": 2048,
-		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
-		},
+		"max_tokens": r.maxTokens(),
+		"messages":   messages,
+	}
+	if sys := r.SystemText(); sys != "" {
+		reqBody["system"] = sys
+	}
+	if r.Temperature != nil {
+		reqBody["temperature"] = *r.Temperature
 	}
+	if r.TopP != nil {
+		reqBody["top_p"] = *r.TopP
+	}
+	if len(r.Stop) > 0 {
+		reqBody["stop_sequences"] = r.Stop
+	}
+	if f := r.ResponseFormat; f != nil && f.isObject() {
+		reqBody["tools"] = []map[string]interface{}{{
+			"name":         f.name(),
+			"description":  "Return the response as structured data.",
+			"input_schema": f.Schema,
+		}}
+		reqBody["tool_choice"] = map[string]string{"type": "tool", "name": f.name()}
+	} else if len(r.Tools) > 0 {
+		tools := make([]map[string]interface{}, len(r.Tools))
+		for i, t := range r.Tools {
+			tools[i] = map[string]interface{}{
+				"name":         t.Name,
+				"description":  t.Description,
+				"input_schema": t.toolParameters(),
+			}
+		}
+		reqBody["tools"] = tools
+		switch r.ToolChoice {
+		case "":
+		case "auto", "none":
+			reqBody["tool_choice"] = map[string]string{"type": r.ToolChoice}
+		case "required":
+			reqBody["tool_choice"] = map[string]string{"type": "any"}
+		default:
+			reqBody["tool_choice"] = map[string]string{"type": "tool", "name": r.ToolChoice}
+		}
+	}
+	return reqBody
+}
 
+// anthropicMessages 는 대화 메시지를 Messages API 형식으로 바꿉니다.
+// 도구 호출은 assistant 의 tool_use 블록, 도구 결과는 user 메시지의 tool_result 블록이 되며
+// 연속된 도구 결과는 하나의 user 메시지로 합칩니다.
+func anthropicMessages(chat []Message) []map[string]interface{} {
+	messages := make([]map[string]interface{}, 0, len(chat))
+	for _, m := range chat {
+		switch {
+		case m.Role == RoleTool:
+			block := map[string]interface{}{
+				"type":        "tool_result",
+				"tool_use_id": m.ToolCallID,
+				"content":     m.Content,
+			}
+			if m.ToolError {
+				block["is_error"] = true
+			}
+			if n := len(messages); n > 0 && messages[n-1]["role"] == "user" {
+				if blocks, ok := messages[n-1]["content"].([]map[string]interface{}); ok {
+					messages[n-1]["content"] = append(blocks, block)
+					continue
+				}
+			}
+			messages = append(messages, map[string]interface{}{
+				"role":    "user",
+				"content": []map[string]interface{}{block},
+			})
+		case len(m.ToolCalls) > 0:
+			var blocks []map[string]interface{}
+			if m.Content != "" {
+				blocks = append(blocks, map[string]interface{}{"type": "text", "text": m.Content})
+			}
+			for _, tc := range m.ToolCalls {
+				blocks = append(blocks, map[string]interface{}{
+					"type":  "tool_use",
+					"id":    tc.ID,
+					"name":  tc.Name,
+					"input": tc.args(),
+				})
+			}
+			messages = append(messages, map[string]interface{}{"role": string(m.Role), "content": blocks})
+		default:
+			messages = append(messages, map[string]interface{}{"role": string(m.Role), "content": m.Content})
+		}
+	}
+	return messages
+}
+
+func (c *AnthropicClient) newRequest(ctx context.Context, reqBody map[string]interface{}) (*http.Request, error) {
 	b, _ := json.Marshal(reqBody)
 	req, err := http.NewRequestWithContext(ctx, "POST",
-		"https://api.anthropic.com/v1/messages", bytes.NewReader(b))
+		joinURL(c.BaseURL, "messages"), bytes.NewReader(b))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	req.Header.Set("x-api-key", c.apiKey)
+	key, err := c.apiKey.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-api-key", key)
 	// Anthropic API는 `anthropic-version` 헤더로 버전을 명시하도록 요구합니다. :contentReference[oaicite:13]{index=13}
 	req.Header.Set("anthropic-version", "2023-06-01")
 	req.Header.Set("content-type", "application/json")
+	return req, nil
+}
 
-	resp, err := c.client.Do(req)
-	if err != nil {
-		return "", err
-	}
-	defer resp.Body.Close()
+// Complete 는 GenerateRequest 를 Messages API 로 보냅니다.
+func (c *AnthropicClient) Complete(ctx context.Context, r GenerateRequest) (*Response, error) {
+	reqBody := c.requestBody(r)
 
 	var decoded struct {
 		Content []struct {
-			Text string `json:"text"`
+			Type  string          `json:"type"`
+			Text  string          `json:"text"`
+			ID    string          `json:"id"`    // tool_use
+			Name  string          `json:"name"`  // tool_use
+			Input json.RawMessage `json:"input"` // tool_use (도구 호출 또는 구조화 출력)
 		} `json:"content"`
+		StopReason string `json:"stop_reason"`
+		Usage      struct {
+			InputTokens          int `json:"input_tokens"`
+			OutputTokens         int `json:"output_tokens"`
+			CacheReadInputTokens int `json:"cache_read_input_tokens"`
+		} `json:"usage"`
+	}
+	stats, err := doJSON(ctx, c.client, c.Retry, "anthropic", func() (*http.Request, error) {
+		return c.newRequest(ctx, reqBody)
+	}, &decoded)
+	if err != nil {
+		return nil, err
 	}
-	json.NewDecoder(resp.Body).Decode(&decoded)
 
 	if len(decoded.Content) == 0 {
-		return "", errors.New("no content found")
+		return nil, errEmptyResponse("anthropic", "no content found")
 	}
-	return decoded.Content[0].Text, nil
+	var sb strings.Builder
+	var calls []ToolCall
+	for _, block := range decoded.Content {
+		switch block.Type {
+		case "", "text":
+			sb.WriteString(block.Text)
+		case "tool_use":
+			if r.ResponseFormat != nil {
+				sb.Reset()
+				sb.Write(block.Input)
+			} else {
+				calls = append(calls, ToolCall{ID: block.ID, Name: block.Name, Arguments: block.Input})
+			}
+		}
+	}
+	return &Response{
+		Text:          sb.String(),
+		Model:         c.Model,
+		FinishReason:  decoded.StopReason,
+		Usage:         anthropicUsage(decoded.Usage.InputTokens, decoded.Usage.OutputTokens, decoded.Usage.CacheReadInputTokens),
+		Attempts:      stats.Attempts,
+		RetriedErrors: stats.Retried,
+		ToolCalls:     calls,
+	}, nil
+}
+
+// Stream 은 Messages API 의 SSE 스트림(stream=true)으로 토큰을 전달합니다.
+// 입력 토큰은 message_start, 출력 토큰은 message_delta 이벤트에서 집계합니다.
+func (c *AnthropicClient) Stream(ctx context.Context, r GenerateRequest) (<-chan StreamEvent, error) {
+	reqBody := c.requestBody(r)
+	reqBody["stream"] = true
+
+	resp, stats, err := doWithRetry(ctx, streamingHTTPClient(c.client), c.Retry, "anthropic", func() (*http.Request, error) {
+		req, err := c.newRequest(ctx, reqBody)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("accept", "text/event-stream")
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan StreamEvent)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+
+		var usage Usage
+		var streamErr error
+		var calls []ToolCall
+		toolBlock := -1 // 진행 중인 tool_use 블록의 calls 인덱스
+		err := readSSE(resp.Body, func(ev sseEvent) bool {
+			var payload struct {
+				Type         string `json:"type"`
+				ContentBlock struct {
+					Type string `json:"type"`
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"content_block"`
+				Message struct {
+					Usage struct {
+						InputTokens          int `json:"input_tokens"`
+						CacheReadInputTokens int `json:"cache_read_input_tokens"`
+					} `json:"usage"`
+				} `json:"message"`
+				Delta struct {
+					Type        string `json:"type"`
+					Text        string `json:"text"`
+					PartialJSON string `json:"partial_json"` // tool_use 입력 (구조화 출력)
+				} `json:"delta"`
+				Usage struct {
+					OutputTokens int `json:"output_tokens"`
+				} `json:"usage"`
+				Error struct {
+					Type    string `json:"type"`
+					Message string `json:"message"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal([]byte(ev.Data), &payload); err != nil {
+				streamErr = err
+				return false
+			}
+			switch payload.Type {
+			case "message_start":
+				usage = anthropicUsage(payload.Message.Usage.InputTokens, usage.OutputTokens, payload.Message.Usage.CacheReadInputTokens)
+			case "content_block_start":
+				if payload.ContentBlock.Type == "tool_use" && r.ResponseFormat == nil {
+					calls = append(calls, ToolCall{ID: payload.ContentBlock.ID, Name: payload.ContentBlock.Name})
+					toolBlock = len(calls) - 1
+				}
+			case "content_block_stop":
+				toolBlock = -1
+			case "content_block_delta":
+				if payload.Delta.Type == "text_delta" && payload.Delta.Text != "" {
+					return emit(ctx, ch, StreamEvent{Delta: payload.Delta.Text})
+				}
+				if payload.Delta.Type == "input_json_delta" && payload.Delta.PartialJSON != "" {
+					if toolBlock >= 0 {
+						calls[toolBlock].Arguments = append(calls[toolBlock].Arguments, payload.Delta.PartialJSON...)
+						return true
+					}
+					return emit(ctx, ch, StreamEvent{Delta: payload.Delta.PartialJSON})
+				}
+			case "message_delta":
+				usage.OutputTokens = payload.Usage.OutputTokens
+			case "message_stop":
+				return false
+			case "error":
+				streamErr = fmt.Errorf("anthropic stream error: %s: %s", payload.Error.Type, payload.Error.Message)
+				return false
+			}
+			return true
+		})
+		if err == nil {
+			err = streamErr
+		}
+		if err != nil {
+			emit(ctx, ch, StreamEvent{Err: err})
+			return
+		}
+		emit(ctx, ch, StreamEvent{Done: true, Usage: &usage, ToolCalls: calls, Attempts: stats.Attempts, RetriedErrors: stats.Retried})
+	}()
+	return ch, nil
+}
+
+// anthropicUsage 는 Anthropic 사용량을 공통 Usage 로 바꿉니다.
+// Anthropic 의 input_tokens 에는 캐시에서 읽은 토큰(cache_read_input_tokens)이 빠져 있으므로 더해서,
+// 다른 공급자처럼 CachedTokens 가 InputTokens 의 일부가 되게 합니다 (Pricing.Cost 가 이 전제로 계산).
+func anthropicUsage(input, output, cacheRead int) Usage {
+	return Usage{InputTokens: input + cacheRead, OutputTokens: output, CachedTokens: cacheRead}
 }
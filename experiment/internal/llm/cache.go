@@ -0,0 +1,456 @@
+// internal/llm/cache.go
+package llm
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 응답 캐시 기본값
+const (
+	// DefaultCacheDir 는 실행 디렉터리 기준 캐시 위치입니다 (_runs 와 같은 .specify 아래).
+	DefaultCacheDir = ".specify/_cache"
+	// DefaultCacheTTL 은 항목이 유효한 기간입니다.
+	DefaultCacheTTL = 7 * 24 * time.Hour
+	// DefaultCacheMaxBytes 는 캐시 디렉터리 전체 크기 상한입니다. 넘으면 오래된 항목부터 지웁니다.
+	DefaultCacheMaxBytes = 100 << 20
+)
+
+// CacheEntry 는 캐시 파일 하나(<Dir>/<key 앞 2자>/<key>.json)의 내용입니다.
+type CacheEntry struct {
+	Key       string    `json:"key"`
+	Tag       string    `json:"tag,omitempty"`
+	Provider  string    `json:"provider"`
+	BaseURL   string    `json:"base_url,omitempty"`
+	Model     string    `json:"model"`
+	Prompt    string    `json:"prompt"` // 사람이 알아보기 위한 앞부분 (비밀 값 가림)
+	CreatedAt time.Time `json:"created_at"`
+	Response  *Response `json:"response"`
+
+	Path string `json:"-"` // Entries 가 채움
+	Size int64  `json:"-"`
+}
+
+// Expired 는 ttl 기준으로 만료됐는지 알려줍니다 (ttl <= 0 이면 만료 없음).
+func (e CacheEntry) Expired(ttl time.Duration, now time.Time) bool {
+	return ttl > 0 && now.Sub(e.CreatedAt) > ttl
+}
+
+// ResponseCache 는 응답을 디스크에 내용 주소(요청 해시)로 저장하는 캐시입니다.
+// 키는 공급자, 서버 주소, 모델, 생성 파라미터, 정규화된 프롬프트로 만들며(CacheKey), 성공한 응답만 저장합니다.
+type ResponseCache struct {
+	Dir        string
+	TTL        time.Duration // 0 이면 만료 없음
+	MaxBytes   int64         // 0 이면 크기 제한 없음
+	MaxEntries int           // 0 이면 개수 제한 없음
+	// Bypass 면 캐시를 읽지 않고 항상 모델을 호출하되 결과는 새로 저장합니다 (-cache refresh).
+	Bypass bool
+
+	mu     sync.Mutex // 쓰기/정리 직렬화
+	hits   atomic.Int64
+	misses atomic.Int64
+
+	// Put 이 매번 디렉터리 전체를 훑지 않도록 마지막 정리 이후 크기/개수를 어림합니다 (mu 로 보호).
+	// 같은 키를 덮어쓰면 실제보다 크게 잡히므로 조금 일찍 정리할 뿐 제한을 넘지는 않습니다.
+	tallied bool
+	size    int64
+	count   int
+}
+
+// OpenCache 는 dir 의 캐시를 기본 TTL/크기 제한으로 엽니다 (디렉터리가 없으면 만듭니다).
+func OpenCache(dir string) (*ResponseCache, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("open cache: %w", err)
+	}
+	return &ResponseCache{Dir: dir, TTL: DefaultCacheTTL, MaxBytes: DefaultCacheMaxBytes}, nil
+}
+
+type cacheBypassKey struct{}
+
+// WithCacheBypass 는 이 ctx 의 호출이 캐시를 읽지 않도록 합니다 (결과는 저장됨).
+func WithCacheBypass(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheBypassKey{}, true)
+}
+
+func cacheBypassed(ctx context.Context) bool {
+	v, _ := ctx.Value(cacheBypassKey{}).(bool)
+	return v
+}
+
+// Stats 는 지금까지의 적중/실패 횟수입니다.
+func (c *ResponseCache) Stats() (hits, misses int64) { return c.hits.Load(), c.misses.Load() }
+
+func (c *ResponseCache) path(key string) string {
+	return filepath.Join(c.Dir, key[:2], key+".json")
+}
+
+// Get 은 key 의 유효한 항목을 읽습니다. 만료된 항목은 지우고 없는 것으로 취급합니다.
+func (c *ResponseCache) Get(key string) (*CacheEntry, bool) {
+	p := c.path(key)
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, false
+	}
+	var e CacheEntry
+	if err := json.Unmarshal(b, &e); err != nil || e.Response == nil {
+		os.Remove(p) // 깨진 항목
+		return nil, false
+	}
+	if e.Expired(c.TTL, time.Now()) {
+		os.Remove(p)
+		return nil, false
+	}
+	e.Path, e.Size = p, int64(len(b))
+	return &e, true
+}
+
+// Put 은 항목을 저장하고(임시 파일에 쓴 뒤 교체) 크기/개수 제한을 넘으면 오래된 항목부터 지웁니다.
+// 정리할 때는 제한의 90% 까지 줄여, 제한 근처에서 쓸 때마다 디렉터리를 다시 훑지 않게 합니다.
+func (c *ResponseCache) Put(e CacheEntry) error {
+	resp := *e.Response
+	resp.QueueWait, resp.Latency, resp.Attempts, resp.RetriedErrors = 0, 0, 0, nil
+	e.Response = &resp
+	e.Prompt = RedactSecrets(e.Prompt)
+	b, err := json.MarshalIndent(e, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	p := c.path(e.Key)
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		return err
+	}
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, p); err != nil {
+		return err
+	}
+	if c.MaxBytes <= 0 && c.MaxEntries <= 0 {
+		return nil
+	}
+	if !c.tallied { // 이 프로세스의 첫 쓰기: 한 번 훑어 현재 크기를 잽니다
+		_, _, err = c.pruneLocked(PruneOptions{MaxBytes: c.MaxBytes, MaxEntries: c.MaxEntries})
+		return err
+	}
+	c.size += int64(len(b))
+	c.count++
+	if (c.MaxBytes <= 0 || c.size <= c.MaxBytes) && (c.MaxEntries <= 0 || c.count <= c.MaxEntries) {
+		return nil
+	}
+	_, _, err = c.pruneLocked(PruneOptions{MaxBytes: headroom(c.MaxBytes), MaxEntries: int(headroom(int64(c.MaxEntries)))})
+	return err
+}
+
+// headroom 은 자동 정리 때 남길 상한입니다 (limit 의 90%, 0 이면 제한 없음, 최소 1).
+func headroom(limit int64) int64 {
+	if limit <= 0 {
+		return 0
+	}
+	return max(limit-limit/10, 1)
+}
+
+// Entries 는 모든 항목을 최신순으로 읽습니다 (만료된 항목 포함, 깨진 파일은 건너뜀).
+func (c *ResponseCache) Entries() ([]CacheEntry, error) {
+	var entries []CacheEntry
+	err := filepath.WalkDir(c.Dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(p, ".json") {
+			return nil
+		}
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		var e CacheEntry
+		if json.Unmarshal(b, &e) != nil {
+			return nil
+		}
+		e.Path, e.Size = p, int64(len(b))
+		entries = append(entries, e)
+		return nil
+	})
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].CreatedAt.After(entries[j].CreatedAt) })
+	return entries, err
+}
+
+// PruneOptions 는 Prune 이 지울 항목의 조건입니다. 여러 조건은 함께 적용됩니다.
+type PruneOptions struct {
+	All        bool          // 전부 지움
+	Expired    bool          // TTL 이 지난 항목
+	OlderThan  time.Duration // 이보다 오래된 항목 (0 이면 무시)
+	MaxBytes   int64         // 남길 전체 크기 상한 (오래된 것부터 지움, 0 이면 무시)
+	MaxEntries int           // 남길 항목 수 상한 (0 이면 무시)
+}
+
+// Prune 은 조건에 맞는 항목을 지우고 지운 개수와 바이트를 반환합니다.
+func (c *ResponseCache) Prune(opts PruneOptions) (removed int, freed int64, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.pruneLocked(opts)
+}
+
+func (c *ResponseCache) pruneLocked(opts PruneOptions) (removed int, freed int64, err error) {
+	entries, err := c.Entries()
+	if err != nil {
+		return 0, 0, err
+	}
+	now := time.Now()
+	var total int64
+	kept := 0
+	var errs []error
+	for _, e := range entries { // 최신순
+		drop := opts.All ||
+			(opts.Expired && e.Expired(c.TTL, now)) ||
+			(opts.OlderThan > 0 && now.Sub(e.CreatedAt) > opts.OlderThan) ||
+			(opts.MaxBytes > 0 && total+e.Size > opts.MaxBytes) ||
+			(opts.MaxEntries > 0 && kept >= opts.MaxEntries)
+		if !drop {
+			total += e.Size
+			kept++
+			continue
+		}
+		if err := os.Remove(e.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+		removed++
+		freed += e.Size
+	}
+	c.tallied, c.size, c.count = len(errs) == 0, total, kept
+	return removed, freed, errors.Join(errs...)
+}
+
+// Wrap 은 client 를 이 캐시를 쓰는 CachedClient 로 감쌉니다.
+// ModelRegistry.WrapAll 에 그대로 넘길 수 있으며, Fallback/Router 는 감싸지 않습니다
+// (감싼 태그들이 각각 캐시되므로).
+func (c *ResponseCache) Wrap(tag string, client LLMClient) LLMClient {
+	if isComposite(client) {
+		return client
+	}
+	return &CachedClient{inner: client, cache: c, tag: tag}
+}
+
+// CachedClient 는 응답 캐시를 먼저 찾아보고, 없으면 내부 클라이언트를 호출해 결과를 저장합니다.
+// 적중한 응답은 Response.Cached 가 true 이고 Usage 는 처음 호출 때의 값입니다.
+type CachedClient struct {
+	inner LLMClient
+	cache *ResponseCache
+	tag   string
+}
+
+func (c *CachedClient) Name() string { return c.inner.Name() }
+
+func (c *CachedClient) Unwrap() LLMClient { return c.inner }
+
+func (c *CachedClient) rewrap(tag string, inner LLMClient) LLMClient {
+	return &CachedClient{inner: inner, cache: c.cache, tag: tag}
+}
+
+func (c *CachedClient) Generate(ctx context.Context, prompt string) (string, error) {
+	resp, err := c.Complete(ctx, PromptRequest(prompt))
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// key 는 모델 기본값(WithDefaults)을 적용한 실제 요청으로 키를 만듭니다.
+func (c *CachedClient) key(req GenerateRequest) string {
+	if d, ok := findBase[*defaultsClient](c.inner); ok {
+		req = d.defaults.Apply(req)
+	}
+	provider, baseURL := providerOf(c.inner)
+	return CacheKey(provider, baseURL, c.inner.Name(), req)
+}
+
+func (c *CachedClient) lookup(ctx context.Context, key string) (*Response, bool) {
+	if c.cache.Bypass || cacheBypassed(ctx) {
+		c.cache.misses.Add(1)
+		return nil, false
+	}
+	e, ok := c.cache.Get(key)
+	if !ok {
+		c.cache.misses.Add(1)
+		return nil, false
+	}
+	c.cache.hits.Add(1)
+	resp := *e.Response
+	resp.Cached = true
+	return &resp, true
+}
+
+func (c *CachedClient) store(key string, req GenerateRequest, resp *Response) error {
+	provider, baseURL := providerOf(c.inner)
+	err := c.cache.Put(CacheEntry{
+		Key:       key,
+		Tag:       c.tag,
+		Provider:  provider,
+		BaseURL:   baseURL,
+		Model:     c.inner.Name(),
+		Prompt:    excerpt(lastUserText(req), 200),
+		CreatedAt: time.Now().UTC(),
+		Response:  resp,
+	})
+	if err != nil {
+		return fmt.Errorf("save cache: %w", err)
+	}
+	return nil
+}
+
+// Complete 는 캐시 적중이면 저장된 응답을, 아니면 내부 클라이언트의 응답을 저장 후 반환합니다.
+// 저장 실패는 응답과 함께 오류로 알립니다.
+func (c *CachedClient) Complete(ctx context.Context, req GenerateRequest) (*Response, error) {
+	key := c.key(req)
+	if resp, ok := c.lookup(ctx, key); ok {
+		return resp, nil
+	}
+	resp, err := Complete(ctx, c.inner, req)
+	if err != nil {
+		return resp, err
+	}
+	if err := c.store(key, req, resp); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// Stream 은 캐시 적중이면 저장된 텍스트를 한 번에 흘려보내고, 아니면 내부 스트림을 전달하며 모은 결과를 저장합니다.
+func (c *CachedClient) Stream(ctx context.Context, req GenerateRequest) (<-chan StreamEvent, error) {
+	key := c.key(req)
+	if resp, ok := c.lookup(ctx, key); ok {
+		ch := make(chan StreamEvent, 2)
+		ch <- StreamEvent{Delta: resp.Text}
+		ch <- StreamEvent{Done: true, Usage: &resp.Usage, Model: resp.Model, ToolCalls: resp.ToolCalls, Cached: true}
+		close(ch)
+		return ch, nil
+	}
+	in, err := streamOrComplete(ctx, c.inner, req)
+	if err != nil {
+		return nil, err
+	}
+	out := make(chan StreamEvent)
+	go func() {
+		defer close(out)
+		var sb strings.Builder
+		resp := &Response{Model: c.inner.Name()}
+		for ev := range in {
+			sb.WriteString(ev.Delta)
+			if ev.Done {
+				if ev.Usage != nil {
+					resp.Usage = *ev.Usage
+				}
+				if ev.Model != "" {
+					resp.Model = ev.Model
+				}
+				resp.ToolCalls = ev.ToolCalls
+				resp.Text = sb.String()
+				if err := c.store(key, req, resp); err != nil {
+					emit(ctx, out, StreamEvent{Err: err})
+					return
+				}
+			}
+			if !emit(ctx, out, ev) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// lastUserText 는 마지막 user 메시지입니다 (캐시 목록에서 항목을 알아보기 위한 용도).
+func lastUserText(req GenerateRequest) string {
+	msgs := req.ChatMessages()
+	for i := len(msgs) - 1; i >= 0; i-- {
+		if msgs[i].Role == RoleUser {
+			return msgs[i].Content
+		}
+	}
+	return req.Prompt()
+}
+
+// providerOf 는 래퍼를 벗겨 낸 기본 클라이언트의 공급자 이름과 서버 주소입니다 (캐시 키용).
+// 같은 모델 이름이라도 서버(예: fakellm 과 실제 API)가 다르면 다른 키가 되어야 합니다.
+func providerOf(c LLMClient) (provider, baseURL string) {
+	for c != nil {
+		switch b := c.(type) {
+		case *OpenAIClient:
+			return b.provider, b.BaseURL
+		case *AnthropicClient:
+			return "anthropic", b.BaseURL
+		case *GeminiClient:
+			return "gemini", b.BaseURL
+		case *OllamaClient:
+			return "ollama", b.BaseURL
+		}
+		u, ok := c.(Unwrapper)
+		if !ok {
+			return fmt.Sprintf("%T", c), ""
+		}
+		c = u.Unwrap()
+	}
+	return "", ""
+}
+
+// CacheKey 는 공급자, 서버 주소, 모델, 생성 파라미터와 정규화된 프롬프트로 만든 캐시 키입니다 (sha256 앞 32자).
+// 줄 끝 공백과 개행 형식 차이는 같은 프롬프트로 봅니다. 주소 끝의 "/" 는 무시합니다.
+func CacheKey(provider, baseURL, model string, req GenerateRequest) string {
+	type keyMessage struct {
+		Role       Role       `json:"role"`
+		Content    string     `json:"content"`
+		ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+		ToolCallID string     `json:"tool_call_id,omitempty"`
+		ToolName   string     `json:"tool_name,omitempty"`
+	}
+	msgs := make([]keyMessage, 0, len(req.Messages))
+	for _, m := range req.ChatMessages() {
+		km := keyMessage{Role: m.Role, Content: normalizePrompt(m.Content), ToolCallID: m.ToolCallID, ToolName: m.ToolName}
+		for _, tc := range m.ToolCalls {
+			tc.Arguments = tc.args()
+			km.ToolCalls = append(km.ToolCalls, tc)
+		}
+		msgs = append(msgs, km)
+	}
+	k := struct {
+		Provider       string          `json:"provider"`
+		BaseURL        string          `json:"base_url"`
+		Model          string          `json:"model"`
+		System         string          `json:"system"`
+		Messages       []keyMessage    `json:"messages"`
+		Temperature    *float64        `json:"temperature"`
+		TopP           *float64        `json:"top_p"`
+		MaxTokens      int             `json:"max_tokens"`
+		Stop           []string        `json:"stop"`
+		Seed           *int64          `json:"seed"`
+		ResponseFormat *ResponseFormat `json:"response_format"`
+		Tools          []Tool          `json:"tools"`
+		ToolChoice     string          `json:"tool_choice"`
+	}{
+		provider, strings.TrimRight(baseURL, "/"), model, normalizePrompt(req.SystemText()), msgs,
+		req.Temperature, req.TopP, req.maxTokens(), req.Stop, req.Seed,
+		req.ResponseFormat, req.Tools, req.ToolChoice,
+	}
+	b, _ := json.Marshal(k)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])[:32]
+}
@@ -0,0 +1,132 @@
+// internal/llm/cache_test.go
+package llm_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"speckit-study/internal/fakellm"
+	"speckit-study/internal/llm"
+)
+
+// 같은 모델 이름이라도 서버가 다르면 캐시 항목을 함께 쓰지 않습니다.
+func TestCacheKeyIncludesServer(t *testing.T) {
+	a := fakellm.NewTestServer(fakellm.Script{Default: fakellm.Reply{Text: "from a"}})
+	defer a.Close()
+	b := fakellm.NewTestServer(fakellm.Script{Default: fakellm.Reply{Text: "from b"}})
+	defer b.Close()
+
+	cache, err := llm.OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, req := context.Background(), llm.PromptRequest("same prompt")
+	ca := cache.Wrap("local", llm.NewOpenAICompatibleClient(a.OpenAIBaseURL(), "m"))
+	cb := cache.Wrap("remote", llm.NewOpenAICompatibleClient(b.OpenAIBaseURL(), "m"))
+
+	for i, want := range []struct {
+		client llm.LLMClient
+		text   string
+		cached bool
+	}{{ca, "from a", false}, {cb, "from b", false}, {ca, "from a", true}, {cb, "from b", true}} {
+		resp, err := llm.Complete(ctx, want.client, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Text != want.text || resp.Cached != want.cached {
+			t.Errorf("call %d: got %q cached=%v, want %q cached=%v", i, resp.Text, resp.Cached, want.text, want.cached)
+		}
+	}
+	if len(a.Fake.Calls()) != 1 || len(b.Fake.Calls()) != 1 {
+		t.Errorf("server calls a=%d b=%d, want 1 each", len(a.Fake.Calls()), len(b.Fake.Calls()))
+	}
+	if llm.CacheKey("openai-compatible", "http://x/v1/", "m", req) != llm.CacheKey("openai-compatible", "http://x/v1", "m", req) {
+		t.Error("a trailing slash in the base URL changed the key")
+	}
+}
+
+func TestCacheTTLAndBypass(t *testing.T) {
+	cache, err := llm.OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.TTL = time.Hour
+	old := llm.CacheEntry{Key: "aa-old", CreatedAt: time.Now().Add(-2 * time.Hour), Response: &llm.Response{Text: "stale"}}
+	fresh := llm.CacheEntry{Key: "bb-fresh", CreatedAt: time.Now(), Response: &llm.Response{Text: "ok"}}
+	for _, e := range []llm.CacheEntry{old, fresh} {
+		if err := cache.Put(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, ok := cache.Get(old.Key); ok {
+		t.Error("expired entry was returned")
+	}
+	if e, ok := cache.Get(fresh.Key); !ok || e.Response.Text != "ok" {
+		t.Errorf("fresh entry: got %v, %v", e, ok)
+	}
+
+	client := &stubClient{name: "m", replies: []stubReply{{text: "first"}, {text: "second"}}}
+	cc := cache.Wrap("m", client)
+	ctx, req := context.Background(), llm.PromptRequest("p")
+	llm.Complete(ctx, cc, req)
+	resp, _ := llm.Complete(llm.WithCacheBypass(ctx), cc, req)
+	if resp.Text != "second" || resp.Cached {
+		t.Errorf("bypass: got %q cached=%v, want a live call", resp.Text, resp.Cached)
+	}
+	resp, _ = llm.Complete(ctx, cc, req)
+	if resp.Text != "second" || !resp.Cached {
+		t.Errorf("after bypass: got %q cached=%v, want the refreshed entry", resp.Text, resp.Cached)
+	}
+}
+
+func TestCacheLimits(t *testing.T) {
+	cache, err := llm.OpenCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	cache.MaxBytes, cache.MaxEntries = 0, 10
+	start := time.Now().Add(-time.Hour)
+	for i := range 25 {
+		e := llm.CacheEntry{Key: fmt.Sprintf("%02d-entry", i), CreatedAt: start.Add(time.Duration(i) * time.Minute), Response: &llm.Response{Text: "x"}}
+		if err := cache.Put(e); err != nil {
+			t.Fatal(err)
+		}
+		entries, err := cache.Entries()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) > cache.MaxEntries {
+			t.Fatalf("after %d puts: %d entries, limit %d", i+1, len(entries), cache.MaxEntries)
+		}
+	}
+	entries, _ := cache.Entries()
+	if entries[0].Key != "24-entry" {
+		t.Errorf("newest entry %s was pruned", "24-entry")
+	}
+	if _, ok := cache.Get("00-entry"); ok {
+		t.Error("oldest entry survived pruning")
+	}
+
+	// 바이트 제한도 같은 방식으로 지킵니다.
+	cache.MaxEntries = 0
+	cache.MaxBytes = entries[0].Size * 4
+	if _, _, err := cache.Prune(llm.PruneOptions{MaxBytes: cache.MaxBytes}); err != nil {
+		t.Fatal(err)
+	}
+	for i := 25; i < 40; i++ {
+		e := llm.CacheEntry{Key: fmt.Sprintf("%02d-entry", i), CreatedAt: start.Add(time.Duration(i) * time.Minute), Response: &llm.Response{Text: "x"}}
+		if err := cache.Put(e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	entries, _ = cache.Entries()
+	var total int64
+	for _, e := range entries {
+		total += e.Size
+	}
+	if total > cache.MaxBytes || entries[0].Key != "39-entry" {
+		t.Errorf("size %d (limit %d), newest %s", total, cache.MaxBytes, entries[0].Key)
+	}
+}
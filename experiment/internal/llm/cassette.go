@@ -0,0 +1,359 @@
+// internal/llm/cassette.go
+package llm
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CassetteMode 는 RecordingClient 의 동작 방식입니다.
+type CassetteMode string
+
+const (
+	// ModeRecord 는 항상 실제 모델을 호출하고 카세트를 새로 씁니다.
+	ModeRecord CassetteMode = "record"
+	// ModeReplay 는 카세트에서만 응답하며, 없는 요청은 ErrCassetteMiss 로 실패합니다.
+	ModeReplay CassetteMode = "replay"
+	// ModeRecordNew 는 카세트에 있는 요청은 재생하고, 없는 요청만 호출해 기록합니다.
+	ModeRecordNew CassetteMode = "record_new"
+)
+
+// ErrCassetteMiss 는 replay 모드에서 카세트에 없는 요청이 들어왔을 때 반환됩니다.
+var ErrCassetteMiss = errors.New("cassette miss")
+
+// Interaction 은 카세트에 기록된 요청/응답 한 쌍입니다.
+type Interaction struct {
+	Key      string          `yaml:"key"`
+	Model    string          `yaml:"model"`
+	Request  GenerateRequest `yaml:"request"`
+	Response *Response       `yaml:"response,omitempty"`
+	Error    string          `yaml:"error,omitempty"`
+	// ProviderError 는 Error 가 공급자 오류였을 때의 분류로, 재생 때 *ProviderError 로 되살립니다.
+	ProviderError *RecordedError `yaml:"provider_error,omitempty"`
+	RecordedAt    time.Time      `yaml:"recorded_at"`
+}
+
+// RecordedError 는 카세트에 남긴 ProviderError 와 재시도 횟수입니다.
+// 재생한 오류도 errors.As/errors.Is 와 IsRetryable 이 실제 호출 때와 같게 동작하도록 분류를 함께 저장합니다.
+type RecordedError struct {
+	Provider   string    `yaml:"provider"`
+	StatusCode int       `yaml:"status"`
+	Kind       ErrorKind `yaml:"kind"`
+	Type       string    `yaml:"type,omitempty"`
+	Message    string    `yaml:"message,omitempty"`
+	Attempts   int       `yaml:"attempts,omitempty"` // 1 보다 크면 RetryError 로 감싸 재생
+	Retried    []string  `yaml:"retried,omitempty"`
+}
+
+// recordError 는 err 안의 ProviderError 를 기록용으로 바꿉니다 (공급자 오류가 아니면 nil).
+func recordError(err error) *RecordedError {
+	var pe *ProviderError
+	if !errors.As(err, &pe) {
+		return nil
+	}
+	rec := &RecordedError{Provider: pe.Provider, StatusCode: pe.StatusCode, Kind: pe.Kind, Type: pe.Type, Message: pe.Message}
+	var re *RetryError
+	if errors.As(err, &re) {
+		rec.Attempts, rec.Retried = re.Attempts, re.Retried
+	}
+	return rec
+}
+
+// err 는 기록된 오류를 실제 호출 때와 같은 형태(*ProviderError, 재시도했으면 *RetryError)로 되살립니다.
+func (r *RecordedError) err() error {
+	pe := &ProviderError{Provider: r.Provider, StatusCode: r.StatusCode, Kind: r.Kind, Type: r.Type, Message: r.Message}
+	if r.Attempts > 1 {
+		return &RetryError{Attempts: r.Attempts, Retried: r.Retried, Err: pe}
+	}
+	return pe
+}
+
+// Cassette 는 디스크의 YAML 파일 하나에 대응하며 여러 클라이언트가 함께 쓸 수 있습니다.
+// 키는 모델 이름 + 정규화된 프롬프트이므로 같은 파일에 여러 모델의 기록이 섞여도 됩니다.
+type Cassette struct {
+	Path string
+	Mode CassetteMode
+
+	mu           sync.Mutex
+	interactions []Interaction
+	byKey        map[string][]int // key → interactions 인덱스 (기록 순)
+	served       map[string]int   // key → 재생한 횟수
+}
+
+type cassetteFile struct {
+	Version      int           `yaml:"version"`
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+// OpenCassette 는 path 의 카세트를 엽니다.
+// ModeRecord 는 기존 내용을 버리고, 나머지 모드는 파일이 있으면 읽어 들입니다 (replay 는 파일 필수).
+func OpenCassette(path string, mode CassetteMode) (*Cassette, error) {
+	c := &Cassette{Path: path, Mode: mode, byKey: map[string][]int{}, served: map[string]int{}}
+	switch mode {
+	case ModeRecord:
+		return c, nil
+	case ModeReplay, ModeRecordNew:
+	default:
+		return nil, fmt.Errorf("unknown cassette mode %q", mode)
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) && mode == ModeRecordNew {
+			return c, nil
+		}
+		return nil, fmt.Errorf("read cassette: %w", err)
+	}
+	var f cassetteFile
+	if err := yaml.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("parse cassette %s: %w", path, err)
+	}
+	for _, it := range f.Interactions {
+		c.add(it)
+	}
+	return c, nil
+}
+
+// Wrap 은 client 를 이 카세트로 기록/재생하는 RecordingClient 로 감쌉니다.
+// ModelRegistry.WrapAll 에 그대로 넘길 수 있도록 tag 인자를 받습니다.
+func (c *Cassette) Wrap(tag string, client LLMClient) LLMClient {
+	return &RecordingClient{inner: client, cassette: c}
+}
+
+// Len 은 기록된 상호작용 수입니다.
+func (c *Cassette) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.interactions)
+}
+
+// Save 는 카세트를 파일에 씁니다 (임시 파일에 쓴 뒤 교체).
+func (c *Cassette) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.saveLocked()
+}
+
+func (c *Cassette) saveLocked() error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(cassetteFile{Version: 1, Interactions: c.interactions}); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0o755); err != nil {
+		return err
+	}
+	tmp := c.Path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, c.Path)
+}
+
+func (c *Cassette) add(it Interaction) {
+	c.byKey[it.Key] = append(c.byKey[it.Key], len(c.interactions))
+	c.interactions = append(c.interactions, it)
+}
+
+// lookup 은 key 의 기록을 기록 순서대로 하나씩 돌려줍니다.
+// 같은 요청이 여러 번 기록된 경우(스모크 반복 등) 순서대로 재생하고, 다 쓰면 마지막 것을 재사용합니다.
+// 응답도 오류도 없는 기록(손으로 고친 카세트 등)은 없는 것으로 보고, record_new 에서는 기록된 오류도
+// 일시적일 수 있으므로 재생하지 않고 다시 호출합니다.
+func (c *Cassette) lookup(key string) (Interaction, bool) {
+	var idx []int
+	for _, i := range c.byKey[key] {
+		it := c.interactions[i]
+		if it.Response == nil && (it.Error == "" || c.Mode == ModeRecordNew) {
+			continue
+		}
+		idx = append(idx, i)
+	}
+	if len(idx) == 0 {
+		return Interaction{}, false
+	}
+	n := c.served[key]
+	c.served[key] = n + 1
+	return c.interactions[idx[min(n, len(idx)-1)]], true
+}
+
+// record 는 상호작용을 비밀 값을 가린 뒤 추가하고 바로 저장합니다.
+// 응답 본문은 재생 결과가 실제 호출과 같아야 하므로 가리지 않습니다 (요청, 오류만).
+func (c *Cassette) record(it Interaction) error {
+	it.Request = redactRequest(it.Request)
+	it.Error = RedactSecrets(it.Error)
+	if it.ProviderError != nil {
+		pe := *it.ProviderError
+		pe.Message = RedactSecrets(pe.Message)
+		pe.Retried = make([]string, len(it.ProviderError.Retried))
+		for i, e := range it.ProviderError.Retried {
+			pe.Retried[i] = RedactSecrets(e)
+		}
+		it.ProviderError = &pe
+	}
+	if it.Response != nil {
+		resp := *it.Response
+		resp.QueueWait, resp.Latency = 0, 0 // 재생 시에는 대기하지 않습니다
+		resp.RetriedErrors = make([]string, len(it.Response.RetriedErrors))
+		for i, e := range it.Response.RetriedErrors {
+			resp.RetriedErrors[i] = RedactSecrets(e)
+		}
+		it.Response = &resp
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.add(it)
+	c.served[it.Key]++ // 방금 기록한 것은 이미 "재생된" 것으로 칩니다
+	return c.saveLocked()
+}
+
+// RecordingClient 는 LLMClient 를 감싸 요청/응답을 카세트에 기록하거나 카세트에서 재생합니다.
+type RecordingClient struct {
+	inner    LLMClient
+	cassette *Cassette
+}
+
+// NewRecordingClient 는 client 를 path 카세트로 감쌉니다.
+// 여러 클라이언트가 같은 파일을 쓸 때는 OpenCassette 후 Cassette.Wrap 을 사용하세요.
+func NewRecordingClient(client LLMClient, path string, mode CassetteMode) (*RecordingClient, error) {
+	c, err := OpenCassette(path, mode)
+	if err != nil {
+		return nil, err
+	}
+	return &RecordingClient{inner: client, cassette: c}, nil
+}
+
+func (r *RecordingClient) Name() string { return r.inner.Name() }
+
+func (r *RecordingClient) Unwrap() LLMClient { return r.inner }
+
+func (r *RecordingClient) rewrap(tag string, inner LLMClient) LLMClient {
+	return &RecordingClient{inner: inner, cassette: r.cassette}
+}
+
+func (r *RecordingClient) Generate(ctx context.Context, prompt string) (string, error) {
+	resp, err := r.Complete(ctx, PromptRequest(prompt))
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// Complete 는 모드에 따라 카세트에서 재생하거나 내부 클라이언트를 호출해 기록합니다.
+func (r *RecordingClient) Complete(ctx context.Context, req GenerateRequest) (*Response, error) {
+	key := CassetteKey(r.inner.Name(), req)
+
+	if r.cassette.Mode != ModeRecord {
+		r.cassette.mu.Lock()
+		it, ok := r.cassette.lookup(key)
+		r.cassette.mu.Unlock()
+		if ok {
+			if it.ProviderError != nil {
+				return nil, it.ProviderError.err()
+			}
+			if it.Error != "" {
+				return nil, fmt.Errorf("replayed error: %s", it.Error)
+			}
+			resp := *it.Response
+			return &resp, nil
+		}
+		if r.cassette.Mode == ModeReplay {
+			return nil, fmt.Errorf("%w: model=%s key=%s prompt=%q (cassette %s)",
+				ErrCassetteMiss, r.inner.Name(), key, excerpt(req.Prompt(), 80), r.cassette.Path)
+		}
+	}
+
+	resp, err := Complete(ctx, r.inner, req)
+	it := Interaction{Key: key, Model: r.inner.Name(), Request: req, Response: resp, RecordedAt: time.Now().UTC()}
+	if err != nil {
+		if ctx.Err() != nil || r.cassette.Mode == ModeRecordNew {
+			return nil, err // 취소와 record_new 의 오류는 기록하지 않습니다 (다음 실행에서 다시 호출)
+		}
+		it.Error, it.ProviderError = err.Error(), recordError(err)
+	}
+	if rerr := r.cassette.record(it); rerr != nil {
+		return resp, errors.Join(err, fmt.Errorf("save cassette: %w", rerr))
+	}
+	return resp, err
+}
+
+// CassetteKey 는 모델 이름과 정규화된 프롬프트(시스템 + 메시지)로 만든 카세트 키입니다.
+// 개행 형식, 줄 끝 공백, 앞뒤 공백 차이는 같은 키가 됩니다.
+func CassetteKey(model string, req GenerateRequest) string {
+	h := sha256.New()
+	h.Write([]byte(model))
+	h.Write([]byte{0})
+	h.Write([]byte(normalizePrompt(req.SystemText())))
+	for _, m := range req.ChatMessages() {
+		h.Write([]byte{0})
+		h.Write([]byte(m.Role))
+		h.Write([]byte{0})
+		h.Write([]byte(normalizePrompt(m.Content)))
+		// 호출 ID 는 공급자가 매번 새로 만들 수 있어 키에서 뺍니다.
+		for _, tc := range m.ToolCalls {
+			h.Write([]byte{0})
+			h.Write([]byte(tc.Name))
+			h.Write([]byte(compactJSON(tc.args())))
+		}
+		if m.ToolName != "" {
+			h.Write([]byte{0})
+			h.Write([]byte(m.ToolName))
+		}
+	}
+	for _, t := range req.Tools {
+		h.Write([]byte{0})
+		h.Write([]byte(t.Name))
+		h.Write([]byte(compactJSON(t.toolParameters())))
+	}
+	if req.ToolChoice != "" {
+		h.Write([]byte{0})
+		h.Write([]byte(req.ToolChoice))
+	}
+	if f := req.ResponseFormat; f != nil {
+		h.Write([]byte{0})
+		h.Write([]byte(f.name()))
+		h.Write([]byte(compactJSON(f.Schema)))
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+func normalizePrompt(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(s, "\r", "\n")
+	lines := strings.Split(s, "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func redactRequest(req GenerateRequest) GenerateRequest {
+	req.System = RedactSecrets(req.System)
+	msgs := make([]Message, len(req.Messages))
+	for i, m := range req.Messages {
+		m.Content = RedactSecrets(m.Content)
+		msgs[i] = m
+	}
+	req.Messages = msgs
+	return req
+}
+
+func excerpt(s string, n int) string {
+	r := []rune(strings.Join(strings.Fields(s), " "))
+	if len(r) <= n {
+		return string(r)
+	}
+	return string(r[:n]) + "..."
+}
@@ -0,0 +1,159 @@
+// internal/llm/cassette_test.go
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"speckit-study/internal/llm"
+)
+
+// stubClient 는 호출마다 replies 를 차례로 돌려주는 테스트용 클라이언트입니다 (다 쓰면 마지막 것을 반복).
+type stubClient struct {
+	name    string
+	replies []stubReply
+	calls   int
+}
+
+type stubReply struct {
+	text string
+	err  error
+}
+
+func (s *stubClient) Name() string { return s.name }
+
+func (s *stubClient) Generate(ctx context.Context, prompt string) (string, error) {
+	r := s.replies[min(s.calls, len(s.replies)-1)]
+	s.calls++
+	return r.text, r.err
+}
+
+func TestCassetteRecordNewRetriesErrors(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	ctx := context.Background()
+	req := llm.PromptRequest("describe the retention job")
+
+	// record 모드는 오류도 기록하고, replay 는 그 오류를 재생합니다.
+	rec, err := llm.OpenCassette(path, llm.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	failing := &stubClient{name: "m", replies: []stubReply{{err: errors.New("HTTP 503")}}}
+	if _, err := llm.Complete(ctx, rec.Wrap("m", failing), req); err == nil {
+		t.Fatal("record: want the inner error")
+	}
+	replay, err := llm.OpenCassette(path, llm.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := llm.Complete(ctx, replay.Wrap("m", failing), req); err == nil || errors.Is(err, llm.ErrCassetteMiss) {
+		t.Fatalf("replay: want the recorded error, got %v", err)
+	}
+
+	// record_new 는 기록된 오류를 재생하지 않고 다시 호출하며, 새 오류는 기록하지 않습니다.
+	flaky := &stubClient{name: "m", replies: []stubReply{{err: errors.New("HTTP 503")}, {text: "## Steps"}}}
+	rn, err := llm.OpenCassette(path, llm.ModeRecordNew)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := llm.Complete(ctx, rn.Wrap("m", flaky), req); err == nil {
+		t.Fatal("record_new: want the live error")
+	}
+	if flaky.calls != 1 || rn.Len() != 1 {
+		t.Fatalf("record_new after error: calls=%d interactions=%d, want 1 and 1", flaky.calls, rn.Len())
+	}
+	resp, err := llm.Complete(ctx, rn.Wrap("m", flaky), req)
+	if err != nil || resp.Text != "## Steps" {
+		t.Fatalf("record_new retry: got %v, %v", resp, err)
+	}
+
+	// 다시 연 카세트는 오류가 아닌 새 응답을 재생합니다.
+	for _, mode := range []llm.CassetteMode{llm.ModeReplay, llm.ModeRecordNew} {
+		c, err := llm.OpenCassette(path, mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dead := &stubClient{name: "m", replies: []stubReply{{err: errors.New("must not be called")}}}
+		resp, err := llm.Complete(ctx, c.Wrap("m", dead), req)
+		if mode == llm.ModeReplay && err == nil {
+			t.Fatalf("%s: the first recorded interaction is the error, want it replayed", mode)
+		}
+		if mode == llm.ModeRecordNew && (err != nil || resp.Text != "## Steps" || dead.calls != 0) {
+			t.Fatalf("%s: got %v, %v (calls %d), want the recorded response", mode, resp, err, dead.calls)
+		}
+	}
+}
+
+// 재생한 공급자 오류는 기록 때와 같은 *ProviderError(재시도했으면 *RetryError)로 돌아와야
+// 호출자의 errors.As/errors.Is, IsRetryable, 오류 문구가 실제 호출과 같습니다.
+func TestCassetteReplaysProviderError(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	ctx := context.Background()
+	req := llm.PromptRequest("describe the retention job")
+	live := &llm.RetryError{Attempts: 3, Retried: []string{"first 503", "second 503"}, Err: &llm.ProviderError{
+		Provider: "anthropic", StatusCode: 529, Kind: llm.ErrorKindOverloaded, Type: "overloaded_error",
+		Message: "Overloaded (key sk-ant-api03-abcdefghij)",
+	}}
+
+	rec, err := llm.OpenCassette(path, llm.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, recErr := llm.Complete(ctx, rec.Wrap("m", &stubClient{name: "m", replies: []stubReply{{err: live}}}), req)
+	if recErr == nil {
+		t.Fatal("record: want the inner error")
+	}
+
+	replay, err := llm.OpenCassette(path, llm.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = llm.Complete(ctx, replay.Wrap("m", &stubClient{name: "m", replies: []stubReply{{text: "must not be called"}}}), req)
+	var pe *llm.ProviderError
+	if !errors.As(err, &pe) {
+		t.Fatalf("replay: got %v, want a ProviderError", err)
+	}
+	if pe.Provider != "anthropic" || pe.StatusCode != 529 || pe.Kind != llm.ErrorKindOverloaded || pe.Type != "overloaded_error" {
+		t.Errorf("replayed %+v", pe)
+	}
+	if !errors.Is(err, llm.ErrOverloaded) || !llm.IsRetryable(err) {
+		t.Errorf("replayed error lost its kind: is overloaded %v, retryable %v", errors.Is(err, llm.ErrOverloaded), llm.IsRetryable(err))
+	}
+	var re *llm.RetryError
+	if !errors.As(err, &re) || re.Attempts != 3 || len(re.Retried) != 2 {
+		t.Errorf("replayed retry record = %+v", re)
+	}
+	if err.Error() != recErr.Error() {
+		t.Errorf("replayed %q, recorded %q", err, recErr)
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "sk-ant-api03") {
+		t.Errorf("cassette stores the key from the error message:\n%s", b)
+	}
+}
+
+func TestCassetteEmptyInteractionIsMiss(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.yaml")
+	req := llm.PromptRequest("hello")
+	key := llm.CassetteKey("m", req)
+	// 응답도 오류도 없는 항목 (손으로 고친 카세트)
+	doc := "version: 1\ninteractions:\n  - key: " + key + "\n    model: m\n    recorded_at: 2025-01-01T00:00:00Z\n"
+	if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c, err := llm.OpenCassette(path, llm.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = llm.Complete(context.Background(), c.Wrap("m", &stubClient{name: "m", replies: []stubReply{{text: "live"}}}), req)
+	if !errors.Is(err, llm.ErrCassetteMiss) {
+		t.Fatalf("got %v, want ErrCassetteMiss", err)
+	}
+}
@@ -0,0 +1,265 @@
+// internal/llm/clients_test.go
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"speckit-study/internal/fakellm"
+	"speckit-study/internal/llm"
+)
+
+var providers = []string{"openai", "anthropic", "gemini"}
+
+// fastRetry 는 테스트가 기다리지 않도록 대기 시간을 줄인 재시도 정책입니다.
+var fastRetry = llm.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 10 * time.Millisecond}
+
+// fakeClients 는 srv 를 가리키는 공급자별 클라이언트입니다 (재시도는 fastRetry).
+func fakeClients(t *testing.T, srv *fakellm.TestServer) map[string]llm.LLMClient {
+	t.Helper()
+	t.Setenv("OPENAI_API_KEY", "test-openai-key")
+	t.Setenv("ANTHROPIC_API_KEY", "test-anthropic-key")
+	t.Setenv("GEMINI_API_KEY", "test-gemini-key")
+
+	oa := llm.NewOpenAIClient("gpt-test")
+	oa.BaseURL, oa.Retry = srv.OpenAIBaseURL(), fastRetry
+	an := llm.NewAnthropicClient("claude-test")
+	an.BaseURL, an.Retry = srv.AnthropicBaseURL(), fastRetry
+	ge := llm.NewGeminiClient("gemini-test")
+	ge.BaseURL, ge.Retry = srv.GeminiBaseURL(), fastRetry
+	return map[string]llm.LLMClient{"openai": oa, "anthropic": an, "gemini": ge}
+}
+
+// 세 공급자 형식으로 요청을 보내고 fakellm 이 받은 내용과 돌려준 응답을 확인합니다.
+func TestClientsWireFormats(t *testing.T) {
+	srv := fakellm.NewTestServer(fakellm.DefaultScript())
+	defer srv.Close()
+	clients := fakeClients(t, srv)
+	models := map[string]string{"openai": "gpt-test", "anthropic": "claude-test", "gemini": "gemini-test"}
+
+	for _, provider := range providers {
+		t.Run(provider, func(t *testing.T) {
+			before := len(srv.Fake.Calls())
+			req := llm.GenerateRequest{System: "be brief", Messages: []llm.Message{{Role: llm.RoleUser, Content: "list the steps"}}}
+			resp, err := llm.Complete(context.Background(), clients[provider], req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "[" + provider + "/" + models[provider] + "] list the steps"; resp.Text != want {
+				t.Errorf("text = %q, want %q", resp.Text, want)
+			}
+			if resp.Usage.InputTokens == 0 || resp.Usage.OutputTokens == 0 {
+				t.Errorf("usage not decoded: %+v", resp.Usage)
+			}
+			calls := srv.Fake.Calls()[before:]
+			if len(calls) != 1 {
+				t.Fatalf("server saw %d calls, want 1", len(calls))
+			}
+			c := calls[0]
+			if c.Provider != provider || c.Model != models[provider] || c.System != "be brief" || c.Prompt != "list the steps" || c.Stream {
+				t.Errorf("server saw %+v", c)
+			}
+		})
+	}
+}
+
+// Role 이 빈 0 값 Message 는 공급자마다 role "user" 로 나가야 합니다 (빈 role 은 400 으로 거부됨).
+func TestClientsEmptyRoleWire(t *testing.T) {
+	t.Setenv("OPENAI_API_KEY", "test-openai-key")
+	t.Setenv("ANTHROPIC_API_KEY", "test-anthropic-key")
+	t.Setenv("GEMINI_API_KEY", "test-gemini-key")
+	cases := []struct {
+		name   string
+		client func(base string) llm.LLMClient
+		reply  string
+		// system 메시지를 본문 messages 에 넣는 공급자는 그 역할도 함께 옵니다.
+		roles string
+	}{
+		{"openai", func(base string) llm.LLMClient {
+			c := llm.NewOpenAIClient("gpt-test")
+			c.BaseURL = base
+			return c
+		}, `{"choices":[{"message":{"content":"ok"}}]}`, "system,user,assistant,user"},
+		{"anthropic", func(base string) llm.LLMClient {
+			c := llm.NewAnthropicClient("claude-test")
+			c.BaseURL = base
+			return c
+		}, `{"content":[{"type":"text","text":"ok"}]}`, "user,assistant,user"},
+		{"gemini", func(base string) llm.LLMClient {
+			c := llm.NewGeminiClient("gemini-test")
+			c.BaseURL = base
+			return c
+		}, `{"candidates":[{"content":{"parts":[{"text":"ok"}]}}]}`, "user,model,user"},
+		{"ollama", func(base string) llm.LLMClient {
+			c := llm.NewOllamaClient("llama-test")
+			c.BaseURL = base
+			return c
+		}, `{"message":{"content":"ok"},"done":true}`, "system,user,assistant,user"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			var body struct {
+				Messages []struct{ Role string } `json:"messages"`
+				Contents []struct{ Role string } `json:"contents"`
+			}
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+					t.Errorf("decode request: %v", err)
+				}
+				w.Header().Set("Content-Type", "application/json")
+				io.WriteString(w, c.reply)
+			}))
+			defer srv.Close()
+
+			req := llm.GenerateRequest{System: "be brief", Messages: []llm.Message{
+				{Content: "q1"},
+				{Role: llm.RoleAssistant, Content: "a1"},
+				{Content: "q2"},
+			}}
+			if _, err := llm.Complete(context.Background(), c.client(srv.URL), req); err != nil {
+				t.Fatal(err)
+			}
+			var roles []string
+			for _, m := range body.Messages {
+				roles = append(roles, m.Role)
+			}
+			for _, m := range body.Contents {
+				roles = append(roles, m.Role)
+			}
+			if got := strings.Join(roles, ","); got != c.roles {
+				t.Errorf("roles on the wire = %s, want %s", got, c.roles)
+			}
+		})
+	}
+}
+
+// countingWriter 는 Write 호출 횟수를 셉니다 (스트림 청크 수 확인용).
+type countingWriter struct {
+	sb     strings.Builder
+	writes int
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return w.sb.Write(p)
+}
+
+func (w *countingWriter) String() string { return w.sb.String() }
+
+func TestClientsStreaming(t *testing.T) {
+	srv := fakellm.NewTestServer(fakellm.Script{Default: fakellm.Reply{Text: "## Steps\n1. copy the partition\n2. drop it"}})
+	defer srv.Close()
+	clients := fakeClients(t, srv)
+
+	for _, provider := range providers {
+		t.Run(provider, func(t *testing.T) {
+			var w countingWriter
+			resp, err := llm.StreamTo(context.Background(), clients[provider], llm.PromptRequest("p"), &w)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if w.String() != "## Steps\n1. copy the partition\n2. drop it" || resp.Text != w.String() {
+				t.Errorf("streamed %q, response %q", w.String(), resp.Text)
+			}
+			if w.writes < 2 {
+				t.Errorf("got %d chunks, want the reply split into several deltas", w.writes)
+			}
+			if resp.Usage.OutputTokens == 0 {
+				t.Errorf("usage missing from the final event: %+v", resp.Usage)
+			}
+			calls := srv.Fake.Calls()
+			if !calls[len(calls)-1].Stream {
+				t.Error("request was not sent as a stream")
+			}
+		})
+	}
+}
+
+// 429/5xx 는 재시도하고, 400 은 바로 실패하며, 오류는 공통 ProviderError 로 분류됩니다.
+func TestClientsErrorInjection(t *testing.T) {
+	cases := []struct {
+		name     string
+		status   int
+		times    int // 0 이면 계속 실패
+		attempts int
+		kind     llm.ErrorKind // 성공하면 ""
+	}{
+		{"rate limit then ok", http.StatusTooManyRequests, 1, 2, ""},
+		{"overloaded then ok", http.StatusServiceUnavailable, 2, 3, ""},
+		{"server error exhausts retries", http.StatusInternalServerError, 0, 3, llm.ErrorKindServer},
+		{"bad request is not retried", http.StatusBadRequest, 0, 1, llm.ErrorKindInvalidRequest},
+	}
+	for _, provider := range providers {
+		for _, c := range cases {
+			t.Run(provider+"/"+c.name, func(t *testing.T) {
+				srv := fakellm.NewTestServer(fakellm.Script{
+					Rules:   []fakellm.Rule{{Times: c.times, Reply: fakellm.Reply{Status: c.status}}},
+					Default: fakellm.Reply{Text: "ok"},
+				})
+				defer srv.Close()
+
+				resp, err := llm.Complete(context.Background(), fakeClients(t, srv)[provider], llm.PromptRequest("p"))
+				if got := len(srv.Fake.Calls()); got != c.attempts {
+					t.Errorf("server saw %d calls, want %d", got, c.attempts)
+				}
+				if c.kind == "" {
+					if err != nil {
+						t.Fatal(err)
+					}
+					if resp.Attempts != c.attempts || len(resp.RetriedErrors) != c.attempts-1 {
+						t.Errorf("attempts=%d retried=%d, want %d and %d", resp.Attempts, len(resp.RetriedErrors), c.attempts, c.attempts-1)
+					}
+					return
+				}
+				var pe *llm.ProviderError
+				if !errors.As(err, &pe) {
+					t.Fatalf("got %v, want a ProviderError", err)
+				}
+				if pe.StatusCode != c.status || pe.Kind != c.kind || pe.Provider != provider {
+					t.Errorf("got %s %d %s, want %s %d %s", pe.Provider, pe.StatusCode, pe.Kind, provider, c.status, c.kind)
+				}
+				var re *llm.RetryError
+				if errors.As(err, &re) != (c.attempts > 1) {
+					t.Errorf("RetryError wrapping = %v, want %v", errors.As(err, &re), c.attempts > 1)
+				}
+			})
+		}
+	}
+}
+
+// *_BASE_URL 환경 변수만으로 기본 생성자가 가짜 서버를 가리키는지 확인합니다.
+func TestClientsBaseURLOverride(t *testing.T) {
+	srv := fakellm.NewTestServer(fakellm.DefaultScript())
+	defer srv.Close()
+	t.Setenv("OPENAI_BASE_URL", srv.OpenAIBaseURL())
+	t.Setenv("ANTHROPIC_BASE_URL", srv.AnthropicBaseURL())
+	t.Setenv("GEMINI_BASE_URL", srv.GeminiBaseURL())
+	t.Setenv("OPENAI_API_KEY", "test-openai-key")
+	t.Setenv("ANTHROPIC_API_KEY", "test-anthropic-key")
+	t.Setenv("GEMINI_API_KEY", "test-gemini-key")
+
+	clients := map[string]llm.LLMClient{
+		"openai":    llm.NewOpenAIClient("gpt-test"),
+		"anthropic": llm.NewAnthropicClient("claude-test"),
+		"gemini":    llm.NewGeminiClient("gemini-test"),
+	}
+	for _, provider := range providers {
+		out, err := clients[provider].Generate(context.Background(), "ping")
+		if err != nil {
+			t.Fatalf("%s: %v", provider, err)
+		}
+		if !strings.HasPrefix(out, "["+provider+"/") {
+			t.Errorf("%s: got %q from the fake server", provider, out)
+		}
+	}
+	if got := len(srv.Fake.Calls()); got != len(providers) {
+		t.Errorf("fake server saw %d calls, want %d", got, len(providers))
+	}
+}
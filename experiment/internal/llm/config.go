@@ -0,0 +1,687 @@
+// internal/llm/config.go
+package llm
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 환경 변수 오버라이드
+const (
+	// EnvModelsFile 은 models.yaml 경로를 바꿉니다.
+	EnvModelsFile = "SPECKIT_MODELS_FILE"
+	// EnvDefaultModel 은 파일의 default 를 덮어씁니다 (CI 에서 기본 모델 교체용).
+	EnvDefaultModel = "SPECKIT_DEFAULT_MODEL"
+)
+
+// RegistryConfig 는 models.yaml 의 최상위 구조입니다.
+//
+//	default: claude
+//	providers:
+//	  anthropic: {timeout: 60s}
+//	models:
+//	  - tag: claude
+//	    provider: anthropic
+//	    model: claude-3-5-sonnet-20240620
+//	    aliases: [sonnet]
+//	    defaults: {temperature: 0.2, max_tokens: 4096}
+//	fallbacks:
+//	  - tag: resilient
+//	    chain: [claude, gpt, gemini]
+//	middleware:
+//	  - name: recover
+//	  - name: logging
+//	embedders:
+//	  - tag: embed
+//	    provider: openai
+//	    model: text-embedding-3-small
+type RegistryConfig struct {
+	Default   string                    `yaml:"default"`
+	Pricing   string                    `yaml:"pricing"` // 라우터 비용 상한용 가격표 (설정 파일 기준 상대 경로)
+	Providers map[string]ProviderConfig `yaml:"providers"`
+	Models    []ModelConfig             `yaml:"models"`
+	Fallbacks []FallbackConfig          `yaml:"fallbacks"`
+	Routers   []RouterConfig            `yaml:"routers"`
+	// Middleware 는 모델 태그를 감쌀 미들웨어 체인입니다 (위에서부터 바깥쪽).
+	Middleware []MiddlewareConfig `yaml:"middleware"`
+	// Embedders 는 임베딩 모델입니다 (LLM 모델과 별개의 태그 이름 공간).
+	Embedders []EmbedderConfig `yaml:"embedders"`
+
+	// 검증 오류에 파일 위치를 붙이기 위한 정보 (LoadRegistryConfig 가 채움)
+	source     string
+	digest     string // 파일 내용의 sha256 앞 16자
+	lines      []int
+	embedLines []int
+}
+
+// ProviderConfig 는 같은 공급자를 쓰는 모든 모델에 적용되는 공통 설정입니다.
+type ProviderConfig struct {
+	BaseURL   string            `yaml:"base_url"`
+	APIKeyEnv string            `yaml:"api_key_env"` // 키의 비밀 이름 (env → .env → secret/<이름> → 볼트 순으로 조회)
+	Timeout   time.Duration     `yaml:"timeout"`
+	Headers   map[string]string `yaml:"headers"`
+	// Limits 는 이 공급자의 모든 모델이 함께 쓰는 한도입니다 (계정 단위 RPM/TPM 등).
+	Limits Limits `yaml:"limits"`
+}
+
+// ModelConfig 는 태그 하나로 등록될 모델 정의입니다. 빈 항목은 providers 설정을 따릅니다.
+type ModelConfig struct {
+	Tag       string             `yaml:"tag"`
+	Provider  string             `yaml:"provider"`
+	Model     string             `yaml:"model"`
+	Aliases   []string           `yaml:"aliases"`
+	BaseURL   string             `yaml:"base_url"`
+	APIKeyEnv string             `yaml:"api_key_env"` // 비밀 이름 (ProviderConfig 참고)
+	Timeout   time.Duration      `yaml:"timeout"`
+	Defaults  GenerationDefaults `yaml:"defaults"`
+	Limits    Limits             `yaml:"limits"` // 이 태그만의 한도 (공급자 한도와 함께 적용)
+
+	// openai-compatible / ollama 전용: 추가 헤더와 인증 헤더 형식
+	Headers    map[string]string `yaml:"headers"`
+	AuthHeader string            `yaml:"auth_header"` // 기본 "Authorization"
+	AuthScheme string            `yaml:"auth_scheme"` // 기본 "Bearer" ("-" 면 접두어 없이 키만)
+}
+
+// FallbackConfig 는 chain 의 태그를 순서대로 시도하는 FallbackClient 를 tag 로 등록합니다.
+type FallbackConfig struct {
+	Tag             string        `yaml:"tag"`
+	Chain           []string      `yaml:"chain"`
+	PerModelTimeout time.Duration `yaml:"per_model_timeout"`
+}
+
+// RouterConfig 는 규칙으로 모델을 고르는 RouterClient 를 tag 로 등록합니다.
+type RouterConfig struct {
+	Tag     string      `yaml:"tag"`
+	Default string      `yaml:"default"`
+	Rules   []RouteRule `yaml:"rules"`
+}
+
+// MiddlewareConfig 는 RegisterMiddleware 로 등록된 미들웨어 하나입니다.
+// name/tags 외의 키는 미들웨어 옵션으로 팩토리에 전달됩니다.
+//
+//	middleware:
+//	  - name: size_limit
+//	    tags: [claude]          # 생략하면 모든 모델 태그
+//	    max_prompt_chars: 200000
+type MiddlewareConfig struct {
+	Name string   `yaml:"name"`
+	Tags []string `yaml:"tags"`
+
+	node yaml.Node
+}
+
+func (m *MiddlewareConfig) UnmarshalYAML(n *yaml.Node) error {
+	type plain MiddlewareConfig
+	if err := n.Decode((*plain)(m)); err != nil {
+		return err
+	}
+	m.node = *n
+	return nil
+}
+
+// build 는 팩토리로 Middleware 를 만듭니다.
+func (m MiddlewareConfig) build() (Middleware, error) {
+	factory, ok := middlewareFactory(m.Name)
+	if !ok {
+		return nil, fmt.Errorf("unknown middleware %q (one of %s)", m.Name, strings.Join(middlewareNames(), ", "))
+	}
+	return factory(func(v interface{}) error {
+		if m.node.Kind == 0 {
+			return nil
+		}
+		return m.node.Decode(v)
+	})
+}
+
+// EmbedderConfig 는 태그 하나로 등록될 임베딩 모델 정의입니다. 빈 항목은 providers 설정을 따릅니다.
+type EmbedderConfig struct {
+	Tag        string        `yaml:"tag"`
+	Provider   string        `yaml:"provider"` // openai, gemini, openai-compatible
+	Model      string        `yaml:"model"`
+	Dimensions int           `yaml:"dimensions"` // 0 이면 모델 기본 차원
+	BatchSize  int           `yaml:"batch_size"` // 0 이면 DefaultEmbedBatchSize
+	TaskType   string        `yaml:"task_type"`  // gemini 전용 (예: SEMANTIC_SIMILARITY)
+	BaseURL    string        `yaml:"base_url"`
+	APIKeyEnv  string        `yaml:"api_key_env"`
+	Timeout    time.Duration `yaml:"timeout"`
+
+	Headers    map[string]string `yaml:"headers"`
+	AuthHeader string            `yaml:"auth_header"`
+	AuthScheme string            `yaml:"auth_scheme"`
+}
+
+// model 은 같은 공급자의 클라이언트 팩토리에 넘길 ModelConfig 입니다 (주소/인증/타임아웃 공유).
+func (e EmbedderConfig) model() ModelConfig {
+	return ModelConfig{
+		Tag: e.Tag, Provider: e.Provider, Model: e.Model,
+		BaseURL: e.BaseURL, APIKeyEnv: e.APIKeyEnv, Timeout: e.Timeout,
+		Headers: e.Headers, AuthHeader: e.AuthHeader, AuthScheme: e.AuthScheme,
+	}
+}
+
+// embedderProviders 는 임베딩을 지원하는 공급자입니다. 클라이언트는 providers 팩토리로 만든 뒤 감쌉니다.
+var embedderProviders = map[string]func(client LLMClient, ec EmbedderConfig) (Embedder, error){
+	"openai":            newOpenAIEmbedderFromConfig,
+	"openai-compatible": newOpenAIEmbedderFromConfig,
+	"gemini":            newGeminiEmbedderFromConfig,
+}
+
+func newOpenAIEmbedderFromConfig(client LLMClient, ec EmbedderConfig) (Embedder, error) {
+	c, ok := client.(*OpenAIClient)
+	if !ok {
+		return nil, fmt.Errorf("provider %q is not an OpenAI client (%T)", ec.Provider, client)
+	}
+	return &OpenAIEmbedder{Client: c, BatchSize: ec.BatchSize, dims: embedDims{configured: ec.Dimensions}}, nil
+}
+
+func newGeminiEmbedderFromConfig(client LLMClient, ec EmbedderConfig) (Embedder, error) {
+	c, ok := client.(*GeminiClient)
+	if !ok {
+		return nil, fmt.Errorf("provider %q is not a Gemini client (%T)", ec.Provider, client)
+	}
+	return &GeminiEmbedder{Client: c, BatchSize: ec.BatchSize, TaskType: ec.TaskType,
+		dims: embedDims{configured: ec.Dimensions}}, nil
+}
+
+func embedderProviderNames() []string {
+	names := make([]string, 0, len(embedderProviders))
+	for n := range embedderProviders {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ProviderFactory 는 ModelConfig 로 클라이언트를 만듭니다.
+type ProviderFactory func(mc ModelConfig) (LLMClient, error)
+
+var (
+	providersMu sync.RWMutex
+	providers   = map[string]ProviderFactory{
+		"openai":    newOpenAIFromConfig,
+		"anthropic": newAnthropicFromConfig,
+		"gemini":    newGeminiFromConfig,
+
+		"openai-compatible": newOpenAICompatibleFromConfig,
+		"ollama":            newOllamaFromConfig,
+	}
+)
+
+// RegisterProvider 는 models.yaml 의 provider 이름에 대응하는 팩토리를 등록합니다.
+func RegisterProvider(name string, factory ProviderFactory) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+	providers[name] = factory
+}
+
+func providerFactory(name string) (ProviderFactory, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	f, ok := providers[name]
+	return f, ok
+}
+
+func providerNames() []string {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+	names := make([]string, 0, len(providers))
+	for n := range providers {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadRegistryConfig 는 YAML 설정 파일을 읽고 검증합니다.
+// EnvDefaultModel 이 설정돼 있으면 default 를 덮어씁니다.
+func LoadRegistryConfig(path string) (*RegistryConfig, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read models config: %w", err)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(b, &root); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	var cfg RegistryConfig
+	if err := root.Decode(&cfg); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+	cfg.source = path
+	sum := sha256.Sum256(b)
+	cfg.digest = hex.EncodeToString(sum[:])[:16]
+	cfg.lines = sectionLines(&root, "models")
+	cfg.embedLines = sectionLines(&root, "embedders")
+
+	if v := os.Getenv(EnvDefaultModel); v != "" {
+		cfg.Default = v
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// sectionLines 는 최상위 key 배열(models, embedders) 각 항목의 시작 줄 번호를 찾습니다.
+func sectionLines(root *yaml.Node, key string) []int {
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	m := root.Content[0]
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key && m.Content[i+1].Kind == yaml.SequenceNode {
+			lines := make([]int, len(m.Content[i+1].Content))
+			for j, n := range m.Content[i+1].Content {
+				lines[j] = n.Line
+			}
+			return lines
+		}
+	}
+	return nil
+}
+
+// ConfigError 는 설정 항목 하나에 대한 검증 오류입니다.
+type ConfigError struct {
+	Source string // 파일 경로 (알 수 없으면 빈 값)
+	Line   int    // 항목 시작 줄 (알 수 없으면 0)
+	Entry  string // 예: `models[2] (tag "gpt")`
+	Msg    string
+}
+
+func (e *ConfigError) Error() string {
+	loc := e.Source
+	if loc != "" && e.Line > 0 {
+		loc = fmt.Sprintf("%s:%d", loc, e.Line)
+	}
+	if loc != "" {
+		return fmt.Sprintf("%s: %s: %s", loc, e.Entry, e.Msg)
+	}
+	return fmt.Sprintf("%s: %s", e.Entry, e.Msg)
+}
+
+// Validate 는 모든 항목을 검사하고 문제를 모아 errors.Join 으로 반환합니다.
+func (c *RegistryConfig) Validate() error {
+	var errs []error
+	fail := func(i int, msg string, args ...interface{}) {
+		e := &ConfigError{Source: c.source, Msg: fmt.Sprintf(msg, args...)}
+		if i < 0 {
+			e.Entry = "default"
+		} else {
+			e.Entry = fmt.Sprintf("models[%d]", i)
+			if t := c.Models[i].Tag; t != "" {
+				e.Entry += fmt.Sprintf(" (tag %q)", t)
+			}
+			if i < len(c.lines) {
+				e.Line = c.lines[i]
+			}
+		}
+		errs = append(errs, e)
+	}
+
+	names := map[string]string{} // tag/alias → 어디서 정의됐는지
+	for i, m := range c.Models {
+		where := fmt.Sprintf("models[%d]", i)
+		switch {
+		case m.Tag == "":
+			fail(i, "tag is required")
+		case names[m.Tag] != "":
+			fail(i, "tag %q already defined by %s", m.Tag, names[m.Tag])
+		default:
+			names[m.Tag] = where
+		}
+		if m.Provider == "" {
+			fail(i, "provider is required (one of %s)", strings.Join(providerNames(), ", "))
+		} else if _, ok := providerFactory(m.Provider); !ok {
+			fail(i, "unknown provider %q (one of %s)", m.Provider, strings.Join(providerNames(), ", "))
+		}
+		if m.Model == "" {
+			fail(i, "model is required")
+		}
+		if m.Timeout < 0 {
+			fail(i, "timeout must not be negative")
+		}
+		if m.Provider == "openai-compatible" && m.BaseURL == "" && c.Providers[m.Provider].BaseURL == "" {
+			fail(i, "base_url is required for provider openai-compatible")
+		}
+		if t := m.Defaults.Temperature; t != nil && (*t < 0 || *t > 2) {
+			fail(i, "defaults.temperature %.2f out of range [0, 2]", *t)
+		}
+		if p := m.Defaults.TopP; p != nil && (*p < 0 || *p > 1) {
+			fail(i, "defaults.top_p %.2f out of range [0, 1]", *p)
+		}
+		if m.Defaults.MaxTokens < 0 {
+			fail(i, "defaults.max_tokens must not be negative")
+		}
+		if l := m.Limits; l.MaxInFlight < 0 || l.RPM < 0 || l.TPM < 0 {
+			fail(i, "limits must not be negative")
+		}
+	}
+	// 별칭은 모든 태그를 확인한 뒤 검사합니다 (뒤에 나오는 태그와의 충돌도 잡기 위해).
+	for i, m := range c.Models {
+		for _, a := range m.Aliases {
+			if prev := names[a]; prev != "" {
+				fail(i, "alias %q already defined by %s", a, prev)
+				continue
+			}
+			names[a] = fmt.Sprintf("models[%d].aliases", i)
+		}
+	}
+	// 복합 클라이언트 (fallbacks, routers): 자기 태그를 먼저 등록한 뒤 참조를 검사합니다.
+	composite := func(entry, tag string) {
+		switch {
+		case tag == "":
+			errs = append(errs, &ConfigError{Source: c.source, Entry: entry, Msg: "tag is required"})
+		case names[tag] != "":
+			errs = append(errs, &ConfigError{Source: c.source, Entry: entry, Msg: fmt.Sprintf("tag %q already defined by %s", tag, names[tag])})
+		default:
+			names[tag] = entry
+		}
+	}
+	for i, f := range c.Fallbacks {
+		composite(fmt.Sprintf("fallbacks[%d]", i), f.Tag)
+	}
+	for i, r := range c.Routers {
+		composite(fmt.Sprintf("routers[%d]", i), r.Tag)
+	}
+	ref := func(entry, field, tag string) {
+		if names[tag] == "" {
+			errs = append(errs, &ConfigError{Source: c.source, Entry: entry, Msg: fmt.Sprintf("%s: %q is not a defined tag or alias", field, tag)})
+		}
+	}
+	for i, f := range c.Fallbacks {
+		entry := fmt.Sprintf("fallbacks[%d] (tag %q)", i, f.Tag)
+		if len(f.Chain) == 0 {
+			errs = append(errs, &ConfigError{Source: c.source, Entry: entry, Msg: "chain is empty"})
+		}
+		for _, t := range f.Chain {
+			ref(entry, "chain", t)
+		}
+	}
+	for i, r := range c.Routers {
+		entry := fmt.Sprintf("routers[%d] (tag %q)", i, r.Tag)
+		if r.Default != "" {
+			ref(entry, "default", r.Default)
+		}
+		for j, rule := range r.Rules {
+			field := fmt.Sprintf("rules[%d].tags", j)
+			if len(rule.Tags) == 0 {
+				errs = append(errs, &ConfigError{Source: c.source, Entry: entry, Msg: field + " is empty"})
+			}
+			for _, t := range rule.Tags {
+				ref(entry, field, t)
+			}
+		}
+	}
+	for i, m := range c.Middleware {
+		entry := fmt.Sprintf("middleware[%d]", i)
+		if m.Name != "" {
+			entry += fmt.Sprintf(" (%s)", m.Name)
+		}
+		if m.Name == "" {
+			errs = append(errs, &ConfigError{Source: c.source, Line: m.node.Line, Entry: entry, Msg: "name is required"})
+		} else if _, err := m.build(); err != nil {
+			errs = append(errs, &ConfigError{Source: c.source, Line: m.node.Line, Entry: entry, Msg: err.Error()})
+		}
+		for _, t := range m.Tags {
+			ref(entry, "tags", t)
+		}
+	}
+
+	embedTags := map[string]int{}
+	for i, e := range c.Embedders {
+		entry := fmt.Sprintf("embedders[%d]", i)
+		if e.Tag != "" {
+			entry += fmt.Sprintf(" (tag %q)", e.Tag)
+		}
+		efail := func(msg string, args ...interface{}) {
+			ce := &ConfigError{Source: c.source, Entry: entry, Msg: fmt.Sprintf(msg, args...)}
+			if i < len(c.embedLines) {
+				ce.Line = c.embedLines[i]
+			}
+			errs = append(errs, ce)
+		}
+		if e.Tag == "" {
+			efail("tag is required")
+		} else if prev, ok := embedTags[e.Tag]; ok {
+			efail("tag %q already defined by embedders[%d]", e.Tag, prev)
+		} else {
+			embedTags[e.Tag] = i
+		}
+		if _, ok := embedderProviders[e.Provider]; !ok {
+			efail("provider %q does not support embeddings (one of %s)", e.Provider, strings.Join(embedderProviderNames(), ", "))
+		}
+		if e.Model == "" {
+			efail("model is required")
+		}
+		if e.Dimensions < 0 || e.BatchSize < 0 || e.Timeout < 0 {
+			efail("dimensions, batch_size and timeout must not be negative")
+		}
+		if e.Provider == "openai-compatible" && e.BaseURL == "" && c.Providers[e.Provider].BaseURL == "" {
+			efail("base_url is required for provider openai-compatible")
+		}
+	}
+
+	if c.Default != "" && names[c.Default] == "" {
+		fail(-1, "%q is not a defined tag or alias", c.Default)
+	}
+	return errors.Join(errs...)
+}
+
+// resolved 는 providers 공통 설정을 채운 ModelConfig 입니다.
+func (c *RegistryConfig) resolved(m ModelConfig) ModelConfig {
+	p := c.Providers[m.Provider]
+	if m.BaseURL == "" {
+		m.BaseURL = p.BaseURL
+	}
+	if m.APIKeyEnv == "" {
+		m.APIKeyEnv = p.APIKeyEnv
+	}
+	if m.Timeout == 0 {
+		m.Timeout = p.Timeout
+	}
+	if len(p.Headers) > 0 {
+		h := make(map[string]string, len(p.Headers)+len(m.Headers))
+		for k, v := range p.Headers {
+			h[k] = v
+		}
+		for k, v := range m.Headers {
+			h[k] = v
+		}
+		m.Headers = h
+	}
+	return m
+}
+
+// Build 는 설정으로 ModelRegistry 를 만듭니다.
+func (c *RegistryConfig) Build() (*ModelRegistry, error) {
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	reg := NewModelRegistry()
+	shared := map[string]*Limiter{} // 공급자별 한도는 모델끼리 공유합니다
+	for name, p := range c.Providers {
+		if !p.Limits.IsZero() {
+			shared[name] = NewLimiter(p.Limits)
+		}
+	}
+	for i, m := range c.Models {
+		m = c.resolved(m)
+		factory, _ := providerFactory(m.Provider)
+		client, err := factory(m)
+		if err != nil {
+			e := &ConfigError{Source: c.source, Entry: fmt.Sprintf("models[%d] (tag %q)", i, m.Tag), Msg: err.Error()}
+			if i < len(c.lines) {
+				e.Line = c.lines[i]
+			}
+			return nil, e
+		}
+		// 한도는 기본값을 채운 요청으로 추정해야 하므로 defaults 바깥에 씌웁니다.
+		client = WithLimiter(WithDefaults(client, m.Defaults), shared[m.Provider])
+		if !m.Limits.IsZero() {
+			client = WithLimiter(client, NewLimiter(m.Limits))
+		}
+		reg.RegisterModel(m.Tag, client)
+		for _, a := range m.Aliases {
+			reg.RegisterAlias(a, m.Tag)
+		}
+	}
+	// 미들웨어는 모델 태그에만 붙입니다 (복합 클라이언트는 감싼 태그를 호출하므로).
+	// 뒤에서부터 감싸야 목록의 첫 항목이 가장 바깥이 됩니다.
+	for i := len(c.Middleware) - 1; i >= 0; i-- {
+		m := c.Middleware[i]
+		mw, _ := m.build()
+		if len(m.Tags) == 0 {
+			reg.Use(mw)
+		} else if err := reg.UseFor(m.Tags, mw); err != nil {
+			return nil, &ConfigError{Source: c.source, Line: m.node.Line, Entry: fmt.Sprintf("middleware[%d]", i), Msg: err.Error()}
+		}
+	}
+	for _, f := range c.Fallbacks {
+		fc := NewFallbackClient(reg, f.Chain...)
+		fc.PerModelTimeout = f.PerModelTimeout
+		reg.RegisterModel(f.Tag, fc)
+	}
+	if len(c.Routers) > 0 {
+		var prices PriceTable
+		if c.Pricing != "" {
+			p := c.Pricing
+			if !filepath.IsAbs(p) && c.source != "" {
+				p = filepath.Join(filepath.Dir(c.source), p)
+			}
+			var err error
+			if prices, err = LoadPriceTable(p); err != nil {
+				return nil, &ConfigError{Source: c.source, Entry: "pricing", Msg: err.Error()}
+			}
+		}
+		for _, r := range c.Routers {
+			rc := NewRouterClient(reg, r.Default, r.Rules...)
+			rc.Prices = prices
+			reg.RegisterModel(r.Tag, rc)
+		}
+	}
+	for i, e := range c.Embedders {
+		m := c.resolved(e.model())
+		factory, _ := providerFactory(m.Provider)
+		client, err := factory(m)
+		var emb Embedder
+		if err == nil {
+			emb, err = embedderProviders[e.Provider](client, e)
+		}
+		if err != nil {
+			ce := &ConfigError{Source: c.source, Entry: fmt.Sprintf("embedders[%d] (tag %q)", i, e.Tag), Msg: err.Error()}
+			if i < len(c.embedLines) {
+				ce.Line = c.embedLines[i]
+			}
+			return nil, ce
+		}
+		reg.RegisterEmbedder(e.Tag, emb)
+	}
+	if c.Default != "" {
+		reg.SetDefault(c.Default)
+	}
+	reg.digest = c.digest
+	return reg, nil
+}
+
+// ConfigDigest 는 레지스트리를 만든 설정 파일 내용의 해시입니다 (파일 없이 만들었으면 "").
+// 모델, 기본값, 폴백 설정이 바뀌었는지 입력 해시에 넣어 확인할 때 씁니다.
+func (r *ModelRegistry) ConfigDigest() string { return r.digest }
+
+// LoadRegistry 는 path 의 설정 파일로 레지스트리를 만듭니다.
+// EnvModelsFile 이 설정돼 있으면 path 대신 그 파일을 읽습니다.
+func LoadRegistry(path string) (*ModelRegistry, error) {
+	if v := os.Getenv(EnvModelsFile); v != "" {
+		path = v
+	}
+	cfg, err := LoadRegistryConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	return cfg.Build()
+}
+
+// ---- 기본 공급자 팩토리 ----
+
+func newOpenAIFromConfig(mc ModelConfig) (LLMClient, error) {
+	c := NewOpenAIClient(mc.Model)
+	if mc.BaseURL != "" {
+		c.BaseURL = mc.BaseURL
+	}
+	if mc.APIKeyEnv != "" {
+		c.apiKey = secretNamed(mc.APIKeyEnv)
+	}
+	if mc.Timeout > 0 {
+		c.httpc.Timeout = mc.Timeout
+	}
+	return c, nil
+}
+
+func newAnthropicFromConfig(mc ModelConfig) (LLMClient, error) {
+	c := NewAnthropicClient(mc.Model)
+	if mc.BaseURL != "" {
+		c.BaseURL = mc.BaseURL
+	}
+	if mc.APIKeyEnv != "" {
+		c.apiKey = secretNamed(mc.APIKeyEnv)
+	}
+	if mc.Timeout > 0 {
+		c.client.Timeout = mc.Timeout
+	}
+	return c, nil
+}
+
+func newGeminiFromConfig(mc ModelConfig) (LLMClient, error) {
+	c := NewGeminiClient(mc.Model)
+	if mc.BaseURL != "" {
+		c.BaseURL = mc.BaseURL
+	}
+	if mc.APIKeyEnv != "" {
+		c.apiKey = secretNamed(mc.APIKeyEnv)
+	}
+	if mc.Timeout > 0 {
+		c.httpc.Timeout = mc.Timeout
+	}
+	return c, nil
+}
+
+// newOpenAICompatibleFromConfig 는 vLLM, LM Studio, llama.cpp server, Ollama(/v1) 처럼
+// OpenAI Chat Completions 형식을 따르는 서버용 클라이언트를 만듭니다.
+func newOpenAICompatibleFromConfig(mc ModelConfig) (LLMClient, error) {
+	c := NewOpenAICompatibleClient(mc.BaseURL, mc.Model)
+	if mc.APIKeyEnv != "" {
+		c.SetAPIKeySecret(mc.APIKeyEnv)
+	}
+	if mc.Timeout > 0 {
+		c.httpc.Timeout = mc.Timeout
+	}
+	if mc.AuthHeader != "" {
+		c.AuthHeader = mc.AuthHeader
+	}
+	if mc.AuthScheme != "" {
+		c.AuthScheme = strings.TrimPrefix(mc.AuthScheme, "-")
+	}
+	c.Headers = mc.Headers
+	return c, nil
+}
+
+func newOllamaFromConfig(mc ModelConfig) (LLMClient, error) {
+	c := NewOllamaClient(mc.Model)
+	if mc.BaseURL != "" {
+		c.BaseURL = mc.BaseURL
+	}
+	if mc.Timeout > 0 {
+		c.httpc.Timeout = mc.Timeout
+	}
+	c.Headers = mc.Headers
+	return c, nil
+}
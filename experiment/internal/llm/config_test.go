@@ -0,0 +1,221 @@
+// internal/llm/config_test.go
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"speckit-study/internal/fakellm"
+	"speckit-study/internal/llm"
+)
+
+func writeConfig(t *testing.T, doc string) string {
+	t.Helper()
+	p := filepath.Join(t.TempDir(), "models.yaml")
+	if err := os.WriteFile(p, []byte(doc), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+// 검증 오류는 한 번에 모두 보고되고, 모델/임베더 항목은 시작 줄 번호를 가집니다.
+func TestConfigValidateLines(t *testing.T) {
+	p := writeConfig(t, `default: missing
+models:
+  - tag: ok
+    provider: openai-compatible
+    model: m
+    base_url: http://localhost/v1
+  - tag: ok
+    provider: nope
+    model: m
+  - tag: hot
+    provider: anthropic
+    model: m
+    aliases: [ok]
+    defaults: {temperature: 3}
+fallbacks:
+  - tag: chain
+    chain: [hot, ghost]
+middleware:
+  - name: size_limit
+    max_prompt_chars: -1
+embedders:
+  - tag: e
+    provider: anthropic
+    model: x
+`)
+	_, err := llm.LoadRegistryConfig(p)
+	if err == nil {
+		t.Fatal("want validation errors")
+	}
+	var got []*llm.ConfigError
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var ce *llm.ConfigError
+		if !errors.As(e, &ce) {
+			t.Fatalf("%v is not a ConfigError", e)
+		}
+		got = append(got, ce)
+	}
+
+	want := []struct {
+		entry string
+		line  int
+		msg   string
+	}{
+		{`models[1] (tag "ok")`, 7, `tag "ok" already defined by models[0]`},
+		{`models[1] (tag "ok")`, 7, `unknown provider "nope"`},
+		{`models[2] (tag "hot")`, 10, "defaults.temperature 3.00 out of range"},
+		{`models[2] (tag "hot")`, 10, `alias "ok" already defined by models[0]`},
+		{`fallbacks[0] (tag "chain")`, 0, `chain: "ghost" is not a defined tag or alias`},
+		{"middleware[0] (size_limit)", 19, "size limits must not be negative"},
+		{`embedders[0] (tag "e")`, 22, `provider "anthropic" does not support embeddings`},
+		{"default", 0, `"missing" is not a defined tag or alias`},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d errors, want %d:\n%v", len(got), len(want), err)
+	}
+	for i, w := range want {
+		g := got[i]
+		if g.Entry != w.entry || g.Line != w.line || !strings.HasPrefix(g.Msg, w.msg) {
+			t.Errorf("error %d = %s (line %d), want %s line %d: %s", i, g, g.Line, w.entry, w.line, w.msg)
+		}
+	}
+	if !strings.HasPrefix(got[0].Error(), p+":7: ") {
+		t.Errorf("Error() = %q, want the file:line prefix", got[0].Error())
+	}
+}
+
+// 유효한 설정은 별칭, 공급자 공통 설정, 폴백을 모두 등록합니다.
+func TestConfigBuild(t *testing.T) {
+	srv := fakellm.NewTestServer(fakellm.DefaultScript())
+	defer srv.Close()
+	p := writeConfig(t, `default: fast
+providers:
+  openai-compatible:
+    base_url: `+srv.OpenAIBaseURL()+`
+models:
+  - tag: local
+    provider: openai-compatible
+    model: small
+    aliases: [fast]
+fallbacks:
+  - tag: safe
+    chain: [fast]
+`)
+	t.Setenv(llm.EnvDefaultModel, "")
+	reg, err := llm.LoadRegistry(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reg.DefaultTag() != "local" {
+		t.Errorf("default tag = %q, want the alias resolved to local", reg.DefaultTag())
+	}
+	for _, tag := range []string{"fast", "safe"} {
+		client, ok := reg.GetModel(tag)
+		if !ok {
+			t.Fatalf("%s not registered", tag)
+		}
+		out, err := client.Generate(context.Background(), "hi")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if out != "[openai/small] hi" {
+			t.Errorf("%s: got %q", tag, out)
+		}
+	}
+
+	// EnvDefaultModel 은 파일의 default 를 덮어쓰고, 없는 태그면 검증에서 걸립니다.
+	t.Setenv(llm.EnvDefaultModel, "nowhere")
+	if _, err := llm.LoadRegistryConfig(p); err == nil || !strings.Contains(err.Error(), `"nowhere" is not a defined tag`) {
+		t.Errorf("got %v, want the overridden default rejected", err)
+	}
+}
+
+// 한도의 토큰 예약은 defaults.max_tokens 가 채워진 요청으로 추정합니다.
+// 실패한 호출은 예약을 돌려받지 못하므로, 기본값(DefaultMaxTokens)으로 추정하면 버킷이 비어 다음 호출이 기다립니다.
+func TestConfigLimitsUseDefaults(t *testing.T) {
+	var calls atomic.Int32
+	var maxTokens []int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			MaxTokens int `json:"max_tokens"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		maxTokens = append(maxTokens, body.MaxTokens)
+		if calls.Add(1) == 1 {
+			http.Error(w, `{"error":{"message":"bad request"}}`, http.StatusBadRequest)
+			return
+		}
+		io.WriteString(w, `{"choices":[{"message":{"content":"ok"}}],"usage":{"prompt_tokens":1,"completion_tokens":1}}`)
+	}))
+	defer srv.Close()
+	p := writeConfig(t, `default: local
+models:
+  - tag: local
+    provider: openai-compatible
+    model: small
+    base_url: `+srv.URL+`
+    defaults: {max_tokens: 20}
+    limits: {tpm: 120}
+`)
+	reg, err := llm.LoadRegistry(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client, _ := reg.GetModel("local")
+	if _, err := llm.Complete(context.Background(), client, llm.PromptRequest("p")); err == nil {
+		t.Fatal("first call: want the server error")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	resp, err := llm.Complete(ctx, client, llm.PromptRequest("p"))
+	if err != nil {
+		t.Fatalf("second call: %v (the first call reserved more than defaults.max_tokens)", err)
+	}
+	if resp.QueueWait > 100*time.Millisecond {
+		t.Errorf("queue wait %s, want the reservation sized by defaults.max_tokens", resp.QueueWait)
+	}
+	if len(maxTokens) != 2 || maxTokens[0] != 20 || maxTokens[1] != 20 {
+		t.Errorf("server saw max_tokens %v, want 20 on every call", maxTokens)
+	}
+}
+
+// ConfigDigest 는 설정 파일 내용이 같으면 같고, 한 글자라도 바뀌면 달라집니다.
+func TestConfigDigest(t *testing.T) {
+	t.Setenv(llm.EnvModelsFile, "")
+	doc := `models:
+  - tag: local
+    provider: openai-compatible
+    model: small
+    base_url: http://localhost:1
+`
+	digest := func(doc string) string {
+		t.Helper()
+		reg, err := llm.LoadRegistry(writeConfig(t, doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reg.ConfigDigest()
+	}
+	a, b := digest(doc), digest(doc)
+	if a == "" || a != b {
+		t.Errorf("same file: %q vs %q", a, b)
+	}
+	if c := digest(strings.Replace(doc, "small", "large", 1)); c == a {
+		t.Error("changed model name kept the digest")
+	}
+	if d := llm.NewModelRegistry().ConfigDigest(); d != "" {
+		t.Errorf("registry built in code: digest %q, want empty", d)
+	}
+}
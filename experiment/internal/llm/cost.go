@@ -0,0 +1,196 @@
+// internal/llm/cost.go
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Pricing 은 100만 토큰당 가격(USD)입니다.
+// CachedPerMTok 이 0 이면 캐시된 입력 토큰도 InputPerMTok 으로 계산합니다.
+type Pricing struct {
+	InputPerMTok  float64 `yaml:"input_per_mtok" json:"input_per_mtok"`
+	OutputPerMTok float64 `yaml:"output_per_mtok" json:"output_per_mtok"`
+	CachedPerMTok float64 `yaml:"cached_per_mtok,omitempty" json:"cached_per_mtok,omitempty"`
+}
+
+// Cost 는 u 에 대한 비용(USD)입니다.
+func (p Pricing) Cost(u Usage) float64 {
+	cached := min(u.CachedTokens, u.InputTokens)
+	cachedRate := p.CachedPerMTok
+	if cachedRate == 0 {
+		cachedRate = p.InputPerMTok
+	}
+	return (float64(u.InputTokens-cached)*p.InputPerMTok +
+		float64(cached)*cachedRate +
+		float64(u.OutputTokens)*p.OutputPerMTok) / 1e6
+}
+
+// PriceTable 은 모델 태그(또는 모델 이름) → 가격표입니다.
+type PriceTable map[string]Pricing
+
+// LoadPriceTable 은 YAML 가격표 파일을 읽습니다.
+//
+//	claude:
+//	  input_per_mtok: 3
+//	  output_per_mtok: 15
+func LoadPriceTable(path string) (PriceTable, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read pricing: %w", err)
+	}
+	var t PriceTable
+	if err := yaml.Unmarshal(b, &t); err != nil {
+		return nil, fmt.Errorf("parse pricing %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Lookup 은 태그, 모델 이름 순으로 가격을 찾습니다.
+func (t PriceTable) Lookup(tag, model string) (Pricing, bool) {
+	if p, ok := t[tag]; ok {
+		return p, true
+	}
+	p, ok := t[model]
+	return p, ok
+}
+
+// CostLine 은 집계 단위(모델/태스크/전체) 하나의 합계입니다.
+type CostLine struct {
+	Calls   int     `json:"calls"`
+	Usage   Usage   `json:"usage"`
+	CostUSD float64 `json:"cost_usd"`
+}
+
+func (l *CostLine) add(u Usage, cost float64) {
+	l.Calls++
+	l.Usage.InputTokens += u.InputTokens
+	l.Usage.OutputTokens += u.OutputTokens
+	l.Usage.CachedTokens += u.CachedTokens
+	l.CostUSD += cost
+}
+
+// CostSummary 는 한 번의 실행(run)에 대한 비용 집계입니다.
+type CostSummary struct {
+	Total    CostLine            `json:"total"`
+	ByModel  map[string]CostLine `json:"by_model"` // key: "tag/model"
+	ByTask   map[string]CostLine `json:"by_task"`
+	Unpriced []string            `json:"unpriced,omitempty"` // 가격표에 없는 "tag/model"
+}
+
+// CostTracker 는 호출별 Usage 를 모아 실행/모델/태스크 단위로 집계합니다. 여러 고루틴에서 써도 안전합니다.
+type CostTracker struct {
+	Prices PriceTable
+
+	mu       sync.Mutex
+	total    CostLine
+	byModel  map[string]*CostLine
+	byTask   map[string]*CostLine
+	unpriced map[string]bool
+}
+
+// NewCostTracker 는 prices 로 비용을 계산하는 트래커를 만듭니다 (nil 이면 토큰만 집계).
+func NewCostTracker(prices PriceTable) *CostTracker {
+	return &CostTracker{
+		Prices:   prices,
+		byModel:  map[string]*CostLine{},
+		byTask:   map[string]*CostLine{},
+		unpriced: map[string]bool{},
+	}
+}
+
+// Record 는 호출 한 건의 Usage 를 집계하고 그 호출의 비용을 반환합니다.
+func (t *CostTracker) Record(task, tag, model string, u Usage) float64 {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	modelKey := tag + "/" + model
+	var cost float64
+	if p, ok := t.Prices.Lookup(tag, model); ok {
+		cost = p.Cost(u)
+	} else {
+		t.unpriced[modelKey] = true
+	}
+
+	t.total.add(u, cost)
+	line(t.byModel, modelKey).add(u, cost)
+	line(t.byTask, task).add(u, cost)
+	return cost
+}
+
+func line(m map[string]*CostLine, key string) *CostLine {
+	l, ok := m[key]
+	if !ok {
+		l = &CostLine{}
+		m[key] = l
+	}
+	return l
+}
+
+// Summary 는 현재까지의 집계 스냅샷입니다.
+func (t *CostTracker) Summary() CostSummary {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	s := CostSummary{
+		Total:   t.total,
+		ByModel: make(map[string]CostLine, len(t.byModel)),
+		ByTask:  make(map[string]CostLine, len(t.byTask)),
+	}
+	for k, v := range t.byModel {
+		s.ByModel[k] = *v
+	}
+	for k, v := range t.byTask {
+		s.ByTask[k] = *v
+	}
+	for k := range t.unpriced {
+		s.Unpriced = append(s.Unpriced, k)
+	}
+	sort.Strings(s.Unpriced)
+	return s
+}
+
+// String 은 사람이 읽기 위한 비용 요약 표입니다.
+func (s CostSummary) String() string {
+	var sb strings.Builder
+	writeLines := func(title string, m map[string]CostLine) {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		fmt.Fprintf(&sb, "%s\n", title)
+		for _, k := range keys {
+			l := m[k]
+			fmt.Fprintf(&sb, "  %-40s calls=%-3d in=%-7d out=%-7d cached=%-7d $%.4f\n",
+				k, l.Calls, l.Usage.InputTokens, l.Usage.OutputTokens, l.Usage.CachedTokens, l.CostUSD)
+		}
+	}
+	writeLines("by model:", s.ByModel)
+	writeLines("by task:", s.ByTask)
+	fmt.Fprintf(&sb, "total: calls=%d in=%d out=%d cached=%d $%.4f\n",
+		s.Total.Calls, s.Total.Usage.InputTokens, s.Total.Usage.OutputTokens, s.Total.Usage.CachedTokens, s.Total.CostUSD)
+	if len(s.Unpriced) > 0 {
+		fmt.Fprintf(&sb, "unpriced (no entry in pricing table): %s\n", strings.Join(s.Unpriced, ", "))
+	}
+	return sb.String()
+}
+
+// WriteFile 은 요약을 JSON 으로 path 에 씁니다.
+func (s CostSummary) WriteFile(path string) error {
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, b, 0o644)
+}
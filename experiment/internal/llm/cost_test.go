@@ -0,0 +1,59 @@
+// internal/llm/cost_test.go
+package llm_test
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"speckit-study/internal/llm"
+)
+
+func TestPricingCost(t *testing.T) {
+	p := llm.Pricing{InputPerMTok: 3, OutputPerMTok: 15, CachedPerMTok: 0.3}
+	cases := []struct {
+		name string
+		p    llm.Pricing
+		u    llm.Usage
+		want float64
+	}{
+		{"no cache", p, llm.Usage{InputTokens: 1_000_000, OutputTokens: 100_000}, 3 + 1.5},
+		{"cached part of input", p, llm.Usage{InputTokens: 1_000_000, CachedTokens: 800_000}, 0.2*3 + 0.8*0.3},
+		{"no cached rate", llm.Pricing{InputPerMTok: 3}, llm.Usage{InputTokens: 1_000_000, CachedTokens: 500_000}, 3},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.p.Cost(c.u); math.Abs(got-c.want) > 1e-9 {
+				t.Errorf("Cost(%+v) = %v, want %v", c.u, got, c.want)
+			}
+		})
+	}
+}
+
+// Anthropic 은 캐시에서 읽은 토큰을 input_tokens 와 따로 보고하므로 InputTokens 에 더해야 합니다.
+func TestAnthropicUsageIncludesCacheReads(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn",
+			"usage":{"input_tokens":200,"output_tokens":10,"cache_read_input_tokens":800}}`))
+	}))
+	defer srv.Close()
+	t.Setenv("ANTHROPIC_API_KEY", "test-anthropic-key")
+	c := llm.NewAnthropicClient("claude-test")
+	c.BaseURL = srv.URL + "/v1"
+
+	resp, err := c.Complete(context.Background(), llm.PromptRequest("p"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := llm.Usage{InputTokens: 1000, OutputTokens: 10, CachedTokens: 800}
+	if resp.Usage != want {
+		t.Fatalf("usage = %+v, want %+v", resp.Usage, want)
+	}
+	p := llm.Pricing{InputPerMTok: 3, OutputPerMTok: 15, CachedPerMTok: 0.3}
+	if got, want := p.Cost(resp.Usage), (200*3+800*0.3+10*15)/1e6; math.Abs(got-want) > 1e-12 {
+		t.Errorf("cost = %v, want %v", got, want)
+	}
+}
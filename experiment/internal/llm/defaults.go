@@ -0,0 +1,81 @@
+// internal/llm/defaults.go
+package llm
+
+import "context"
+
+// GenerationDefaults 는 모델별 기본 생성 파라미터입니다.
+// 요청에 값이 없을 때만 채워지며, 요청에 명시된 값이 항상 우선합니다.
+type GenerationDefaults struct {
+	System      string   `yaml:"system,omitempty"`
+	Temperature *float64 `yaml:"temperature,omitempty"`
+	TopP        *float64 `yaml:"top_p,omitempty"`
+	MaxTokens   int      `yaml:"max_tokens,omitempty"`
+	Stop        []string `yaml:"stop,omitempty"`
+	Seed        *int64   `yaml:"seed,omitempty"`
+}
+
+// IsZero 는 설정된 기본값이 하나도 없는지 알려줍니다.
+func (d GenerationDefaults) IsZero() bool {
+	return d.System == "" && d.Temperature == nil && d.TopP == nil &&
+		d.MaxTokens == 0 && len(d.Stop) == 0 && d.Seed == nil
+}
+
+// Apply 는 req 의 빈 항목을 기본값으로 채운 요청을 반환합니다.
+func (d GenerationDefaults) Apply(req GenerateRequest) GenerateRequest {
+	if req.System == "" {
+		req.System = d.System
+	}
+	if req.Temperature == nil {
+		req.Temperature = d.Temperature
+	}
+	if req.TopP == nil {
+		req.TopP = d.TopP
+	}
+	if req.MaxTokens == 0 {
+		req.MaxTokens = d.MaxTokens
+	}
+	if len(req.Stop) == 0 {
+		req.Stop = d.Stop
+	}
+	if req.Seed == nil {
+		req.Seed = d.Seed
+	}
+	return req
+}
+
+// WithDefaults 는 모든 호출에 defaults 를 적용하는 클라이언트로 감쌉니다.
+func WithDefaults(client LLMClient, defaults GenerationDefaults) LLMClient {
+	if defaults.IsZero() {
+		return client
+	}
+	return &defaultsClient{inner: client, defaults: defaults}
+}
+
+type defaultsClient struct {
+	inner    LLMClient
+	defaults GenerationDefaults
+}
+
+func (c *defaultsClient) Name() string { return c.inner.Name() }
+
+func (c *defaultsClient) Generate(ctx context.Context, prompt string) (string, error) {
+	resp, err := c.Complete(ctx, PromptRequest(prompt))
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+func (c *defaultsClient) Complete(ctx context.Context, req GenerateRequest) (*Response, error) {
+	return Complete(ctx, c.inner, c.defaults.Apply(req))
+}
+
+func (c *defaultsClient) Stream(ctx context.Context, req GenerateRequest) (<-chan StreamEvent, error) {
+	return streamOrComplete(ctx, c.inner, c.defaults.Apply(req))
+}
+
+func (c *defaultsClient) Unwrap() LLMClient { return c.inner }
+
+func (c *defaultsClient) rewrap(tag string, inner LLMClient) LLMClient {
+	return &defaultsClient{inner: inner, defaults: c.defaults}
+}
@@ -0,0 +1,107 @@
+// internal/llm/discovery.go
+package llm
+
+import (
+	"context"
+	"fmt"
+)
+
+// ModelInfo 는 서버가 제공하는 모델 한 개의 정보입니다.
+type ModelInfo struct {
+	ID      string `json:"id"`
+	OwnedBy string `json:"owned_by,omitempty"`
+	Size    int64  `json:"size,omitempty"` // 바이트 (Ollama 만 제공)
+}
+
+// ModelLister 는 서버가 제공하는 모델 목록을 조회할 수 있는 클라이언트가 구현합니다.
+type ModelLister interface {
+	ListModels(ctx context.Context) ([]ModelInfo, error)
+}
+
+// modelSwitcher 는 같은 서버 설정으로 모델만 바꾼 클라이언트를 만들 수 있는 클라이언트입니다.
+type modelSwitcher interface {
+	WithModel(model string) LLMClient
+}
+
+// Unwrapper 는 다른 클라이언트를 감싼 래퍼가 구현합니다 (기본값/카세트 등).
+type Unwrapper interface {
+	Unwrap() LLMClient
+}
+
+// rewrapper 는 같은 설정으로 다른 클라이언트를 감쌀 수 있는 래퍼입니다 (기본값/한도/미들웨어/캐시/카세트).
+// tag 는 새로 감싼 클라이언트가 등록될 레지스트리 태그입니다.
+type rewrapper interface {
+	Unwrapper
+	rewrap(tag string, inner LLMClient) LLMClient
+}
+
+// withModel 은 c 의 래퍼 체인을 그대로 다시 씌운 채 맨 안쪽 클라이언트만 model 로 바꿉니다.
+// 모델을 바꿀 수 없거나, 다시 씌울 수 없는 래퍼가 있으면 false 입니다.
+func withModel(c LLMClient, tag, model string) (LLMClient, bool) {
+	if sw, ok := c.(modelSwitcher); ok {
+		return sw.WithModel(model), true
+	}
+	rw, ok := c.(rewrapper)
+	if !ok {
+		return nil, false
+	}
+	inner, ok := withModel(rw.Unwrap(), tag, model)
+	if !ok {
+		return nil, false
+	}
+	return rw.rewrap(tag, inner), true
+}
+
+// findBase 는 래퍼를 벗겨 가며 T 를 구현하는 클라이언트를 찾습니다.
+func findBase[T any](c LLMClient) (T, bool) {
+	for c != nil {
+		if t, ok := c.(T); ok {
+			return t, true
+		}
+		u, ok := c.(Unwrapper)
+		if !ok {
+			break
+		}
+		c = u.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// Discover 는 tag 로 등록된 클라이언트의 서버가 제공하는 모델 목록을 조회합니다.
+func (r *ModelRegistry) Discover(ctx context.Context, tag string) ([]ModelInfo, error) {
+	c, ok := r.GetModel(tag)
+	if !ok {
+		return nil, fmt.Errorf("model not registered: %s", tag)
+	}
+	lister, ok := findBase[ModelLister](c)
+	if !ok {
+		return nil, fmt.Errorf("%s: model discovery not supported by %T", tag, c)
+	}
+	return lister.ListModels(ctx)
+}
+
+// RegisterDiscovered 는 tag 서버가 제공하는 모델을 모두 prefix+모델ID 태그로 등록하고 등록한 태그를 반환합니다.
+// 등록한 모델은 tag 와 같은 래퍼(기본값, 한도, 미들웨어, 캐시, 카세트)를 거칩니다. 한도(Limiter)는 tag 와 공유합니다.
+// 예: reg.RegisterDiscovered(ctx, "local", "local/") → "local/llama3.1:8b", "local/qwen2.5:7b" ...
+func (r *ModelRegistry) RegisterDiscovered(ctx context.Context, tag, prefix string) ([]string, error) {
+	models, err := r.Discover(ctx, tag)
+	if err != nil {
+		return nil, err
+	}
+	c, _ := r.GetModel(tag)
+	if _, ok := findBase[modelSwitcher](c); !ok {
+		return nil, fmt.Errorf("%s: %T cannot switch models", tag, c)
+	}
+	tags := make([]string, 0, len(models))
+	for _, m := range models {
+		t := prefix + m.ID
+		client, ok := withModel(c, t, m.ID)
+		if !ok {
+			return nil, fmt.Errorf("%s: %T cannot be rebuilt around another model", tag, c)
+		}
+		r.RegisterModel(t, client)
+		tags = append(tags, t)
+	}
+	return tags, nil
+}
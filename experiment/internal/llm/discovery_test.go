@@ -0,0 +1,100 @@
+// internal/llm/discovery_test.go
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"speckit-study/internal/llm"
+)
+
+// opaqueWrapper 는 Unwrap 만 구현하는 외부 래퍼입니다 (다시 씌우는 방법을 모름).
+type opaqueWrapper struct{ llm.LLMClient }
+
+func (w opaqueWrapper) Unwrap() llm.LLMClient { return w.LLMClient }
+
+// 발견한 모델도 원래 태그의 래퍼(기본값, 미들웨어)를 그대로 거쳐야 합니다.
+func TestRegisterDiscoveredKeepsWrappers(t *testing.T) {
+	var mu sync.Mutex
+	var sent []string // 서버가 받은 "모델 num_predict"
+	c := ollamaServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/tags" {
+			io.WriteString(w, `{"models":[{"name":"llama3.1:8b"},{"name":"qwen2.5:7b"}]}`)
+			return
+		}
+		var body struct {
+			Model   string `json:"model"`
+			Options struct {
+				NumPredict int `json:"num_predict"`
+			} `json:"options"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		mu.Lock()
+		sent = append(sent, body.Model+" "+strconv.Itoa(body.Options.NumPredict))
+		mu.Unlock()
+		io.WriteString(w, `{"message":{"content":"from `+body.Model+`"},"done":true}`)
+	})
+
+	reg := llm.NewModelRegistry()
+	reg.RegisterModel("local", llm.WithDefaults(c, llm.GenerationDefaults{MaxTokens: 77}))
+	var tags []string // 미들웨어가 본 레지스트리 태그
+	reg.Use(func(next llm.Handler) llm.Handler {
+		return func(ctx context.Context, req llm.GenerateRequest) (*llm.Response, error) {
+			mu.Lock()
+			tags = append(tags, llm.ClientTag(ctx))
+			mu.Unlock()
+			return next(ctx, req)
+		}
+	})
+
+	got, err := reg.RegisterDiscovered(context.Background(), "local", "local/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(got, ",") != "local/llama3.1:8b,local/qwen2.5:7b" {
+		t.Fatalf("registered %v", got)
+	}
+	client, ok := reg.GetModel("local/qwen2.5:7b")
+	if !ok {
+		t.Fatal("local/qwen2.5:7b not registered")
+	}
+	out, err := client.Generate(context.Background(), "p")
+	if err != nil || out != "from qwen2.5:7b" {
+		t.Fatalf("Generate: %q, %v", out, err)
+	}
+	if strings.Join(sent, ",") != "qwen2.5:7b 77" {
+		t.Errorf("server saw %v, want the discovered model with defaults.max_tokens", sent)
+	}
+	if strings.Join(tags, ",") != "local/qwen2.5:7b" {
+		t.Errorf("middleware saw tags %v, want the discovered tag", tags)
+	}
+	if base, _ := reg.GetModel("local"); base.Name() != "llama-test" {
+		t.Errorf("base tag now answers as %s", base.Name())
+	}
+}
+
+func TestRegisterDiscoveredUnsupported(t *testing.T) {
+	c := ollamaServer(t, func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"models":[{"name":"llama3.1:8b"}]}`)
+	})
+	reg := llm.NewModelRegistry()
+	reg.RegisterModel("stub", &stubClient{name: "stub", replies: []stubReply{{text: "x"}}})
+	reg.RegisterModel("opaque", opaqueWrapper{c})
+
+	if _, err := reg.RegisterDiscovered(context.Background(), "stub", "s/"); err == nil {
+		t.Error("stub: want a discovery error")
+	}
+	// 모델 목록은 찾아도, 다시 씌울 수 없는 래퍼가 있으면 래퍼 없이 등록하지 않고 오류를 돌려줍니다.
+	if _, err := reg.RegisterDiscovered(context.Background(), "opaque", "o/"); err == nil || !strings.Contains(err.Error(), "cannot be rebuilt") {
+		t.Errorf("opaque wrapper: got %v, want a rebuild error", err)
+	}
+	if _, ok := reg.GetModel("o/llama3.1:8b"); ok {
+		t.Error("registered a discovered model without its wrapper")
+	}
+}
@@ -0,0 +1,171 @@
+// internal/llm/embed.go
+package llm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"sync/atomic"
+)
+
+// DefaultEmbedBatchSize 는 임베딩 요청 하나에 담는 기본 텍스트 수입니다.
+const DefaultEmbedBatchSize = 100
+
+// Embedder 는 텍스트를 벡터로 바꾸는 임베딩 모델입니다. LLMClient 와 별개로 ModelRegistry 에 태그로 등록합니다.
+type Embedder interface {
+	Name() string
+	// Dimensions 는 벡터 차원입니다. 설정하지 않았으면 첫 응답 전까지 0 입니다.
+	Dimensions() int
+	// Embed 는 texts 와 같은 순서의 벡터를 반환합니다. 배치 크기를 넘으면 나눠서 요청합니다.
+	Embed(ctx context.Context, texts []string) (*Embeddings, error)
+}
+
+// Embeddings 는 Embed 의 결과입니다.
+type Embeddings struct {
+	Vectors    [][]float32
+	Model      string
+	Dimensions int
+	Usage      Usage // InputTokens 만 채워집니다 (공급자가 알려 줄 때)
+	Attempts   int   // 모든 배치의 HTTP 시도 수 합계
+}
+
+// embedDims 는 설정된 차원, 없으면 응답에서 관찰한 차원을 기억합니다.
+type embedDims struct {
+	configured int
+	observed   atomic.Int64
+}
+
+func (d *embedDims) get() int {
+	if d.configured > 0 {
+		return d.configured
+	}
+	return int(d.observed.Load())
+}
+
+// embedBatched 는 texts 를 size 개씩 나눠 call 로 보내고 결과를 이어 붙입니다.
+// 배치마다 벡터 수와 차원이 일치하는지 확인합니다.
+func embedBatched(ctx context.Context, provider string, texts []string, size int, dims *embedDims,
+	call func(ctx context.Context, batch []string) (*Embeddings, error)) (*Embeddings, error) {
+	if size <= 0 {
+		size = DefaultEmbedBatchSize
+	}
+	out := &Embeddings{Vectors: make([][]float32, 0, len(texts))}
+	for start := 0; start < len(texts); start += size {
+		batch := texts[start:min(start+size, len(texts))]
+		res, err := call(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		if len(res.Vectors) != len(batch) {
+			return nil, fmt.Errorf("%s: %w: got %d embeddings for %d inputs", provider, ErrEmptyResponse, len(res.Vectors), len(batch))
+		}
+		for _, v := range res.Vectors {
+			if out.Dimensions == 0 {
+				out.Dimensions = len(v)
+			}
+			if len(v) != out.Dimensions {
+				return nil, fmt.Errorf("%s: embedding dimensions differ (%d and %d)", provider, out.Dimensions, len(v))
+			}
+		}
+		out.Vectors = append(out.Vectors, res.Vectors...)
+		out.Model = res.Model
+		out.Usage.InputTokens += res.Usage.InputTokens
+		out.Attempts += res.Attempts
+	}
+	if out.Dimensions > 0 {
+		dims.observed.Store(int64(out.Dimensions))
+	}
+	return out, nil
+}
+
+// ---- 레지스트리 ----
+
+// RegisterEmbedder 는 임베딩 모델을 태그로 등록합니다. 태그는 LLM 모델 태그와 별개의 이름 공간입니다.
+func (r *ModelRegistry) RegisterEmbedder(tag string, e Embedder) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.embedders == nil {
+		r.embedders = map[string]Embedder{}
+	}
+	r.embedders[tag] = e
+}
+
+// GetEmbedder 는 태그로 임베딩 모델을 조회합니다.
+func (r *ModelRegistry) GetEmbedder(tag string) (Embedder, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	e, ok := r.embedders[tag]
+	return e, ok
+}
+
+// ListEmbedders 는 등록된 임베딩 모델 태그를 정렬해 반환합니다.
+func (r *ModelRegistry) ListEmbedders() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	tags := make([]string, 0, len(r.embedders))
+	for t := range r.embedders {
+		tags = append(tags, t)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// ---- 유사도 ----
+
+// Cosine 은 두 벡터의 코사인 유사도입니다 (-1..1). 길이가 다르거나 영벡터면 0 입니다.
+func Cosine(a, b []float32) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+		na += float64(a[i]) * float64(a[i])
+		nb += float64(b[i]) * float64(b[i])
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// Match 는 유사도 검색 결과 하나입니다.
+type Match struct {
+	Index int     // vectors 안의 위치
+	Score float64 // 코사인 유사도
+}
+
+// Nearest 는 query 와 가장 비슷한 벡터 k 개를 유사도 내림차순으로 반환합니다 (k <= 0 이면 전부).
+func Nearest(query []float32, vectors [][]float32, k int) []Match {
+	matches := make([]Match, len(vectors))
+	for i, v := range vectors {
+		matches[i] = Match{Index: i, Score: Cosine(query, v)}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	if k > 0 && k < len(matches) {
+		matches = matches[:k]
+	}
+	return matches
+}
+
+// Pair 는 서로 비슷한 두 벡터입니다 (A < B).
+type Pair struct {
+	A, B  int
+	Score float64
+}
+
+// SimilarPairs 는 유사도가 threshold 이상인 모든 쌍을 유사도 내림차순으로 반환합니다.
+// 여러 서비스 스펙에 거의 같은 요구사항이 있는지 찾는 데 씁니다.
+func SimilarPairs(vectors [][]float32, threshold float64) []Pair {
+	var pairs []Pair
+	for i := range vectors {
+		for j := i + 1; j < len(vectors); j++ {
+			if s := Cosine(vectors[i], vectors[j]); s >= threshold {
+				pairs = append(pairs, Pair{A: i, B: j, Score: s})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(i, j int) bool { return pairs[i].Score > pairs[j].Score })
+	return pairs
+}
@@ -0,0 +1,104 @@
+// internal/llm/embed_test.go
+package llm_test
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"testing"
+
+	"speckit-study/internal/fakellm"
+	"speckit-study/internal/llm"
+)
+
+// fakeEmbedders 는 srv 를 가리키는 공급자별 Embedder 입니다 (배치 크기 batch).
+func fakeEmbedders(t *testing.T, srv *fakellm.TestServer, batch, dims int) map[string]llm.Embedder {
+	t.Helper()
+	clients := fakeClients(t, srv)
+	oa := llm.NewOpenAIEmbedder("embed-test", dims)
+	oa.Client, oa.BatchSize = clients["openai"].(*llm.OpenAIClient), batch
+	ge := llm.NewGeminiEmbedder("embed-test", dims)
+	ge.Client, ge.BatchSize = clients["gemini"].(*llm.GeminiClient), batch
+	return map[string]llm.Embedder{"openai": oa, "gemini": ge}
+}
+
+func TestEmbedBatching(t *testing.T) {
+	texts := make([]string, 5)
+	for i := range texts {
+		texts[i] = fmt.Sprintf("requirement %d: retain audit logs for %d days", i, 30*(i+1))
+	}
+	for _, provider := range []string{"openai", "gemini"} {
+		t.Run(provider, func(t *testing.T) {
+			// 두 번째 배치가 한 번 실패해도 재시도로 채워집니다.
+			srv := fakellm.NewTestServer(fakellm.Script{
+				Rules: []fakellm.Rule{{Match: "requirement 2", Times: 1, Reply: fakellm.Reply{Status: 503}}},
+			})
+			defer srv.Close()
+			batched := fakeEmbedders(t, srv, 2, 0)[provider]
+
+			res, err := batched.Embed(context.Background(), texts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			calls := srv.Fake.Calls()
+			if len(calls) != 4 || res.Attempts != 4 {
+				t.Errorf("server saw %d calls, Attempts=%d, want 3 batches plus one retry", len(calls), res.Attempts)
+			}
+			if len(res.Vectors) != len(texts) || res.Dimensions != fakellm.DefaultEmbedDims || batched.Dimensions() != fakellm.DefaultEmbedDims {
+				t.Fatalf("got %d vectors of %d dims (Dimensions()=%d)", len(res.Vectors), res.Dimensions, batched.Dimensions())
+			}
+
+			// 배치로 나눠도 텍스트 하나씩 요청한 것과 같은 순서와 값입니다.
+			for i, text := range texts {
+				one, err := batched.Embed(context.Background(), []string{text})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if llm.Cosine(one.Vectors[0], res.Vectors[i]) < 0.9999 {
+					t.Errorf("vector %d does not match its text", i)
+				}
+			}
+			if provider == "openai" && res.Usage.InputTokens == 0 {
+				t.Error("usage was not summed across batches")
+			}
+		})
+	}
+}
+
+func TestEmbedDimensions(t *testing.T) {
+	srv := fakellm.NewTestServer(fakellm.DefaultScript())
+	defer srv.Close()
+	for provider, e := range fakeEmbedders(t, srv, 0, 8) {
+		if e.Dimensions() != 8 {
+			t.Errorf("%s: configured Dimensions() = %d before any call", provider, e.Dimensions())
+		}
+		res, err := e.Embed(context.Background(), []string{"a", "b"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.Dimensions != 8 || len(res.Vectors[1]) != 8 {
+			t.Errorf("%s: got %d dims", provider, res.Dimensions)
+		}
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	vectors := [][]float32{{1, 0}, {0, 1}, {1, 0.1}, {-1, 0}}
+	if got := llm.Cosine(vectors[0], vectors[3]); math.Abs(got+1) > 1e-9 {
+		t.Errorf("opposite vectors: %v", got)
+	}
+	if llm.Cosine([]float32{0, 0}, vectors[0]) != 0 || llm.Cosine(vectors[0], []float32{1}) != 0 {
+		t.Error("zero or mismatched vectors should score 0")
+	}
+	near := llm.Nearest([]float32{1, 0}, vectors, 2)
+	if len(near) != 2 || near[0].Index != 0 || near[1].Index != 2 {
+		t.Errorf("Nearest = %+v", near)
+	}
+	pairs := llm.SimilarPairs(vectors, 0.9)
+	if len(pairs) != 1 || pairs[0].A != 0 || pairs[0].B != 2 {
+		t.Errorf("SimilarPairs = %+v", pairs)
+	}
+	if got := llm.Nearest(nil, nil, 3); len(got) != 0 {
+		t.Errorf("Nearest on no vectors = %+v", got)
+	}
+}
@@ -0,0 +1,205 @@
+// internal/llm/errors.go
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrorKind 는 공급자 오류를 공통 분류로 정리한 값입니다.
+type ErrorKind string
+
+const (
+	ErrorKindAuth           ErrorKind = "auth"
+	ErrorKindRateLimit      ErrorKind = "rate_limit"
+	ErrorKindOverloaded     ErrorKind = "overloaded"
+	ErrorKindInvalidRequest ErrorKind = "invalid_request"
+	ErrorKindContextTooLong ErrorKind = "context_too_long"
+	ErrorKindServer         ErrorKind = "server"
+	ErrorKindUnknown        ErrorKind = "unknown"
+)
+
+// errors.Is 로 분류를 검사할 수 있는 센티널 오류입니다.
+// 예: errors.Is(err, llm.ErrRateLimit)
+var (
+	ErrAuth           = errors.New("llm: authentication failed")
+	ErrRateLimit      = errors.New("llm: rate limited")
+	ErrOverloaded     = errors.New("llm: provider overloaded")
+	ErrInvalidRequest = errors.New("llm: invalid request")
+	ErrContextTooLong = errors.New("llm: context too long")
+	ErrServer         = errors.New("llm: provider server error")
+)
+
+var kindSentinels = map[ErrorKind]error{
+	ErrorKindAuth:           ErrAuth,
+	ErrorKindRateLimit:      ErrRateLimit,
+	ErrorKindOverloaded:     ErrOverloaded,
+	ErrorKindInvalidRequest: ErrInvalidRequest,
+	ErrorKindContextTooLong: ErrContextTooLong,
+	ErrorKindServer:         ErrServer,
+}
+
+// ProviderError 는 공급자가 2xx 가 아닌 응답을 돌려줬을 때의 오류입니다.
+type ProviderError struct {
+	Provider   string        // "openai", "anthropic", "gemini"
+	StatusCode int           // HTTP 상태 코드
+	Kind       ErrorKind     // 공통 분류
+	Type       string        // 공급자 고유 오류 타입/코드 (예: "overloaded_error", "RESOURCE_EXHAUSTED")
+	Message    string        // 공급자 오류 메시지
+	RetryAfter time.Duration // Retry-After 헤더 값 (없으면 0)
+}
+
+func (e *ProviderError) Error() string {
+	msg := RedactSecrets(e.Message)
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if e.Type != "" {
+		return fmt.Sprintf("%s: %s (HTTP %d, %s): %s", e.Provider, e.Kind, e.StatusCode, e.Type, msg)
+	}
+	return fmt.Sprintf("%s: %s (HTTP %d): %s", e.Provider, e.Kind, e.StatusCode, msg)
+}
+
+// Is 는 errors.Is(err, ErrRateLimit) 같은 분류 검사를 지원합니다.
+func (e *ProviderError) Is(target error) bool {
+	s, ok := kindSentinels[e.Kind]
+	return ok && s == target
+}
+
+// Retryable 은 같은 요청을 다시 보내면 성공할 수 있는 오류인지 알려줍니다.
+// 속도 제한과, 서버 오류 중 일시적인 상태(retryableStatus)만 재시도합니다.
+func (e *ProviderError) Retryable() bool {
+	switch e.Kind {
+	case ErrorKindRateLimit:
+		return true
+	case ErrorKindOverloaded, ErrorKindServer:
+		return retryableStatus(e.Provider, e.StatusCode)
+	}
+	return false
+}
+
+// retryableStatus 는 잠시 뒤 다시 보내면 나아질 수 있는 5xx 인지 알려줍니다: 500, 502, 503, 504 와 Anthropic 의 529(overloaded).
+// 501, 505 같은 나머지 5xx 와 200 스트림 안의 오류는 다시 보내도 같은 결과이므로 재시도하지 않습니다.
+func retryableStatus(provider string, status int) bool {
+	switch status {
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case 529:
+		return provider == "anthropic"
+	}
+	return false
+}
+
+// IsRetryable 은 err 가 재시도 가능한 공급자 오류 또는 일시적인 네트워크 오류인지 판단합니다.
+// 네트워크 오류는 타임아웃과 연결 오류(*net.OpError, ECONNREFUSED, ECONNRESET)입니다. 취소(context.Canceled)는 재시도하지 않습니다.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var pe *ProviderError
+	if errors.As(err, &pe) {
+		return pe.Retryable()
+	}
+	var te interface{ Timeout() bool }
+	if errors.As(err, &te) && te.Timeout() {
+		return true
+	}
+	var oe *net.OpError
+	return errors.As(err, &oe) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET)
+}
+
+// parseProviderError 는 2xx 가 아닌 응답 본문을 ProviderError 로 변환합니다.
+// 세 공급자 모두 {"error": {...}} 형태를 쓰므로 필드를 합친 구조체 하나로 디코드합니다.
+func parseProviderError(provider string, resp *http.Response) *ProviderError {
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+
+	var decoded struct {
+		Error struct {
+			Message string          `json:"message"`
+			Type    string          `json:"type"`   // openai, anthropic
+			Code    json.RawMessage `json:"code"`   // openai: 문자열, gemini: 숫자
+			Status  string          `json:"status"` // gemini
+		} `json:"error"`
+	}
+	pe := &ProviderError{
+		Provider:   provider,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header),
+	}
+	if err := json.Unmarshal(b, &decoded); err == nil && decoded.Error.Message != "" {
+		pe.Message = decoded.Error.Message
+		pe.Type = decoded.Error.Type
+		if pe.Type == "" {
+			pe.Type = decoded.Error.Status
+		}
+		var code string
+		if json.Unmarshal(decoded.Error.Code, &code) == nil && code != "" {
+			pe.Type = code
+		}
+	} else {
+		pe.Message = strings.TrimSpace(string(b))
+		if len(pe.Message) > 512 {
+			pe.Message = pe.Message[:512] + "..."
+		}
+	}
+	pe.Kind = classifyError(pe)
+	return pe
+}
+
+func classifyError(pe *ProviderError) ErrorKind {
+	t := strings.ToLower(pe.Type)
+	msg := strings.ToLower(pe.Message)
+
+	switch {
+	case t == "context_length_exceeded",
+		strings.Contains(msg, "prompt is too long"),
+		strings.Contains(msg, "maximum context length"),
+		strings.Contains(msg, "exceeds the maximum number of tokens"):
+		return ErrorKindContextTooLong
+	case pe.StatusCode == http.StatusUnauthorized, pe.StatusCode == http.StatusForbidden,
+		t == "authentication_error", t == "permission_error", t == "unauthenticated", t == "permission_denied":
+		return ErrorKindAuth
+	case pe.StatusCode == http.StatusTooManyRequests,
+		t == "rate_limit_error", t == "rate_limit_exceeded", t == "resource_exhausted":
+		return ErrorKindRateLimit
+	case pe.StatusCode == 529, pe.StatusCode == http.StatusServiceUnavailable,
+		t == "overloaded_error", t == "unavailable":
+		return ErrorKindOverloaded
+	case pe.StatusCode >= 500:
+		return ErrorKindServer
+	case pe.StatusCode >= 400:
+		return ErrorKindInvalidRequest
+	}
+	return ErrorKindUnknown
+}
+
+// parseRetryAfter 는 retry-after-ms / Retry-After(초 또는 HTTP-date) 헤더를 해석합니다.
+func parseRetryAfter(h http.Header) time.Duration {
+	if v := h.Get("retry-after-ms"); v != "" {
+		if ms, err := strconv.ParseFloat(v, 64); err == nil && ms > 0 {
+			return time.Duration(ms * float64(time.Millisecond))
+		}
+	}
+	v := h.Get("Retry-After")
+	if v == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
@@ -0,0 +1,186 @@
+// internal/llm/fallback.go
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// FallbackClient 는 레지스트리 태그 체인을 순서대로 시도하는 복합 클라이언트입니다.
+// 재시도 가능한 오류, 타임아웃, 빈 출력이면 다음 태그로 넘어가고,
+// 그 외 오류(잘못된 요청 등)는 다른 모델에서도 같을 것이므로 바로 반환합니다.
+type FallbackClient struct {
+	Tags []string
+	// PerModelTimeout 이 0 보다 크면 태그마다 이 시간 안에 끝나지 않을 때 다음 태그로 넘어갑니다.
+	PerModelTimeout time.Duration
+
+	reg *ModelRegistry
+}
+
+// NewFallbackClient 는 reg 에 등록된 tags 를 순서대로 시도하는 클라이언트를 만듭니다.
+// 예: NewFallbackClient(reg, "claude", "gpt", "gemini")
+func NewFallbackClient(reg *ModelRegistry, tags ...string) *FallbackClient {
+	return &FallbackClient{Tags: tags, reg: reg}
+}
+
+func (f *FallbackClient) Name() string { return "fallback(" + strings.Join(f.Tags, "→") + ")" }
+
+func (f *FallbackClient) Generate(ctx context.Context, prompt string) (string, error) {
+	resp, err := f.Complete(ctx, PromptRequest(prompt))
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// Complete 는 응답한 첫 태그의 결과를 반환합니다. Response.Tag 에 실제 응답한 태그가 기록됩니다.
+func (f *FallbackClient) Complete(ctx context.Context, req GenerateRequest) (*Response, error) {
+	var skipped []string
+	var errs []error
+	for _, tag := range f.Tags {
+		client, ok := f.reg.GetModel(tag)
+		if !ok {
+			skipped = append(skipped, tag+": not registered")
+			errs = append(errs, fmt.Errorf("%s: not registered", tag))
+			continue
+		}
+
+		callCtx, cancel := f.callContext(ctx)
+		resp, err := Complete(callCtx, client, req)
+		cancel()
+		if err == nil && strings.TrimSpace(resp.Text) == "" && len(resp.ToolCalls) == 0 {
+			err = errEmptyResponse(tag, "blank output")
+		}
+		if err == nil {
+			resp.Tag = f.reg.Resolve(tag)
+			resp.FallbackErrors = append(skipped, resp.FallbackErrors...)
+			return resp, nil
+		}
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !shouldFallback(err) {
+			return nil, fmt.Errorf("%s: %w", tag, err)
+		}
+		skipped = append(skipped, tag+": "+err.Error())
+		errs = append(errs, fmt.Errorf("%s: %w", tag, err))
+	}
+	return nil, &FallbackError{Errs: errs}
+}
+
+// Stream 은 첫 출력(공백이 아닌 텍스트나 도구 호출)을 내보낸 첫 태그로 스트리밍합니다.
+// Complete 와 같이 태그마다 PerModelTimeout 을 적용하고, 출력 전에 끝난 빈 스트림이나 오류·타임아웃이면 다음 태그로 넘어갑니다.
+// 이미 토큰을 내보낸 뒤의 오류는 다음 태그로 넘길 수 없으므로 그대로 전달됩니다.
+func (f *FallbackClient) Stream(ctx context.Context, req GenerateRequest) (<-chan StreamEvent, error) {
+	var errs []error
+	for _, tag := range f.Tags {
+		client, ok := f.reg.GetModel(tag)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: not registered", tag))
+			continue
+		}
+
+		callCtx, cancel := f.callContext(ctx)
+		head, events, err := firstOutput(callCtx, client, req, tag)
+		if err == nil {
+			return tagStream(ctx, head, events, f.reg.Resolve(tag), client.Name(), cancel), nil
+		}
+		cancel()
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if !shouldFallback(err) {
+			return nil, fmt.Errorf("%s: %w", tag, err)
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", tag, err))
+	}
+	return nil, &FallbackError{Errs: errs}
+}
+
+// firstOutput 은 스트림을 시작해 첫 출력이 올 때까지의 이벤트를 모아 둡니다 (head).
+// 출력 없이 끝나거나 그 전에 오류가 나면 다음 태그로 넘길 수 있도록 오류를 반환합니다.
+// 오류를 반환하면 호출자가 ctx 를 취소해 남은 스트림을 정리해야 합니다.
+func firstOutput(ctx context.Context, client LLMClient, req GenerateRequest, tag string) ([]StreamEvent, <-chan StreamEvent, error) {
+	events, err := streamOrComplete(ctx, client, req)
+	if err != nil {
+		return nil, nil, err
+	}
+	var head []StreamEvent
+	var text strings.Builder
+	for ev := range events {
+		if ev.Err != nil {
+			return nil, nil, ev.Err
+		}
+		head = append(head, ev)
+		text.WriteString(ev.Delta)
+		if strings.TrimSpace(text.String()) != "" || len(ev.ToolCalls) > 0 {
+			return head, events, nil
+		}
+		if ev.Done {
+			return nil, nil, errEmptyResponse(tag, "blank output")
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err // PerModelTimeout 이면 context.DeadlineExceeded
+	}
+	return nil, nil, errEmptyResponse(tag, "stream ended without output")
+}
+
+func (f *FallbackClient) callContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if f.PerModelTimeout > 0 {
+		return context.WithTimeout(ctx, f.PerModelTimeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// shouldFallback 은 다른 모델로 넘어가야 하는 오류인지 판단합니다.
+func shouldFallback(err error) bool {
+	return IsRetryable(err) ||
+		errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, ErrEmptyResponse)
+}
+
+// tagStream 은 먼저 받아 둔 head 이벤트와 나머지 스트림을 이어 보내며, Done 이벤트에 응답한 태그/모델을 채워 넣습니다.
+// 스트림이 끝나면 태그별 호출 컨텍스트를 닫는 cancel 을 부릅니다.
+func tagStream(ctx context.Context, head []StreamEvent, in <-chan StreamEvent, tag, model string, cancel context.CancelFunc) <-chan StreamEvent {
+	out := make(chan StreamEvent)
+	go func() {
+		defer close(out)
+		defer cancel()
+		send := func(ev StreamEvent) bool {
+			if ev.Done {
+				ev.Tag, ev.Model = tag, model
+			}
+			return emit(ctx, out, ev)
+		}
+		for _, ev := range head {
+			if !send(ev) {
+				return
+			}
+		}
+		for ev := range in {
+			if !send(ev) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// FallbackError 는 체인의 모든 태그가 실패했을 때의 오류입니다.
+type FallbackError struct {
+	Errs []error
+}
+
+func (e *FallbackError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return "all fallback models failed: " + strings.Join(msgs, "; ")
+}
+
+func (e *FallbackError) Unwrap() []error { return e.Errs }
@@ -0,0 +1,117 @@
+// internal/llm/fallback_test.go
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"speckit-study/internal/llm"
+)
+
+// streamStub 은 delay 뒤에 deltas 를 차례로 흘려보내는 Streamer 입니다.
+type streamStub struct {
+	name   string
+	delay  time.Duration
+	deltas []string
+}
+
+func (s *streamStub) Name() string { return s.name }
+
+func (s *streamStub) Generate(ctx context.Context, prompt string) (string, error) {
+	return "", errors.New("streamStub: use Stream")
+}
+
+func (s *streamStub) Stream(ctx context.Context, req llm.GenerateRequest) (<-chan llm.StreamEvent, error) {
+	ch := make(chan llm.StreamEvent)
+	go func() {
+		defer close(ch)
+		select {
+		case <-time.After(s.delay):
+		case <-ctx.Done():
+			return
+		}
+		for _, d := range s.deltas {
+			select {
+			case ch <- llm.StreamEvent{Delta: d}:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case ch <- llm.StreamEvent{Done: true, Usage: &llm.Usage{OutputTokens: len(s.deltas)}}:
+		case <-ctx.Done():
+		}
+	}()
+	return ch, nil
+}
+
+func TestFallbackStream(t *testing.T) {
+	reg := llm.NewModelRegistry()
+	reg.RegisterModel("slow", &streamStub{name: "slow-model", delay: time.Second, deltas: []string{"late"}})
+	reg.RegisterModel("empty", &streamStub{name: "empty-model", deltas: []string{"", "  \n"}})
+	reg.RegisterModel("good", &streamStub{name: "good-model", deltas: []string{"## Steps", "\n1. run"}})
+
+	f := llm.NewFallbackClient(reg, "slow", "empty", "good")
+	f.PerModelTimeout = 50 * time.Millisecond
+	var sb strings.Builder
+	start := time.Now()
+	resp, err := llm.StreamTo(context.Background(), f, llm.PromptRequest("p"), &sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Errorf("PerModelTimeout was not applied to the stream (took %s)", time.Since(start))
+	}
+	if resp.Tag != "good" || resp.Model != "good-model" {
+		t.Errorf("answered by %s/%s, want good/good-model", resp.Tag, resp.Model)
+	}
+	if got := sb.String(); got != "## Steps\n1. run" {
+		t.Errorf("streamed %q", got)
+	}
+
+	// 모든 태그가 출력 없이 끝나면 FallbackError 입니다.
+	f = llm.NewFallbackClient(reg, "empty")
+	_, err = llm.StreamTo(context.Background(), f, llm.PromptRequest("p"), &sb)
+	var fe *llm.FallbackError
+	if !errors.As(err, &fe) || !errors.Is(err, llm.ErrEmptyResponse) {
+		t.Fatalf("got %v, want a FallbackError wrapping ErrEmptyResponse", err)
+	}
+}
+
+// 아무도 듣지 않는 주소(connection refused)는 재시도 가능한 전송 오류이므로 다음 태그로 넘어갑니다.
+func TestFallbackConnectionRefused(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+
+	down := llm.NewOpenAICompatibleClient("http://"+addr+"/v1", "local-model")
+	down.Retry = llm.NoRetry
+	reg := llm.NewModelRegistry()
+	reg.RegisterModel("local", down)
+	reg.RegisterModel("cloud", &stubClient{name: "cloud-model", replies: []stubReply{{text: "## Steps"}}})
+	f := llm.NewFallbackClient(reg, "local", "cloud")
+
+	resp, err := llm.Complete(context.Background(), f, llm.PromptRequest("p"))
+	if err != nil {
+		t.Fatalf("Complete: %v", err)
+	}
+	if resp.Tag != "cloud" || len(resp.FallbackErrors) != 1 || !strings.HasPrefix(resp.FallbackErrors[0], "local: ") {
+		t.Errorf("answered by %q after %q, want cloud after local", resp.Tag, resp.FallbackErrors)
+	}
+
+	var sb strings.Builder
+	resp, err = llm.StreamTo(context.Background(), f, llm.PromptRequest("p"), &sb)
+	if err != nil {
+		t.Fatalf("Stream: %v", err)
+	}
+	if resp.Tag != "cloud" || sb.String() != "## Steps" {
+		t.Errorf("streamed %q from %q, want the cloud reply", sb.String(), resp.Tag)
+	}
+}
@@ -5,77 +5,323 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"net/http"
-	"os"
+	"strings"
 	"time"
 )
 
+// GeminiBaseURL 은 Gemini API 의 기본 주소입니다. GEMINI_BASE_URL 환경 변수로 바꿀 수 있습니다.
+const GeminiBaseURL = "https://generativelanguage.googleapis.com/v1beta"
+
 type GeminiClient struct {
-	Model  string
-	apiKey string
-	httpc  *http.Client
+	Model   string
+	BaseURL string // 예: "https://generativelanguage.googleapis.com/v1beta", 테스트 시 fakellm 주소
+	Retry   RetryPolicy
+	apiKey  *secretRef
+	httpc   *http.Client
 }
 
 func NewGeminiClient(model string) *GeminiClient {
 	return &GeminiClient{
-		Model:  model, // 예: "gemini-2.5-pro" 또는 "gemini-2.5-flash"
-		apiKey: os.Getenv("GEMINI_API_KEY"),
-		httpc:  &http.Client{Timeout: 30 * time.Second},
+		Model:   model, // 예: "gemini-2.5-pro" 또는 "gemini-2.5-flash"
+		BaseURL: envOr("GEMINI_BASE_URL", GeminiBaseURL),
+		Retry:   DefaultRetryPolicy,
+		apiKey:  secretNamed("GEMINI_API_KEY"),
+		httpc:   &http.Client{Timeout: 30 * time.Second},
 	}
 }
 
 func (c *GeminiClient) Name() string { return c.Model }
 
 func (c *GeminiClient) Generate(ctx context.Context, prompt string) (string, error) {
-	// Gemini API는 API 키를 요청 헤더나 쿼리 파라미터로 전달하는 방식을 지원합니다. :contentReference[oaicite:16]{index=16}
+	resp, err := c.Complete(ctx, PromptRequest(prompt))
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// requestBody 는 GenerateRequest 를 generateContent 요청 본문으로 변환합니다.
+// Gemini 는 assistant 역할을 "model" 로 부르고, 시스템 프롬프트는 systemInstruction 으로 받습니다.
+func (c *GeminiClient) requestBody(r GenerateRequest) map[string]interface{} {
+	contents := geminiContents(r.ChatMessages())
+
+	genConfig := map[string]interface{}{
+		"maxOutputTokens": r.maxTokens(),
+	}
+	if r.Temperature != nil {
+		genConfig["temperature"] = *r.Temperature
+	}
+	if r.TopP != nil {
+		genConfig["topP"] = *r.TopP
+	}
+	if len(r.Stop) > 0 {
+		genConfig["stopSequences"] = r.Stop
+	}
+	if r.Seed != nil {
+		genConfig["seed"] = *r.Seed
+	}
+	if f := r.ResponseFormat; f != nil {
+		genConfig["responseMimeType"] = "application/json"
+		genConfig["responseJsonSchema"] = f.Schema
+	}
+
 	reqBody := map[string]interface{}{
-		"contents": []map[string]interface{}{
-			{
-				"role": "user",
-				"parts": []map[string]string{
-					{"text": prompt},
+		"contents":         contents,
+		"generationConfig": genConfig,
+	}
+	if sys := r.SystemText(); sys != "" {
+		reqBody["systemInstruction"] = map[string]interface{}{
+			"parts": []map[string]string{{"text": sys}},
+		}
+	}
+	if len(r.Tools) > 0 {
+		decls := make([]map[string]interface{}, len(r.Tools))
+		for i, t := range r.Tools {
+			decls[i] = map[string]interface{}{
+				"name":                 t.Name,
+				"description":          t.Description,
+				"parametersJsonSchema": t.toolParameters(),
+			}
+		}
+		reqBody["tools"] = []map[string]interface{}{{"functionDeclarations": decls}}
+		switch r.ToolChoice {
+		case "":
+		case "auto", "none":
+			reqBody["toolConfig"] = map[string]interface{}{
+				"functionCallingConfig": map[string]interface{}{"mode": strings.ToUpper(r.ToolChoice)},
+			}
+		case "required":
+			reqBody["toolConfig"] = map[string]interface{}{
+				"functionCallingConfig": map[string]interface{}{"mode": "ANY"},
+			}
+		default:
+			reqBody["toolConfig"] = map[string]interface{}{
+				"functionCallingConfig": map[string]interface{}{
+					"mode":                 "ANY",
+					"allowedFunctionNames": []string{r.ToolChoice},
 				},
-			},
-		},
+			}
+		}
 	}
+	return reqBody
+}
 
-	b, _ := json.Marshal(reqBody)
-	url := fmt.Sprintf(
-		"https://generativelanguage.googleapis.com/v1beta/models/%s:generateContent?key=%s",
-		c.Model,
-		c.apiKey,
-	)
+// geminiContents 는 대화 메시지를 contents 로 바꿉니다.
+// 도구 호출은 model 의 functionCall 파트, 도구 결과는 user 의 functionResponse 파트가 되며
+// 연속된 도구 결과는 하나의 user 콘텐츠로 합칩니다.
+func geminiContents(chat []Message) []map[string]interface{} {
+	contents := make([]map[string]interface{}, 0, len(chat))
+	lastTool := false
+	for _, m := range chat {
+		if m.Role == RoleTool {
+			key := "result"
+			if m.ToolError {
+				key = "error"
+			}
+			part := map[string]interface{}{
+				"functionResponse": map[string]interface{}{
+					"id":       m.ToolCallID,
+					"name":     m.ToolName,
+					"response": map[string]string{key: m.Content},
+				},
+			}
+			if n := len(contents); lastTool && n > 0 {
+				contents[n-1]["parts"] = append(contents[n-1]["parts"].([]map[string]interface{}), part)
+			} else {
+				contents = append(contents, map[string]interface{}{
+					"role":  "user",
+					"parts": []map[string]interface{}{part},
+				})
+			}
+			lastTool = true
+			continue
+		}
+		lastTool = false
+
+		role := "user"
+		if m.Role == RoleAssistant {
+			role = "model"
+		}
+		var parts []map[string]interface{}
+		if m.Content != "" || len(m.ToolCalls) == 0 {
+			parts = append(parts, map[string]interface{}{"text": m.Content})
+		}
+		for _, tc := range m.ToolCalls {
+			parts = append(parts, map[string]interface{}{
+				"functionCall": map[string]interface{}{
+					"id":   tc.ID,
+					"name": tc.Name,
+					"args": tc.args(),
+				},
+			})
+		}
+		contents = append(contents, map[string]interface{}{
+			"role":  role,
+			"parts": parts,
+		})
+	}
+	return contents
+}
 
+// geminiPart 는 응답 content 의 파트입니다.
+type geminiPart struct {
+	Text         string `json:"text"`
+	FunctionCall *struct {
+		ID   string          `json:"id"`
+		Name string          `json:"name"`
+		Args json.RawMessage `json:"args"`
+	} `json:"functionCall"`
+}
+
+// geminiParts 는 파트들의 텍스트와 도구 호출을 모읍니다.
+// Gemini 는 호출 ID 를 주지 않을 수 있어 그 경우 순번으로 만들어 둡니다 (seen 은 지금까지 받은 호출 수).
+func geminiParts(parts []geminiPart, seen int) (string, []ToolCall) {
+	var sb strings.Builder
+	var calls []ToolCall
+	for _, p := range parts {
+		sb.WriteString(p.Text)
+		if fc := p.FunctionCall; fc != nil {
+			id := fc.ID
+			if id == "" {
+				id = fmt.Sprintf("call_%d", seen+len(calls)+1)
+			}
+			calls = append(calls, ToolCall{ID: id, Name: fc.Name, Arguments: fc.Args})
+		}
+	}
+	return sb.String(), calls
+}
+
+func (c *GeminiClient) newRequest(ctx context.Context, url string, reqBody map[string]interface{}) (*http.Request, error) {
+	b, _ := json.Marshal(reqBody)
 	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(b))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpc.Do(req)
+	// 키는 URL 쿼리(?key=) 대신 헤더로 보냅니다. 쿼리에 두면 오류 메시지와 프록시 로그에 남습니다.
+	key, err := c.apiKey.get(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
-	defer resp.Body.Close()
+	if key != "" {
+		req.Header.Set("x-goog-api-key", key)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
+
+// Complete 는 GenerateRequest 를 generateContent 엔드포인트로 보냅니다.
+func (c *GeminiClient) Complete(ctx context.Context, r GenerateRequest) (*Response, error) {
+	url := joinURL(c.BaseURL, "models", c.Model+":generateContent")
+
+	reqBody := c.requestBody(r)
 
 	var decoded struct {
 		Candidates []struct {
 			Content struct {
-				Parts []struct {
-					Text string `json:"text"`
-				} `json:"parts"`
+				Parts []geminiPart `json:"parts"`
 			} `json:"content"`
+			FinishReason string `json:"finishReason"`
 		} `json:"candidates"`
+		UsageMetadata struct {
+			PromptTokenCount        int `json:"promptTokenCount"`
+			CandidatesTokenCount    int `json:"candidatesTokenCount"`
+			CachedContentTokenCount int `json:"cachedContentTokenCount"`
+		} `json:"usageMetadata"`
+	}
+	stats, err := doJSON(ctx, c.httpc, c.Retry, "gemini", func() (*http.Request, error) {
+		return c.newRequest(ctx, url, reqBody)
+	}, &decoded)
+	if err != nil {
+		return nil, err
 	}
-	json.NewDecoder(resp.Body).Decode(&decoded)
 
 	if len(decoded.Candidates) == 0 {
-		return "", errors.New("no candidates found")
+		return nil, errEmptyResponse("gemini", "no candidates found")
 	}
 	if len(decoded.Candidates[0].Content.Parts) == 0 {
-		return "", errors.New("no content parts found")
+		return nil, errEmptyResponse("gemini", "no content parts found")
+	}
+	text, calls := geminiParts(decoded.Candidates[0].Content.Parts, 0)
+	return &Response{
+		Text:         text,
+		Model:        c.Model,
+		FinishReason: decoded.Candidates[0].FinishReason,
+		Usage: Usage{
+			InputTokens:  decoded.UsageMetadata.PromptTokenCount,
+			OutputTokens: decoded.UsageMetadata.CandidatesTokenCount,
+			CachedTokens: decoded.UsageMetadata.CachedContentTokenCount,
+		},
+		Attempts:      stats.Attempts,
+		RetriedErrors: stats.Retried,
+		ToolCalls:     calls,
+	}, nil
+}
+
+// Stream 은 streamGenerateContent?alt=sse 엔드포인트로 토큰을 전달합니다.
+// 각 청크는 GenerateContentResponse 이며 usageMetadata 는 마지막 청크 값을 사용합니다.
+func (c *GeminiClient) Stream(ctx context.Context, r GenerateRequest) (<-chan StreamEvent, error) {
+	url := joinURL(c.BaseURL, "models", c.Model+":streamGenerateContent") + "?alt=sse"
+
+	reqBody := c.requestBody(r)
+	resp, stats, err := doWithRetry(ctx, streamingHTTPClient(c.httpc), c.Retry, "gemini", func() (*http.Request, error) {
+		return c.newRequest(ctx, url, reqBody)
+	})
+	if err != nil {
+		return nil, err
 	}
-	return decoded.Candidates[0].Content.Parts[0].Text, nil
+
+	ch := make(chan StreamEvent)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+
+		var usage *Usage
+		var streamErr error
+		var calls []ToolCall
+		err := readSSE(resp.Body, func(ev sseEvent) bool {
+			var chunk struct {
+				Candidates []struct {
+					Content struct {
+						Parts []geminiPart `json:"parts"`
+					} `json:"content"`
+				} `json:"candidates"`
+				UsageMetadata *struct {
+					PromptTokenCount        int `json:"promptTokenCount"`
+					CandidatesTokenCount    int `json:"candidatesTokenCount"`
+					CachedContentTokenCount int `json:"cachedContentTokenCount"`
+				} `json:"usageMetadata"`
+			}
+			if err := json.Unmarshal([]byte(ev.Data), &chunk); err != nil {
+				streamErr = err
+				return false
+			}
+			if chunk.UsageMetadata != nil {
+				usage = &Usage{
+					InputTokens:  chunk.UsageMetadata.PromptTokenCount,
+					OutputTokens: chunk.UsageMetadata.CandidatesTokenCount,
+					CachedTokens: chunk.UsageMetadata.CachedContentTokenCount,
+				}
+			}
+			if len(chunk.Candidates) == 0 {
+				return true
+			}
+			text, more := geminiParts(chunk.Candidates[0].Content.Parts, len(calls))
+			calls = append(calls, more...)
+			if text != "" {
+				return emit(ctx, ch, StreamEvent{Delta: text})
+			}
+			return true
+		})
+		if err == nil {
+			err = streamErr
+		}
+		if err != nil {
+			emit(ctx, ch, StreamEvent{Err: err})
+			return
+		}
+		emit(ctx, ch, StreamEvent{Done: true, Usage: usage, ToolCalls: calls, Attempts: stats.Attempts, RetriedErrors: stats.Retried})
+	}()
+	return ch, nil
 }
@@ -0,0 +1,69 @@
+// internal/llm/gemini_embedder.go
+package llm
+
+import (
+	"context"
+	"net/http"
+)
+
+// GeminiEmbedder 는 batchEmbedContents API 용 Embedder 입니다. 주소, 인증, 재시도는 Client 설정을 씁니다.
+type GeminiEmbedder struct {
+	Client    *GeminiClient
+	BatchSize int // 요청 하나에 담는 텍스트 수 (0 이면 DefaultEmbedBatchSize, API 상한 100)
+	// TaskType 은 임베딩 용도 힌트입니다 (예: "SEMANTIC_SIMILARITY", "RETRIEVAL_DOCUMENT"). 비우면 보내지 않습니다.
+	TaskType string
+
+	dims embedDims
+}
+
+// NewGeminiEmbedder 는 Gemini 임베딩 모델용 Embedder 를 만듭니다.
+// dimensions 가 0 보다 크면 outputDimensionality 로 보냅니다.
+// 예: NewGeminiEmbedder("gemini-embedding-001", 768)
+func NewGeminiEmbedder(model string, dimensions int) *GeminiEmbedder {
+	return &GeminiEmbedder{Client: NewGeminiClient(model), dims: embedDims{configured: dimensions}}
+}
+
+func (e *GeminiEmbedder) Name() string    { return e.Client.Model }
+func (e *GeminiEmbedder) Dimensions() int { return e.dims.get() }
+
+// Embed 는 texts 를 BatchSize 개씩 batchEmbedContents 로 보냅니다. Gemini 는 토큰 사용량을 알려 주지 않습니다.
+func (e *GeminiEmbedder) Embed(ctx context.Context, texts []string) (*Embeddings, error) {
+	return embedBatched(ctx, "gemini", texts, e.BatchSize, &e.dims, e.embedBatch)
+}
+
+func (e *GeminiEmbedder) embedBatch(ctx context.Context, batch []string) (*Embeddings, error) {
+	c := e.Client
+	requests := make([]map[string]interface{}, len(batch))
+	for i, text := range batch {
+		r := map[string]interface{}{
+			"model":   "models/" + c.Model,
+			"content": map[string]interface{}{"parts": []map[string]string{{"text": text}}},
+		}
+		if e.dims.configured > 0 {
+			r["outputDimensionality"] = e.dims.configured
+		}
+		if e.TaskType != "" {
+			r["taskType"] = e.TaskType
+		}
+		requests[i] = r
+	}
+	url := joinURL(c.BaseURL, "models", c.Model+":batchEmbedContents")
+	reqBody := map[string]interface{}{"requests": requests}
+
+	var decoded struct {
+		Embeddings []struct {
+			Values []float32 `json:"values"`
+		} `json:"embeddings"`
+	}
+	stats, err := doJSON(ctx, c.httpc, c.Retry, "gemini", func() (*http.Request, error) {
+		return c.newRequest(ctx, url, reqBody)
+	}, &decoded)
+	if err != nil {
+		return nil, err
+	}
+	vectors := make([][]float32, len(decoded.Embeddings))
+	for i, emb := range decoded.Embeddings {
+		vectors[i] = emb.Values
+	}
+	return &Embeddings{Vectors: vectors, Model: c.Model, Attempts: stats.Attempts}, nil
+}
@@ -0,0 +1,260 @@
+// internal/llm/limit.go
+package llm
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Limits 는 태그(또는 공급자) 하나에 대한 호출 한도입니다. 0 인 항목은 제한하지 않습니다.
+//
+//	limits: {max_in_flight: 4, rpm: 500, tpm: 200000}
+type Limits struct {
+	MaxInFlight int     `yaml:"max_in_flight" json:"max_in_flight,omitempty"` // 동시에 진행 중인 요청 수
+	RPM         float64 `yaml:"rpm" json:"rpm,omitempty"`                     // 분당 요청 수
+	TPM         float64 `yaml:"tpm" json:"tpm,omitempty"`                     // 분당 토큰 수 (입력 + 최대 출력 추정치)
+}
+
+// IsZero 는 아무 제한도 없는지 확인합니다.
+func (l Limits) IsZero() bool { return l.MaxInFlight <= 0 && l.RPM <= 0 && l.TPM <= 0 }
+
+// Limiter 는 Limits 를 지키도록 호출을 대기시킵니다. 여러 클라이언트가 하나를 공유할 수 있습니다.
+// 대기는 도착 순서(FIFO)대로 처리되어 큰 요청 뒤의 작은 요청이 앞지르지 않으며, ctx 가 취소되면 즉시 빠집니다.
+type Limiter struct {
+	Limits Limits
+
+	head     chan struct{} // 대기열 맨 앞 자리 (채널 대기는 FIFO)
+	inFlight chan struct{}
+	requests *bucket
+	tokens   *bucket
+}
+
+// NewLimiter 는 l 을 지키는 Limiter 를 만듭니다.
+// 버킷은 가득 찬 상태로 시작하므로 1분 한도만큼은 바로 보낼 수 있습니다.
+func NewLimiter(l Limits) *Limiter {
+	lim := &Limiter{Limits: l, head: make(chan struct{}, 1)}
+	if l.MaxInFlight > 0 {
+		lim.inFlight = make(chan struct{}, l.MaxInFlight)
+	}
+	if l.RPM > 0 {
+		lim.requests = newBucket(l.RPM)
+	}
+	if l.TPM > 0 {
+		lim.tokens = newBucket(l.TPM)
+	}
+	return lim
+}
+
+// Acquire 는 tokens 만큼의 예상 사용량으로 호출 자리를 얻을 때까지 기다립니다.
+// 반환된 release 는 호출이 끝나면 실제 사용 토큰 수로 반드시 한 번 호출해야 합니다
+// (추정보다 적게 썼으면 차이를 돌려받습니다). wait 는 대기한 시간입니다.
+func (l *Limiter) Acquire(ctx context.Context, tokens int) (release func(used int), wait time.Duration, err error) {
+	start := time.Now()
+	select {
+	case l.head <- struct{}{}:
+	case <-ctx.Done():
+		return nil, time.Since(start), ctx.Err()
+	}
+	defer func() { <-l.head }()
+
+	if l.inFlight != nil {
+		select {
+		case l.inFlight <- struct{}{}:
+		case <-ctx.Done():
+			return nil, time.Since(start), ctx.Err()
+		}
+	}
+	fail := func(err error) (func(int), time.Duration, error) {
+		if l.inFlight != nil {
+			<-l.inFlight
+		}
+		return nil, time.Since(start), err
+	}
+	if err := l.requests.take(ctx, 1); err != nil {
+		return fail(err)
+	}
+	taken := l.tokens.clamp(float64(tokens))
+	if err := l.tokens.take(ctx, taken); err != nil {
+		l.requests.refund(1)
+		return fail(err)
+	}
+
+	var once sync.Once
+	release = func(used int) {
+		once.Do(func() {
+			if used >= 0 && float64(used) < taken {
+				l.tokens.refund(taken - float64(used))
+			}
+			if l.inFlight != nil {
+				<-l.inFlight
+			}
+		})
+	}
+	return release, time.Since(start), nil
+}
+
+// bucket 은 분당 rate 개가 채워지는 토큰 버킷입니다 (용량 = rate). nil 이면 제한 없음.
+type bucket struct {
+	mu       sync.Mutex
+	capacity float64
+	perSec   float64
+	avail    float64
+	last     time.Time
+}
+
+func newBucket(perMinute float64) *bucket {
+	return &bucket{capacity: perMinute, perSec: perMinute / 60, avail: perMinute, last: time.Now()}
+}
+
+// clamp 는 한 번에 가져갈 양을 용량 이하로 맞춥니다 (용량보다 큰 요청이 영원히 막히지 않도록).
+func (b *bucket) clamp(n float64) float64 {
+	if b == nil {
+		return n
+	}
+	return math.Min(n, b.capacity)
+}
+
+// take 는 n 개를 가져갈 수 있을 때까지 기다립니다.
+func (b *bucket) take(ctx context.Context, n float64) error {
+	if b == nil || n <= 0 {
+		return nil
+	}
+	for {
+		b.mu.Lock()
+		now := time.Now()
+		b.avail = math.Min(b.capacity, b.avail+now.Sub(b.last).Seconds()*b.perSec)
+		b.last = now
+		if b.avail >= n {
+			b.avail -= n
+			b.mu.Unlock()
+			return nil
+		}
+		need := time.Duration((n - b.avail) / b.perSec * float64(time.Second))
+		b.mu.Unlock()
+
+		t := time.NewTimer(need)
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			t.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+func (b *bucket) refund(n float64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.avail = math.Min(b.capacity, b.avail+n)
+}
+
+// Limit 은 tag 로 등록된 클라이언트에 한도를 적용합니다 (이미 적용된 한도와 함께 동작).
+func (r *ModelRegistry) Limit(tag string, l Limits) error {
+	tag = r.Resolve(tag)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.tagToClient[tag]
+	if !ok {
+		return fmt.Errorf("model not registered: %s", tag)
+	}
+	if !l.IsZero() {
+		r.tagToClient[tag] = WithLimiter(c, NewLimiter(l))
+	}
+	return nil
+}
+
+// LimitedClient 는 호출 전에 Limiter 에서 자리를 얻는 래퍼입니다.
+// Response.QueueWait 에 대기 시간이 더해집니다.
+type LimitedClient struct {
+	inner   LLMClient
+	limiter *Limiter
+}
+
+// WithLimiter 는 client 호출이 lim 을 거치도록 감쌉니다. lim 이 nil 이면 client 를 그대로 반환합니다.
+func WithLimiter(client LLMClient, lim *Limiter) LLMClient {
+	if lim == nil {
+		return client
+	}
+	return &LimitedClient{inner: client, limiter: lim}
+}
+
+func (c *LimitedClient) Name() string { return c.inner.Name() }
+
+func (c *LimitedClient) Unwrap() LLMClient { return c.inner }
+
+// rewrap 은 같은 Limiter 를 공유합니다 (발견한 모델은 원래 태그와 같은 서버의 한도를 나눠 씀).
+func (c *LimitedClient) rewrap(tag string, inner LLMClient) LLMClient {
+	return &LimitedClient{inner: inner, limiter: c.limiter}
+}
+
+// estimate 는 모델 기본값(WithDefaults)을 적용한 실제 요청으로 사용량을 추정합니다
+// (한도는 defaults 바깥에 씌워지므로 req 에는 아직 max_tokens 가 없을 수 있음).
+func (c *LimitedClient) estimate(req GenerateRequest) Usage {
+	if d, ok := findBase[*defaultsClient](c.inner); ok {
+		req = d.defaults.Apply(req)
+	}
+	return EstimateUsage(req, utf8.RuneCountInString(req.Prompt()))
+}
+
+func (c *LimitedClient) Generate(ctx context.Context, prompt string) (string, error) {
+	resp, err := c.Complete(ctx, PromptRequest(prompt))
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+func (c *LimitedClient) Complete(ctx context.Context, req GenerateRequest) (*Response, error) {
+	est := c.estimate(req)
+	release, wait, err := c.limiter.Acquire(ctx, est.TotalTokens())
+	if err != nil {
+		return nil, fmt.Errorf("rate limiter (%s): %w", c.inner.Name(), err)
+	}
+	resp, err := Complete(ctx, c.inner, req)
+	if err != nil {
+		release(est.TotalTokens()) // 실패한 호출도 공급자 쪽 한도는 썼다고 봅니다
+		return nil, err
+	}
+	release(resp.Usage.TotalTokens())
+	resp.QueueWait += wait
+	return resp, nil
+}
+
+// Stream 은 스트림이 끝날 때(채널이 닫힐 때) 자리를 돌려줍니다.
+func (c *LimitedClient) Stream(ctx context.Context, req GenerateRequest) (<-chan StreamEvent, error) {
+	est := c.estimate(req)
+	release, wait, err := c.limiter.Acquire(ctx, est.TotalTokens())
+	if err != nil {
+		return nil, fmt.Errorf("rate limiter (%s): %w", c.inner.Name(), err)
+	}
+	in, err := streamOrComplete(ctx, c.inner, req)
+	if err != nil {
+		release(est.TotalTokens())
+		return nil, err
+	}
+	out := make(chan StreamEvent)
+	go func() {
+		defer close(out)
+		used := est.TotalTokens()
+		defer func() { release(used) }()
+		for ev := range in {
+			if ev.Done {
+				if ev.Usage != nil {
+					used = ev.Usage.TotalTokens()
+				}
+				ev.QueueWait += wait
+			}
+			if !emit(ctx, out, ev) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
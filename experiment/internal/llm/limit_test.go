@@ -0,0 +1,89 @@
+// internal/llm/limit_test.go
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"speckit-study/internal/llm"
+)
+
+func TestLimiterInFlight(t *testing.T) {
+	lim := llm.NewLimiter(llm.Limits{MaxInFlight: 2})
+	ctx := context.Background()
+	r1, _, err := lim.Acquire(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := lim.Acquire(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+
+	// 자리가 없으면 ctx 가 끝날 때까지 기다리다 빠집니다.
+	short, cancel := context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := lim.Acquire(short, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("third acquire: got %v, want the deadline", err)
+	}
+
+	go func() {
+		time.Sleep(30 * time.Millisecond)
+		r1(0)
+		r1(0) // 두 번 불러도 자리는 한 번만 돌려줍니다
+	}()
+	_, wait, err := lim.Acquire(ctx, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wait < 20*time.Millisecond {
+		t.Errorf("waited %s, want to block until a release", wait)
+	}
+	short, cancel = context.WithTimeout(ctx, 20*time.Millisecond)
+	defer cancel()
+	if _, _, err := lim.Acquire(short, 1); err == nil {
+		t.Error("a double release freed a second slot")
+	}
+}
+
+func TestLimiterTokenBucket(t *testing.T) {
+	lim := llm.NewLimiter(llm.Limits{TPM: 6000}) // 초당 100 토큰
+	ctx := context.Background()
+
+	// 용량보다 큰 요청은 용량으로 잘려 바로 통과합니다.
+	release, wait, err := lim.Acquire(ctx, 1_000_000)
+	if err != nil || wait > 10*time.Millisecond {
+		t.Fatalf("oversized request: wait %s, err %v", wait, err)
+	}
+	// 실제로 쓴 만큼만 차감되므로 나머지는 바로 다시 쓸 수 있습니다.
+	release(1000)
+	if _, wait, _ := lim.Acquire(ctx, 4000); wait > 10*time.Millisecond {
+		t.Errorf("refunded tokens: waited %s", wait)
+	}
+	// 남은 1000 을 넘는 요청은 채워질 때까지 기다립니다 (약 100ms).
+	if _, wait, _ := lim.Acquire(ctx, 1010); wait < 50*time.Millisecond {
+		t.Errorf("empty bucket: waited only %s", wait)
+	}
+}
+
+func TestLimitedClientQueueWait(t *testing.T) {
+	lim := llm.NewLimiter(llm.Limits{MaxInFlight: 1})
+	client := llm.WithLimiter(&stubClient{name: "m", replies: []stubReply{{text: "ok"}}}, lim)
+	release, _, err := lim.Acquire(context.Background(), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	time.AfterFunc(30*time.Millisecond, func() { release(0) })
+
+	resp, err := llm.Complete(context.Background(), client, llm.PromptRequest("p"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Text != "ok" || resp.QueueWait < 20*time.Millisecond {
+		t.Errorf("text %q queue wait %s, want the time spent waiting for the slot", resp.Text, resp.QueueWait)
+	}
+	if llm.WithLimiter(client, nil) != client {
+		t.Error("a nil limiter should leave the client unwrapped")
+	}
+}
@@ -0,0 +1,255 @@
+// internal/llm/middleware.go
+package llm
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Handler 는 요청 하나를 처리하는 함수입니다. 체인의 맨 끝은 실제 클라이언트 호출입니다.
+type Handler func(ctx context.Context, req GenerateRequest) (*Response, error)
+
+// Middleware 는 Handler 를 감싸 로깅/측정/검사 같은 공통 관심사를 더합니다.
+//
+//	func Header(next llm.Handler) llm.Handler {
+//		return func(ctx context.Context, req llm.GenerateRequest) (*llm.Response, error) {
+//			// 호출 전
+//			resp, err := next(ctx, req)
+//			// 호출 후
+//			return resp, err
+//		}
+//	}
+type Middleware func(next Handler) Handler
+
+// Chain 은 client 를 mws 로 감쌉니다. 첫 번째 미들웨어가 가장 바깥에서 실행됩니다.
+// 스트리밍 호출도 같은 체인을 거치며, 미들웨어는 스트림이 끝난 뒤 전체 Response 를 봅니다
+// (이미 내보낸 토큰은 되돌릴 수 없으므로 응답을 고치는 미들웨어는 Complete 에만 반영됩니다).
+func Chain(client LLMClient, mws ...Middleware) LLMClient {
+	return chainTagged("", client, mws)
+}
+
+func chainTagged(tag string, client LLMClient, mws []Middleware) LLMClient {
+	if len(mws) == 0 {
+		return client
+	}
+	return &middlewareClient{tag: tag, inner: client, mws: slices.Clone(mws)}
+}
+
+type clientTagKey struct{}
+
+// ClientTag 는 미들웨어 안에서 호출 대상의 레지스트리 태그를 알려 줍니다 (Chain 으로 직접 감쌌으면 빈 값).
+func ClientTag(ctx context.Context) string {
+	tag, _ := ctx.Value(clientTagKey{}).(string)
+	return tag
+}
+
+type middlewareClient struct {
+	tag   string
+	inner LLMClient
+	mws   []Middleware
+}
+
+func (c *middlewareClient) Name() string { return c.inner.Name() }
+
+func (c *middlewareClient) Unwrap() LLMClient { return c.inner }
+
+// rewrap 은 레지스트리 태그로 감싼 체인이면 새 태그를 붙이고, Chain 으로 직접 감싼 체인이면 태그 없이 둡니다.
+func (c *middlewareClient) rewrap(tag string, inner LLMClient) LLMClient {
+	if c.tag == "" {
+		tag = ""
+	}
+	return &middlewareClient{tag: tag, inner: inner, mws: c.mws}
+}
+
+func (c *middlewareClient) Generate(ctx context.Context, prompt string) (string, error) {
+	resp, err := c.Complete(ctx, PromptRequest(prompt))
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+func (c *middlewareClient) handler(terminal Handler) Handler {
+	h := terminal
+	for i := len(c.mws) - 1; i >= 0; i-- {
+		h = c.mws[i](h)
+	}
+	return h
+}
+
+func (c *middlewareClient) context(ctx context.Context) context.Context {
+	if c.tag == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, clientTagKey{}, c.tag)
+}
+
+func (c *middlewareClient) Complete(ctx context.Context, req GenerateRequest) (*Response, error) {
+	return c.handler(func(ctx context.Context, req GenerateRequest) (*Response, error) {
+		return Complete(ctx, c.inner, req)
+	})(c.context(ctx), req)
+}
+
+// Stream 은 체인 끝에서 inner 스트림을 열어 토큰을 그대로 전달합니다.
+// 스트림을 여는 데 실패하면(체인이 시작 전에 오류를 내면) FallbackClient 가 넘어갈 수 있도록 오류를 바로 반환합니다.
+func (c *middlewareClient) Stream(ctx context.Context, req GenerateRequest) (<-chan StreamEvent, error) {
+	out := make(chan StreamEvent)
+	started := make(chan struct{})
+	var once sync.Once
+	start := func() { once.Do(func() { close(started) }) }
+	failed := make(chan error, 1)
+
+	terminal := func(ctx context.Context, req GenerateRequest) (*Response, error) {
+		events, err := streamOrComplete(ctx, c.inner, req)
+		if err != nil {
+			return nil, err
+		}
+		start()
+		var sb strings.Builder
+		resp := &Response{Model: c.inner.Name()}
+		for ev := range events {
+			if ev.Err != nil {
+				resp.Text = sb.String()
+				return resp, ev.Err
+			}
+			if ev.Delta != "" {
+				sb.WriteString(ev.Delta)
+				if !emit(ctx, out, StreamEvent{Delta: ev.Delta}) {
+					break
+				}
+			}
+			if ev.Usage != nil {
+				resp.Usage = *ev.Usage
+			}
+			if ev.Model != "" {
+				resp.Model = ev.Model
+			}
+			if ev.Tag != "" {
+				resp.Tag = ev.Tag
+			}
+			resp.QueueWait += ev.QueueWait
+			resp.ToolCalls = append(resp.ToolCalls, ev.ToolCalls...)
+			resp.Cached = resp.Cached || ev.Cached
+			if ev.Done {
+				resp.Attempts, resp.RetriedErrors = ev.Attempts, ev.RetriedErrors
+			}
+		}
+		resp.Text = sb.String()
+		return resp, ctx.Err()
+	}
+
+	ctx = c.context(ctx)
+	go func() {
+		defer close(out)
+		resp, err := c.handler(terminal)(ctx, req)
+		select {
+		case <-started:
+		default:
+			if err != nil {
+				failed <- err
+				return
+			}
+			// 미들웨어가 inner 를 부르지 않고 응답했으면 통째로 한 번에 보냅니다.
+			start()
+			if !emit(ctx, out, StreamEvent{Delta: resp.Text}) {
+				return
+			}
+		}
+		if err != nil {
+			emit(ctx, out, StreamEvent{Err: err})
+			return
+		}
+		emit(ctx, out, StreamEvent{Done: true, Usage: &resp.Usage, Model: resp.Model, Tag: resp.Tag, QueueWait: resp.QueueWait, Latency: resp.Latency, ToolCalls: resp.ToolCalls, Cached: resp.Cached,
+			Attempts: resp.Attempts, RetriedErrors: resp.RetriedErrors})
+	}()
+
+	select {
+	case <-started:
+		return out, nil
+	case err := <-failed:
+		return nil, err
+	}
+}
+
+// Use 는 등록된 모든 모델 태그를 mws 체인으로 감쌉니다 (첫 번째가 가장 바깥).
+// Fallback/Router 같은 복합 클라이언트는 감싸지 않습니다. 이들은 이미 감싼 태그를 호출하므로
+// 같은 호출이 두 번 기록되지 않습니다. 여러 번 호출하면 나중 체인이 바깥에 붙습니다.
+func (r *ModelRegistry) Use(mws ...Middleware) {
+	r.WrapAll(func(tag string, c LLMClient) LLMClient {
+		if isComposite(c) {
+			return c
+		}
+		return chainTagged(tag, c, mws)
+	})
+}
+
+// UseFor 는 지정한 태그(또는 별칭)만 mws 체인으로 감쌉니다.
+func (r *ModelRegistry) UseFor(tags []string, mws ...Middleware) error {
+	for _, tag := range tags {
+		tag = r.Resolve(tag)
+		r.mu.Lock()
+		c, ok := r.tagToClient[tag]
+		if ok {
+			r.tagToClient[tag] = chainTagged(tag, c, mws)
+		}
+		r.mu.Unlock()
+		if !ok {
+			return fmt.Errorf("model not registered: %s", tag)
+		}
+	}
+	return nil
+}
+
+func isComposite(c LLMClient) bool {
+	switch c.(type) {
+	case *FallbackClient, *RouterClient:
+		return true
+	}
+	return false
+}
+
+// MiddlewareFactory 는 models.yaml 의 middleware 항목으로 Middleware 를 만듭니다.
+// decode 는 항목 전체(name 포함)를 팩토리가 정의한 옵션 구조체로 디코딩합니다.
+type MiddlewareFactory func(decode func(v interface{}) error) (Middleware, error)
+
+var (
+	middlewaresMu sync.RWMutex
+	middlewares   = map[string]MiddlewareFactory{}
+)
+
+// RegisterMiddleware 는 models.yaml 에서 name 으로 쓸 수 있는 미들웨어를 등록합니다.
+// 보통 init 에서 호출합니다.
+//
+//	llm.RegisterMiddleware("audit", func(decode func(interface{}) error) (llm.Middleware, error) {
+//		var opts struct{ Path string `yaml:"path"` }
+//		if err := decode(&opts); err != nil {
+//			return nil, err
+//		}
+//		return newAudit(opts.Path), nil
+//	})
+func RegisterMiddleware(name string, factory MiddlewareFactory) {
+	middlewaresMu.Lock()
+	defer middlewaresMu.Unlock()
+	middlewares[name] = factory
+}
+
+func middlewareFactory(name string) (MiddlewareFactory, bool) {
+	middlewaresMu.RLock()
+	defer middlewaresMu.RUnlock()
+	f, ok := middlewares[name]
+	return f, ok
+}
+
+func middlewareNames() []string {
+	middlewaresMu.RLock()
+	defer middlewaresMu.RUnlock()
+	names := make([]string, 0, len(middlewares))
+	for n := range middlewares {
+		names = append(names, n)
+	}
+	slices.Sort(names)
+	return names
+}
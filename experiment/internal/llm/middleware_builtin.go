@@ -0,0 +1,166 @@
+// internal/llm/middleware_builtin.go
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"time"
+	"unicode/utf8"
+)
+
+// Logging 은 호출마다 slog 레코드 한 건을 남깁니다 (성공은 Info, 실패는 Error).
+// 프롬프트/응답 본문은 남기지 않고 크기와 토큰 수만 기록하며, 오류 메시지의 키는 가립니다.
+func Logging(logger *slog.Logger) Middleware {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req GenerateRequest) (*Response, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+			info := CallInfoFrom(ctx)
+			attrs := []slog.Attr{
+				slog.String("tag", ClientTag(ctx)),
+				slog.String("task", info.Task),
+				slog.String("artifact", info.Artifact),
+				slog.Duration("latency", time.Since(start)),
+				slog.Int("prompt_chars", utf8.RuneCountInString(req.Prompt())),
+			}
+			if resp != nil {
+				attrs = append(attrs,
+					slog.String("model", resp.Model),
+					slog.Int("response_chars", len(resp.Text)),
+					slog.Int("input_tokens", resp.Usage.InputTokens),
+					slog.Int("output_tokens", resp.Usage.OutputTokens),
+					slog.Int("attempts", resp.Attempts),
+					slog.Duration("queue_wait", resp.QueueWait),
+				)
+			}
+			if err != nil {
+				attrs = append(attrs, slog.String("error", RedactSecrets(err.Error())))
+				logger.LogAttrs(ctx, slog.LevelError, "llm call failed", attrs...)
+			} else {
+				logger.LogAttrs(ctx, slog.LevelInfo, "llm call", attrs...)
+			}
+			return resp, err
+		}
+	}
+}
+
+// Timing 은 호출 시간을 Response.Latency 에 기록하고, observe 가 있으면 태그와 함께 알려 줍니다.
+// Latency 에는 rate limiter 대기(QueueWait)와 재시도가 포함됩니다.
+func Timing(observe func(tag string, latency time.Duration, err error)) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req GenerateRequest) (*Response, error) {
+			start := time.Now()
+			resp, err := next(ctx, req)
+			d := time.Since(start)
+			if resp != nil {
+				resp.Latency = d
+			}
+			if observe != nil {
+				observe(ClientTag(ctx), d, err)
+			}
+			return resp, err
+		}
+	}
+}
+
+// ErrSizeLimit 은 프롬프트나 응답이 SizeLimit 의 상한을 넘었을 때의 오류입니다.
+var ErrSizeLimit = errors.New("size limit exceeded")
+
+// SizeLimit 은 프롬프트(시스템 포함)와 응답의 글자 수 상한을 검사합니다 (0 = 제한 없음).
+// 프롬프트가 크면 호출하지 않고, 응답이 크면 잘린 응답과 함께 ErrSizeLimit 을 반환합니다.
+func SizeLimit(maxPromptChars, maxResponseChars int) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req GenerateRequest) (*Response, error) {
+			if n := len([]rune(req.Prompt())); maxPromptChars > 0 && n > maxPromptChars {
+				return nil, fmt.Errorf("%w: prompt has %d chars (max %d)", ErrSizeLimit, n, maxPromptChars)
+			}
+			resp, err := next(ctx, req)
+			if err != nil || resp == nil || maxResponseChars <= 0 {
+				return resp, err
+			}
+			if r := []rune(resp.Text); len(r) > maxResponseChars {
+				resp.Text = string(r[:maxResponseChars])
+				return resp, fmt.Errorf("%w: response has %d chars (max %d)", ErrSizeLimit, len(r), maxResponseChars)
+			}
+			return resp, nil
+		}
+	}
+}
+
+// PanicError 는 Recover 가 잡은 패닉입니다.
+type PanicError struct {
+	Value interface{}
+	Stack []byte
+}
+
+func (e *PanicError) Error() string { return fmt.Sprintf("llm client panic: %v", e.Value) }
+
+// Recover 는 체인 안쪽(클라이언트 포함)의 패닉을 PanicError 로 바꿔, 한 모델의 버그가 전체 실행을 멈추지 않게 합니다.
+// 다른 미들웨어의 패닉까지 잡으려면 체인의 맨 앞에 두세요.
+func Recover() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, req GenerateRequest) (resp *Response, err error) {
+			defer func() {
+				if v := recover(); v != nil {
+					resp, err = nil, &PanicError{Value: v, Stack: debug.Stack()}
+				}
+			}()
+			return next(ctx, req)
+		}
+	}
+}
+
+// 내장 미들웨어 (models.yaml 의 middleware 항목 이름)
+func init() {
+	RegisterMiddleware("logging", func(decode func(interface{}) error) (Middleware, error) {
+		var opts struct {
+			Level string `yaml:"level"` // debug | info | warn | error (기본 info)
+		}
+		if err := decode(&opts); err != nil {
+			return nil, err
+		}
+		var level slog.Level
+		if opts.Level != "" {
+			if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+				return nil, err
+			}
+		}
+		h := slog.Default().Handler()
+		return Logging(slog.New(levelHandler{Handler: h, min: level})), nil
+	})
+	RegisterMiddleware("timing", func(func(interface{}) error) (Middleware, error) {
+		return Timing(nil), nil
+	})
+	RegisterMiddleware("size_limit", func(decode func(interface{}) error) (Middleware, error) {
+		var opts struct {
+			MaxPromptChars   int `yaml:"max_prompt_chars"`
+			MaxResponseChars int `yaml:"max_response_chars"`
+		}
+		if err := decode(&opts); err != nil {
+			return nil, err
+		}
+		if opts.MaxPromptChars < 0 || opts.MaxResponseChars < 0 {
+			return nil, errors.New("size limits must not be negative")
+		}
+		return SizeLimit(opts.MaxPromptChars, opts.MaxResponseChars), nil
+	})
+	RegisterMiddleware("recover", func(func(interface{}) error) (Middleware, error) {
+		return Recover(), nil
+	})
+}
+
+// levelHandler 는 기본 slog 핸들러 앞에서 레벨만 거릅니다.
+type levelHandler struct {
+	slog.Handler
+	min slog.Level
+}
+
+func (h levelHandler) Enabled(ctx context.Context, l slog.Level) bool {
+	return l >= h.min && h.Handler.Enabled(ctx, l)
+}
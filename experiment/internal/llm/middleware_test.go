@@ -0,0 +1,126 @@
+// internal/llm/middleware_test.go
+package llm_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"speckit-study/internal/llm"
+)
+
+// trace 는 호출 전후에 이름을 남기는 미들웨어입니다 (체인 순서 확인용).
+func trace(name string, log *[]string) llm.Middleware {
+	return func(next llm.Handler) llm.Handler {
+		return func(ctx context.Context, req llm.GenerateRequest) (*llm.Response, error) {
+			*log = append(*log, name+">"+llm.ClientTag(ctx))
+			resp, err := next(ctx, req)
+			*log = append(*log, "<"+name)
+			return resp, err
+		}
+	}
+}
+
+func TestMiddlewareOrder(t *testing.T) {
+	var log []string
+	reg := llm.NewModelRegistry()
+	reg.RegisterModel("a", &stubClient{name: "a", replies: []stubReply{{text: "ok"}}})
+	reg.RegisterModel("safe", llm.NewFallbackClient(reg, "a"))
+	reg.Use(trace("outer", &log), trace("inner", &log))
+
+	safe, _ := reg.GetModel("safe")
+	if _, err := llm.Complete(context.Background(), safe, llm.PromptRequest("p")); err != nil {
+		t.Fatal(err)
+	}
+	// 폴백은 감싸지 않으므로 a 의 체인만 한 번 실행됩니다.
+	want := []string{"outer>a", "inner>a", "<inner", "<outer"}
+	if strings.Join(log, " ") != strings.Join(want, " ") {
+		t.Errorf("chain ran %q, want %q", log, want)
+	}
+	if err := reg.UseFor([]string{"missing"}, trace("x", &log)); err == nil {
+		t.Error("UseFor on an unknown tag: want an error")
+	}
+}
+
+func TestSizeLimit(t *testing.T) {
+	client := &stubClient{name: "m", replies: []stubReply{{text: "ünïcode answer"}}}
+	ctx := context.Background()
+
+	_, err := llm.Complete(ctx, llm.Chain(client, llm.SizeLimit(3, 0)), llm.PromptRequest("long prompt"))
+	if !errors.Is(err, llm.ErrSizeLimit) || client.calls != 0 {
+		t.Fatalf("got %v after %d calls, want ErrSizeLimit before calling", err, client.calls)
+	}
+	resp, err := llm.Complete(ctx, llm.Chain(client, llm.SizeLimit(0, 7)), llm.PromptRequest("p"))
+	if !errors.Is(err, llm.ErrSizeLimit) || resp == nil || resp.Text != "ünïcode" {
+		t.Errorf("got %v, %v, want the response cut to 7 runes with ErrSizeLimit", resp, err)
+	}
+}
+
+type panicClient struct{}
+
+func (panicClient) Name() string { return "panicky" }
+
+func (panicClient) Generate(ctx context.Context, prompt string) (string, error) {
+	panic("nil map write")
+}
+
+func TestRecoverAndLogging(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	failing := &stubClient{name: "m", replies: []stubReply{{err: errors.New("bad key sk-abcdefghijklmnop0123")}}}
+	ctx := llm.WithCallInfo(context.Background(), llm.CallInfo{Task: "api_design", Artifact: "plan.md"})
+
+	_, err := llm.Complete(ctx, llm.Chain(panicClient{}, llm.Recover(), llm.Logging(logger)), llm.PromptRequest("p"))
+	var pe *llm.PanicError
+	if !errors.As(err, &pe) || pe.Value != "nil map write" || len(pe.Stack) == 0 {
+		t.Fatalf("got %v, want a PanicError with the stack", err)
+	}
+
+	buf.Reset()
+	llm.Complete(ctx, llm.Chain(failing, llm.Logging(logger)), llm.PromptRequest("p"))
+	out := buf.String()
+	if !strings.Contains(out, "level=ERROR") || !strings.Contains(out, "task=api_design") || !strings.Contains(out, "artifact=plan.md") {
+		t.Errorf("log record missing fields: %s", out)
+	}
+	if strings.Contains(out, "sk-abcdefghijklmnop0123") {
+		t.Errorf("key leaked into the log: %s", out)
+	}
+}
+
+// 스트리밍도 체인을 거치며, 미들웨어는 스트림이 끝난 뒤 전체 응답을 봅니다.
+func TestMiddlewareStream(t *testing.T) {
+	var seen string
+	var latency bool
+	capture := func(next llm.Handler) llm.Handler {
+		return func(ctx context.Context, req llm.GenerateRequest) (*llm.Response, error) {
+			resp, err := next(ctx, req)
+			if resp != nil {
+				seen = resp.Text
+			}
+			return resp, err
+		}
+	}
+	client := llm.Chain(&streamStub{name: "m", deltas: []string{"## ", "Steps"}}, llm.Timing(func(string, time.Duration, error) { latency = true }), capture)
+
+	var w countingWriter
+	resp, err := llm.StreamTo(context.Background(), client, llm.PromptRequest("p"), &w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w.String() != "## Steps" || w.writes != 2 || seen != "## Steps" {
+		t.Errorf("streamed %q in %d writes, middleware saw %q", w.String(), w.writes, seen)
+	}
+	if !latency || resp.Latency == 0 {
+		t.Errorf("timing not recorded: observed=%v latency=%s", latency, resp.Latency)
+	}
+
+	// 체인이 inner 를 부르기 전에 실패하면 Stream 이 바로 오류를 돌려줍니다.
+	blocked := llm.Chain(&streamStub{name: "m"}, llm.SizeLimit(1, 0))
+	if _, err := blocked.(llm.Streamer).Stream(context.Background(), llm.PromptRequest("too long")); !errors.Is(err, llm.ErrSizeLimit) {
+		t.Errorf("got %v, want ErrSizeLimit from Stream", err)
+	}
+}
@@ -0,0 +1,280 @@
+// internal/llm/ollama_client.go
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OllamaBaseURL 은 로컬 Ollama 서버의 기본 주소입니다. OLLAMA_HOST 환경 변수로 바꿀 수 있습니다.
+const OllamaBaseURL = "http://localhost:11434"
+
+// OllamaClient 는 Ollama 네이티브 API(/api/chat)를 쓰는 클라이언트입니다.
+// OpenAI 호환 엔드포인트(/v1)를 쓰려면 NewOpenAICompatibleClient 를 사용하세요.
+type OllamaClient struct {
+	Model   string
+	BaseURL string
+	Retry   RetryPolicy
+	Headers map[string]string // 프록시 인증 등 추가 헤더
+	httpc   *http.Client
+}
+
+func NewOllamaClient(model string) *OllamaClient {
+	base := envOr("OLLAMA_HOST", OllamaBaseURL)
+	if !strings.Contains(base, "://") {
+		base = "http://" + base // OLLAMA_HOST=127.0.0.1:11434 형식 지원
+	}
+	return &OllamaClient{
+		Model:   model, // 예: "llama3.1:8b"
+		BaseURL: base,
+		Retry:   DefaultRetryPolicy,
+		httpc:   &http.Client{Timeout: 120 * time.Second},
+	}
+}
+
+func (c *OllamaClient) Name() string { return c.Model }
+
+// WithModel 은 같은 서버 설정으로 model 만 바꾼 복사본을 반환합니다.
+func (c *OllamaClient) WithModel(model string) LLMClient {
+	cp := *c
+	cp.Model = model
+	return &cp
+}
+
+func (c *OllamaClient) Generate(ctx context.Context, prompt string) (string, error) {
+	resp, err := c.Complete(ctx, PromptRequest(prompt))
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// ollamaChunk 는 /api/chat 응답(스트리밍 시 한 줄)입니다.
+type ollamaChunk struct {
+	Model   string `json:"model"`
+	Message struct {
+		Content   string `json:"content"`
+		ToolCalls []struct {
+			Function struct {
+				Name      string          `json:"name"`
+				Arguments json.RawMessage `json:"arguments"`
+			} `json:"function"`
+		} `json:"tool_calls"`
+	} `json:"message"`
+	Done            bool   `json:"done"`
+	DoneReason      string `json:"done_reason"`
+	PromptEvalCount int    `json:"prompt_eval_count"`
+	EvalCount       int    `json:"eval_count"`
+	Error           string `json:"error"`
+}
+
+func (ch ollamaChunk) usage() Usage {
+	return Usage{InputTokens: ch.PromptEvalCount, OutputTokens: ch.EvalCount}
+}
+
+// toolCalls 는 메시지의 도구 호출을 정규화합니다. Ollama 는 호출 ID 를 주지 않아 순번으로 만듭니다 (seen 은 지금까지 받은 호출 수).
+func (ch ollamaChunk) toolCalls(seen int) []ToolCall {
+	var calls []ToolCall
+	for _, tc := range ch.Message.ToolCalls {
+		calls = append(calls, ToolCall{
+			ID:        fmt.Sprintf("call_%d", seen+len(calls)+1),
+			Name:      tc.Function.Name,
+			Arguments: tc.Function.Arguments,
+		})
+	}
+	return calls
+}
+
+// requestBody 는 GenerateRequest 를 /api/chat 본문으로 변환합니다. 생성 파라미터는 options 로 보냅니다.
+// 도구 호출 인자는 문자열이 아닌 객체로, 도구 결과는 tool_name 을 붙인 role "tool" 메시지로 보냅니다.
+func (c *OllamaClient) requestBody(r GenerateRequest, stream bool) map[string]interface{} {
+	messages := make([]map[string]interface{}, 0, len(r.Messages)+1)
+	if sys := r.SystemText(); sys != "" {
+		messages = append(messages, map[string]interface{}{"role": "system", "content": sys})
+	}
+	for _, m := range r.ChatMessages() {
+		msg := map[string]interface{}{"role": string(m.Role), "content": m.Content}
+		if len(m.ToolCalls) > 0 {
+			calls := make([]map[string]interface{}, len(m.ToolCalls))
+			for i, tc := range m.ToolCalls {
+				calls[i] = map[string]interface{}{
+					"function": map[string]interface{}{"name": tc.Name, "arguments": tc.args()},
+				}
+			}
+			msg["tool_calls"] = calls
+		}
+		if m.Role == RoleTool {
+			msg["tool_name"] = m.ToolName
+		}
+		messages = append(messages, msg)
+	}
+
+	options := map[string]interface{}{
+		"num_predict": r.maxTokens(),
+	}
+	if r.Temperature != nil {
+		options["temperature"] = *r.Temperature
+	}
+	if r.TopP != nil {
+		options["top_p"] = *r.TopP
+	}
+	if len(r.Stop) > 0 {
+		options["stop"] = r.Stop
+	}
+	if r.Seed != nil {
+		options["seed"] = *r.Seed
+	}
+	body := map[string]interface{}{
+		"model":    c.Model,
+		"messages": messages,
+		"stream":   stream,
+		"options":  options,
+	}
+	if r.ResponseFormat != nil {
+		body["format"] = r.ResponseFormat.Schema
+	}
+	// Ollama 는 tool_choice 를 지원하지 않아 "none" 이면 도구를 아예 보내지 않습니다.
+	if len(r.Tools) > 0 && r.ToolChoice != "none" {
+		tools := make([]map[string]interface{}, len(r.Tools))
+		for i, t := range r.Tools {
+			tools[i] = map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        t.Name,
+					"description": t.Description,
+					"parameters":  t.toolParameters(),
+				},
+			}
+		}
+		body["tools"] = tools
+	}
+	return body
+}
+
+func (c *OllamaClient) newRequest(ctx context.Context, method, path string, body interface{}) (*http.Request, error) {
+	var rd *bytes.Reader
+	if body != nil {
+		b, _ := json.Marshal(body)
+		rd = bytes.NewReader(b)
+	}
+	var req *http.Request
+	var err error
+	if rd != nil {
+		req, err = http.NewRequestWithContext(ctx, method, joinURL(c.BaseURL, path), rd)
+	} else {
+		req, err = http.NewRequestWithContext(ctx, method, joinURL(c.BaseURL, path), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+	return req, nil
+}
+
+// Complete 는 GenerateRequest 를 /api/chat (stream=false) 로 보냅니다.
+func (c *OllamaClient) Complete(ctx context.Context, r GenerateRequest) (*Response, error) {
+	body := c.requestBody(r, false)
+
+	var decoded ollamaChunk
+	stats, err := doJSON(ctx, c.httpc, c.Retry, "ollama", func() (*http.Request, error) {
+		return c.newRequest(ctx, "POST", "api/chat", body)
+	}, &decoded)
+	if err != nil {
+		return nil, err
+	}
+	calls := decoded.toolCalls(0)
+	if decoded.Message.Content == "" && len(calls) == 0 {
+		return nil, errEmptyResponse("ollama", "no message content")
+	}
+	return &Response{
+		Text:          decoded.Message.Content,
+		Model:         c.Model,
+		FinishReason:  decoded.DoneReason,
+		Usage:         decoded.usage(),
+		Attempts:      stats.Attempts,
+		RetriedErrors: stats.Retried,
+		ToolCalls:     calls,
+	}, nil
+}
+
+// Stream 은 /api/chat (stream=true) 의 NDJSON 스트림으로 토큰을 전달합니다.
+// 마지막 줄(done=true)에 prompt_eval_count/eval_count 가 담깁니다.
+func (c *OllamaClient) Stream(ctx context.Context, r GenerateRequest) (<-chan StreamEvent, error) {
+	body := c.requestBody(r, true)
+	resp, stats, err := doWithRetry(ctx, streamingHTTPClient(c.httpc), c.Retry, "ollama", func() (*http.Request, error) {
+		return c.newRequest(ctx, "POST", "api/chat", body)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan StreamEvent)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+
+		var calls []ToolCall
+		sc := bufio.NewScanner(resp.Body)
+		sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+		for sc.Scan() {
+			line := bytes.TrimSpace(sc.Bytes())
+			if len(line) == 0 {
+				continue
+			}
+			var chunk ollamaChunk
+			if err := json.Unmarshal(line, &chunk); err != nil {
+				emit(ctx, ch, StreamEvent{Err: err})
+				return
+			}
+			if chunk.Error != "" {
+				emit(ctx, ch, StreamEvent{Err: &ProviderError{
+					Provider: "ollama", StatusCode: http.StatusOK, Kind: ErrorKindServer, Message: chunk.Error,
+				}})
+				return
+			}
+			calls = append(calls, chunk.toolCalls(len(calls))...)
+			if chunk.Message.Content != "" && !emit(ctx, ch, StreamEvent{Delta: chunk.Message.Content}) {
+				return
+			}
+			if chunk.Done {
+				u := chunk.usage()
+				emit(ctx, ch, StreamEvent{Done: true, Usage: &u, ToolCalls: calls, Attempts: stats.Attempts, RetriedErrors: stats.Retried})
+				return
+			}
+		}
+		if err := sc.Err(); err != nil {
+			emit(ctx, ch, StreamEvent{Err: err})
+		}
+	}()
+	return ch, nil
+}
+
+// ListModels 는 GET /api/tags 로 로컬에 받아 둔 모델 목록을 조회합니다.
+func (c *OllamaClient) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	var decoded struct {
+		Models []struct {
+			Name string `json:"name"`
+			Size int64  `json:"size"`
+		} `json:"models"`
+	}
+	_, err := doJSON(ctx, c.httpc, c.Retry, "ollama", func() (*http.Request, error) {
+		return c.newRequest(ctx, "GET", "api/tags", nil)
+	}, &decoded)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]ModelInfo, 0, len(decoded.Models))
+	for _, m := range decoded.Models {
+		models = append(models, ModelInfo{ID: m.Name, OwnedBy: "ollama", Size: m.Size})
+	}
+	return models, nil
+}
@@ -0,0 +1,202 @@
+// internal/llm/ollama_test.go
+package llm_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"speckit-study/internal/llm"
+)
+
+// ollamaServer 는 handler 로 응답하는 가짜 Ollama 서버와 그 서버를 가리키는 클라이언트입니다 (재시도 없음).
+func ollamaServer(t *testing.T, handler http.HandlerFunc) *llm.OllamaClient {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	c := llm.NewOllamaClient("llama-test")
+	c.BaseURL, c.Retry = srv.URL, llm.NoRetry
+	return c
+}
+
+// writeNDJSON 은 lines 를 한 줄씩 flush 하며 보냅니다 (Ollama 의 stream=true 응답).
+func writeNDJSON(w http.ResponseWriter, lines ...string) {
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	for _, l := range lines {
+		io.WriteString(w, l+"\n")
+		w.(http.Flusher).Flush()
+	}
+}
+
+func TestOllamaStreamNDJSON(t *testing.T) {
+	var body struct {
+		Model  string `json:"model"`
+		Stream bool   `json:"stream"`
+	}
+	c := ollamaServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/chat" {
+			t.Errorf("path = %s, want /api/chat", r.URL.Path)
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		writeNDJSON(w,
+			`{"model":"llama-test","message":{"role":"assistant","content":"## Steps"},"done":false}`,
+			``, // 빈 줄은 건너뜁니다
+			`{"model":"llama-test","message":{"role":"assistant","content":"\n1. run"},"done":false}`,
+			`{"model":"llama-test","message":{"role":"assistant","content":""},"done":true,"done_reason":"stop","prompt_eval_count":12,"eval_count":5}`,
+		)
+	})
+
+	var w countingWriter
+	resp, err := llm.StreamTo(context.Background(), c, llm.PromptRequest("p"), &w)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !body.Stream || body.Model != "llama-test" {
+		t.Errorf("request body = %+v, want a stream for llama-test", body)
+	}
+	if w.String() != "## Steps\n1. run" || w.writes != 2 {
+		t.Errorf("streamed %q in %d writes, want two deltas", w.String(), w.writes)
+	}
+	if resp.Usage.InputTokens != 12 || resp.Usage.OutputTokens != 5 {
+		t.Errorf("usage = %+v, want 12/5 from the done line", resp.Usage)
+	}
+}
+
+// Ollama 는 스트림 도중 실패를 200 응답 안의 {"error": ...} 줄로 알립니다.
+func TestOllamaStreamErrorLine(t *testing.T) {
+	c := ollamaServer(t, func(w http.ResponseWriter, r *http.Request) {
+		writeNDJSON(w,
+			`{"message":{"content":"partial"},"done":false}`,
+			`{"error":"model runner has unexpectedly stopped"}`,
+		)
+	})
+
+	var sb strings.Builder
+	_, err := llm.StreamTo(context.Background(), c, llm.PromptRequest("p"), &sb)
+	var pe *llm.ProviderError
+	if !errors.As(err, &pe) {
+		t.Fatalf("got %v, want a ProviderError", err)
+	}
+	if pe.Provider != "ollama" || pe.StatusCode != http.StatusOK || pe.Kind != llm.ErrorKindServer ||
+		pe.Message != "model runner has unexpectedly stopped" {
+		t.Errorf("error = %+v", pe)
+	}
+	if llm.IsRetryable(err) {
+		t.Error("an error inside a 200 stream is not retryable")
+	}
+	if sb.String() != "partial" {
+		t.Errorf("streamed %q before the error, want %q", sb.String(), "partial")
+	}
+}
+
+// Ollama 는 도구 호출 ID 를 주지 않으므로 응답 안에서 call_1, call_2 ... 로 번호를 매깁니다.
+// 스트림에서는 여러 줄에 나뉘어 와도 번호가 이어집니다.
+func TestOllamaToolCallIDs(t *testing.T) {
+	c := ollamaServer(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if !body.Stream {
+			io.WriteString(w, `{"message":{"content":"","tool_calls":[`+
+				`{"function":{"name":"read_file","arguments":{"path":"a/specify.md"}}},`+
+				`{"function":{"name":"read_file","arguments":{"path":"a/plan.md"}}}]},"done":true}`)
+			return
+		}
+		writeNDJSON(w,
+			`{"message":{"content":"","tool_calls":[{"function":{"name":"list_specs","arguments":{}}}]},"done":false}`,
+			`{"message":{"content":"","tool_calls":[{"function":{"name":"read_file","arguments":{"path":"a/plan.md"}}}]},"done":false}`,
+			`{"message":{"content":""},"done":true,"done_reason":"stop"}`,
+		)
+	})
+
+	req := llm.PromptRequest("read the spec")
+	req.Tools = []llm.Tool{{Name: "read_file"}, {Name: "list_specs"}}
+	resp, err := llm.Complete(context.Background(), c, req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := toolCallIDs(resp.ToolCalls); got != "call_1:read_file,call_2:read_file" {
+		t.Errorf("complete tool calls = %s", got)
+	}
+	if args := string(resp.ToolCalls[1].Arguments); args != `{"path":"a/plan.md"}` {
+		t.Errorf("arguments = %s", args)
+	}
+
+	resp, err = llm.StreamTo(context.Background(), c, req, io.Discard)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := toolCallIDs(resp.ToolCalls); got != "call_1:list_specs,call_2:read_file" {
+		t.Errorf("streamed tool calls = %s", got)
+	}
+}
+
+func toolCallIDs(calls []llm.ToolCall) string {
+	var parts []string
+	for _, tc := range calls {
+		parts = append(parts, tc.ID+":"+tc.Name)
+	}
+	return strings.Join(parts, ",")
+}
+
+// OLLAMA_HOST 는 Ollama CLI 처럼 scheme 없이 host:port 만 줘도 됩니다.
+func TestOllamaHostWithoutScheme(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, `{"message":{"content":"pong"},"done":true}`)
+	}))
+	defer srv.Close()
+	hostPort := strings.TrimPrefix(srv.URL, "http://")
+
+	cases := map[string]string{
+		hostPort:               srv.URL,
+		srv.URL:                srv.URL,
+		"https://ollama.local": "https://ollama.local",
+		"":                     llm.OllamaBaseURL,
+	}
+	for env, want := range cases {
+		t.Setenv("OLLAMA_HOST", env)
+		if got := llm.NewOllamaClient("m").BaseURL; got != want {
+			t.Errorf("OLLAMA_HOST=%q: BaseURL = %q, want %q", env, got, want)
+		}
+	}
+
+	t.Setenv("OLLAMA_HOST", hostPort)
+	c := llm.NewOllamaClient("m")
+	c.Retry = llm.NoRetry
+	out, err := c.Generate(context.Background(), "ping")
+	if err != nil || out != "pong" {
+		t.Fatalf("Generate via OLLAMA_HOST=%s: %q, %v", hostPort, out, err)
+	}
+}
+
+func TestOllamaListModels(t *testing.T) {
+	c := ollamaServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/api/tags" {
+			t.Errorf("got %s %s, want GET /api/tags", r.Method, r.URL.Path)
+		}
+		io.WriteString(w, `{"models":[{"name":"llama3.1:8b","size":4920753328},{"name":"qwen2.5:7b","size":4683087332}]}`)
+	})
+
+	models, err := c.ListModels(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []llm.ModelInfo{
+		{ID: "llama3.1:8b", OwnedBy: "ollama", Size: 4920753328},
+		{ID: "qwen2.5:7b", OwnedBy: "ollama", Size: 4683087332},
+	}
+	if len(models) != len(want) {
+		t.Fatalf("models = %+v, want %+v", models, want)
+	}
+	for i := range want {
+		if models[i] != want[i] {
+			t.Errorf("models[%d] = %+v, want %+v", i, models[i], want[i])
+		}
+	}
+}
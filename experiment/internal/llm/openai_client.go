@@ -5,64 +5,381 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"errors"
 	"net/http"
-	"os"
 	"time"
 )
 
+// OpenAIBaseURL 은 OpenAI API 의 기본 주소입니다. OPENAI_BASE_URL 환경 변수로 바꿀 수 있습니다.
+const OpenAIBaseURL = "https://api.openai.com/v1"
+
 type OpenAIClient struct {
-	Model  string
-	apiKey string
-	httpc  *http.Client
+	Model   string
+	BaseURL string // 예: "https://api.openai.com/v1", 테스트 시 fakellm 주소
+	Retry   RetryPolicy
+
+	// AuthHeader/AuthScheme 은 키를 실을 헤더와 접두어입니다 (기본 "Authorization", "Bearer").
+	// 키가 비어 있으면 인증 헤더를 보내지 않습니다 (인증 없는 로컬 서버).
+	AuthHeader string
+	AuthScheme string
+	Headers    map[string]string // 추가 헤더 (예: OpenRouter 의 HTTP-Referer)
+
+	provider     string // 오류 메시지용 공급자 이름
+	maxTokensKey string // "max_completion_tokens" 또는 호환 서버용 "max_tokens"
+	apiKey       *secretRef
+	httpc        *http.Client
 }
 
 func NewOpenAIClient(model string) *OpenAIClient {
 	return &OpenAIClient{
-		Model:  model, // 예: "gpt-4.1"
-		apiKey: os.Getenv("OPENAI_API_KEY"),
-		httpc:  &http.Client{Timeout: 30 * time.Second},
+		Model:        model, // 예: "gpt-4.1"
+		BaseURL:      envOr("OPENAI_BASE_URL", OpenAIBaseURL),
+		Retry:        DefaultRetryPolicy,
+		AuthHeader:   "Authorization",
+		AuthScheme:   "Bearer",
+		provider:     "openai",
+		maxTokensKey: "max_completion_tokens",
+		apiKey:       secretNamed("OPENAI_API_KEY"),
+		httpc:        &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// NewOpenAICompatibleClient 는 OpenAI Chat Completions 호환 서버(vLLM, LM Studio, llama.cpp server,
+// Ollama 의 /v1 등)용 클라이언트를 만듭니다. 키는 기본적으로 비어 있으며 SetAPIKey 로 지정합니다.
+// 예: NewOpenAICompatibleClient("http://localhost:1234/v1", "qwen2.5-7b-instruct")
+func NewOpenAICompatibleClient(baseURL, model string) *OpenAIClient {
+	c := NewOpenAIClient(model)
+	c.BaseURL = baseURL
+	c.provider = "openai-compatible"
+	c.maxTokensKey = "max_tokens"
+	c.apiKey = nil
+	c.httpc.Timeout = 120 * time.Second // 로컬 모델은 느릴 수 있습니다
+	return c
+}
+
+// SetAPIKey 는 인증 키를 바꿉니다 (빈 값이면 인증 헤더를 보내지 않음).
+func (c *OpenAIClient) SetAPIKey(key string) {
+	c.apiKey = nil
+	if key != "" {
+		c.apiKey = secretValue(key)
 	}
 }
 
+// SetAPIKeySecret 은 인증 키를 SecretProvider 의 name 으로 (첫 요청 때) 조회하도록 합니다.
+func (c *OpenAIClient) SetAPIKeySecret(name string) { c.apiKey = secretNamed(name) }
+
+// WithModel 은 같은 서버/인증 설정으로 model 만 바꾼 복사본을 반환합니다.
+func (c *OpenAIClient) WithModel(model string) LLMClient {
+	cp := *c
+	cp.Model = model
+	return &cp
+}
+
 func (c *OpenAIClient) Name() string { return c.Model }
 
 func (c *OpenAIClient) Generate(ctx context.Context, prompt string) (string, error) {
+	resp, err := c.Complete(ctx, PromptRequest(prompt))
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+// requestBody 는 GenerateRequest 를 Chat Completions 요청 본문으로 변환합니다.
+// 도구 호출은 assistant 메시지의 tool_calls, 결과는 role "tool" 메시지로 보냅니다.
+func (c *OpenAIClient) requestBody(r GenerateRequest) map[string]interface{} {
+	messages := make([]map[string]interface{}, 0, len(r.Messages)+1)
+	if sys := r.SystemText(); sys != "" {
+		messages = append(messages, map[string]interface{}{"role": "system", "content": sys})
+	}
+	for _, m := range r.ChatMessages() {
+		msg := map[string]interface{}{"role": string(m.Role), "content": m.Content}
+		if len(m.ToolCalls) > 0 {
+			calls := make([]map[string]interface{}, len(m.ToolCalls))
+			for i, tc := range m.ToolCalls {
+				calls[i] = map[string]interface{}{
+					"id":   tc.ID,
+					"type": "function",
+					"function": map[string]string{
+						"name":      tc.Name,
+						"arguments": string(tc.args()),
+					},
+				}
+			}
+			msg["tool_calls"] = calls
+		}
+		if m.Role == RoleTool {
+			msg["tool_call_id"] = m.ToolCallID
+		}
+		messages = append(messages, msg)
+	}
+
 	body := map[string]interface{}{
-		"model": c.Model,
-		"messages": []map[string]string{
-			{"role": "user", "content": prompt},
-		},
-		"max_completion_tokens": 2048,
+		"model":        c.Model,
+		"messages":     messages,
+		c.maxTokensKey: r.maxTokens(),
+	}
+	if r.Temperature != nil {
+		body["temperature"] = *r.Temperature
+	}
+	if r.TopP != nil {
+		body["top_p"] = *r.TopP
 	}
+	if len(r.Stop) > 0 {
+		body["stop"] = r.Stop
+	}
+	if r.Seed != nil {
+		body["seed"] = *r.Seed
+	}
+	if f := r.ResponseFormat; f != nil {
+		body["response_format"] = map[string]interface{}{
+			"type": "json_schema",
+			"json_schema": map[string]interface{}{
+				"name":   f.name(),
+				"schema": f.Schema,
+				"strict": false,
+			},
+		}
+	}
+	if len(r.Tools) > 0 {
+		tools := make([]map[string]interface{}, len(r.Tools))
+		for i, t := range r.Tools {
+			tools[i] = map[string]interface{}{
+				"type": "function",
+				"function": map[string]interface{}{
+					"name":        t.Name,
+					"description": t.Description,
+					"parameters":  t.toolParameters(),
+				},
+			}
+		}
+		body["tools"] = tools
+		switch r.ToolChoice {
+		case "":
+		case "auto", "none", "required":
+			body["tool_choice"] = r.ToolChoice
+		default:
+			body["tool_choice"] = map[string]interface{}{
+				"type":     "function",
+				"function": map[string]string{"name": r.ToolChoice},
+			}
+		}
+	}
+	return body
+}
+
+// openAIToolCall 은 응답의 tool_calls 항목입니다 (스트리밍 시에는 index 로 조각이 이어집니다).
+type openAIToolCall struct {
+	Index    int    `json:"index"`
+	ID       string `json:"id"`
+	Function struct {
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	} `json:"function"`
+}
 
+func (tc openAIToolCall) normalize() ToolCall {
+	return ToolCall{ID: tc.ID, Name: tc.Function.Name, Arguments: json.RawMessage(tc.Function.Arguments)}
+}
+
+func (c *OpenAIClient) newRequest(ctx context.Context, body map[string]interface{}) (*http.Request, error) {
 	b, _ := json.Marshal(body)
 	req, err := http.NewRequestWithContext(ctx, "POST",
-		"https://api.openai.com/v1/chat/completions", bytes.NewReader(b))
+		joinURL(c.BaseURL, "chat/completions"), bytes.NewReader(b))
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
-	req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	if err := c.setHeaders(req); err != nil {
+		return nil, err
+	}
 	req.Header.Set("Content-Type", "application/json")
+	return req, nil
+}
 
-	resp, err := c.httpc.Do(req)
+// setHeaders 는 인증 헤더와 추가 헤더를 붙입니다. 키는 처음 요청할 때 SecretProvider 에서 조회합니다.
+func (c *OpenAIClient) setHeaders(req *http.Request) error {
+	key, err := c.apiKey.get(req.Context())
 	if err != nil {
-		return "", err
+		return err
+	}
+	if key != "" {
+		v := key
+		if c.AuthScheme != "" {
+			v = c.AuthScheme + " " + v
+		}
+		req.Header.Set(c.AuthHeader, v)
 	}
-	defer resp.Body.Close()
+	for k, v := range c.Headers {
+		req.Header.Set(k, v)
+	}
+	return nil
+}
+
+// Complete 는 GenerateRequest 를 Chat Completions API 로 보냅니다.
+func (c *OpenAIClient) Complete(ctx context.Context, r GenerateRequest) (*Response, error) {
+	body := c.requestBody(r)
 
 	var decoded struct {
 		Choices []struct {
 			Message struct {
-				Content string `json:"content"`
+				Content   string           `json:"content"`
+				ToolCalls []openAIToolCall `json:"tool_calls"`
 			} `json:"message"`
+			FinishReason string `json:"finish_reason"`
 		} `json:"choices"`
+		Usage struct {
+			PromptTokens        int `json:"prompt_tokens"`
+			CompletionTokens    int `json:"completion_tokens"`
+			PromptTokensDetails struct {
+				CachedTokens int `json:"cached_tokens"`
+			} `json:"prompt_tokens_details"`
+		} `json:"usage"`
 	}
 
-	json.NewDecoder(resp.Body).Decode(&decoded)
+	stats, err := doJSON(ctx, c.httpc, c.Retry, c.provider, func() (*http.Request, error) {
+		return c.newRequest(ctx, body)
+	}, &decoded)
+	if err != nil {
+		return nil, err
+	}
 	if len(decoded.Choices) == 0 {
-		return "", errors.New("no choices found")
+		return nil, errEmptyResponse(c.provider, "no choices found")
+	}
+	var calls []ToolCall
+	for _, tc := range decoded.Choices[0].Message.ToolCalls {
+		calls = append(calls, tc.normalize())
+	}
+	return &Response{
+		Text:         decoded.Choices[0].Message.Content,
+		Model:        c.Model,
+		FinishReason: decoded.Choices[0].FinishReason,
+		ToolCalls:    calls,
+		Usage: Usage{
+			InputTokens:  decoded.Usage.PromptTokens,
+			OutputTokens: decoded.Usage.CompletionTokens,
+			CachedTokens: decoded.Usage.PromptTokensDetails.CachedTokens,
+		},
+		Attempts:      stats.Attempts,
+		RetriedErrors: stats.Retried,
+	}, nil
+}
+
+// Stream 은 Chat Completions SSE 스트림(stream=true)으로 토큰을 전달합니다.
+// stream_options.include_usage 로 마지막 청크에 usage 가 포함됩니다.
+func (c *OpenAIClient) Stream(ctx context.Context, r GenerateRequest) (<-chan StreamEvent, error) {
+	body := c.requestBody(r)
+	body["stream"] = true
+	body["stream_options"] = map[string]bool{"include_usage": true}
+
+	resp, stats, err := doWithRetry(ctx, streamingHTTPClient(c.httpc), c.Retry, c.provider, func() (*http.Request, error) {
+		req, err := c.newRequest(ctx, body)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Accept", "text/event-stream")
+		return req, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan StreamEvent)
+	go func() {
+		defer close(ch)
+		defer resp.Body.Close()
+
+		var usage *Usage
+		var streamErr error
+		var calls []openAIToolCall // index 별로 이어 붙인 도구 호출
+		err := readSSE(resp.Body, func(ev sseEvent) bool {
+			if ev.Data == "[DONE]" {
+				return false
+			}
+			var chunk struct {
+				Choices []struct {
+					Delta struct {
+						Content   string           `json:"content"`
+						ToolCalls []openAIToolCall `json:"tool_calls"`
+					} `json:"delta"`
+				} `json:"choices"`
+				Usage *struct {
+					PromptTokens        int `json:"prompt_tokens"`
+					CompletionTokens    int `json:"completion_tokens"`
+					PromptTokensDetails struct {
+						CachedTokens int `json:"cached_tokens"`
+					} `json:"prompt_tokens_details"`
+				} `json:"usage"`
+			}
+			if err := json.Unmarshal([]byte(ev.Data), &chunk); err != nil {
+				streamErr = err
+				return false
+			}
+			if chunk.Usage != nil {
+				usage = &Usage{
+					InputTokens:  chunk.Usage.PromptTokens,
+					OutputTokens: chunk.Usage.CompletionTokens,
+					CachedTokens: chunk.Usage.PromptTokensDetails.CachedTokens,
+				}
+			}
+			if len(chunk.Choices) == 0 {
+				return true
+			}
+			for _, part := range chunk.Choices[0].Delta.ToolCalls {
+				for len(calls) <= part.Index {
+					calls = append(calls, openAIToolCall{Index: len(calls)})
+				}
+				tc := &calls[part.Index]
+				if part.ID != "" {
+					tc.ID = part.ID
+				}
+				tc.Function.Name += part.Function.Name
+				tc.Function.Arguments += part.Function.Arguments
+			}
+			if chunk.Choices[0].Delta.Content != "" {
+				return emit(ctx, ch, StreamEvent{Delta: chunk.Choices[0].Delta.Content})
+			}
+			return true
+		})
+		if err == nil {
+			err = streamErr
+		}
+		if err != nil {
+			emit(ctx, ch, StreamEvent{Err: err})
+			return
+		}
+		var toolCalls []ToolCall
+		for _, tc := range calls {
+			toolCalls = append(toolCalls, tc.normalize())
+		}
+		emit(ctx, ch, StreamEvent{Done: true, Usage: usage, ToolCalls: toolCalls, Attempts: stats.Attempts, RetriedErrors: stats.Retried})
+	}()
+	return ch, nil
+}
+
+// ListModels 는 GET /models 로 서버가 제공하는 모델 목록을 조회합니다.
+func (c *OpenAIClient) ListModels(ctx context.Context) ([]ModelInfo, error) {
+	var decoded struct {
+		Data []struct {
+			ID      string `json:"id"`
+			OwnedBy string `json:"owned_by"`
+		} `json:"data"`
+	}
+	_, err := doJSON(ctx, c.httpc, c.Retry, c.provider, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "GET", joinURL(c.BaseURL, "models"), nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.setHeaders(req); err != nil {
+			return nil, err
+		}
+		return req, nil
+	}, &decoded)
+	if err != nil {
+		return nil, err
+	}
+	models := make([]ModelInfo, 0, len(decoded.Data))
+	for _, m := range decoded.Data {
+		models = append(models, ModelInfo{ID: m.ID, OwnedBy: m.OwnedBy})
 	}
-	return decoded.Choices[0].Message.Content, nil
+	return models, nil
 }
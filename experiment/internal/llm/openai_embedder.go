@@ -0,0 +1,91 @@
+// internal/llm/openai_embedder.go
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// OpenAIEmbedder 는 /embeddings API 용 Embedder 입니다.
+// 주소, 인증, 재시도는 Client(OpenAIClient) 설정을 그대로 씁니다 (OpenAI 호환 서버도 같은 방식).
+type OpenAIEmbedder struct {
+	Client    *OpenAIClient
+	BatchSize int // 요청 하나에 담는 텍스트 수 (0 이면 DefaultEmbedBatchSize)
+
+	dims embedDims
+}
+
+// NewOpenAIEmbedder 는 OpenAI 임베딩 모델용 Embedder 를 만듭니다.
+// dimensions 가 0 보다 크면 출력 차원을 줄여 달라고 요청합니다 (text-embedding-3-* 지원).
+// 예: NewOpenAIEmbedder("text-embedding-3-small", 0)
+func NewOpenAIEmbedder(model string, dimensions int) *OpenAIEmbedder {
+	return &OpenAIEmbedder{Client: NewOpenAIClient(model), dims: embedDims{configured: dimensions}}
+}
+
+// NewOpenAICompatibleEmbedder 는 OpenAI /embeddings 호환 서버(vLLM, LM Studio, Ollama 의 /v1 등)용 Embedder 를 만듭니다.
+func NewOpenAICompatibleEmbedder(baseURL, model string, dimensions int) *OpenAIEmbedder {
+	return &OpenAIEmbedder{Client: NewOpenAICompatibleClient(baseURL, model), dims: embedDims{configured: dimensions}}
+}
+
+func (e *OpenAIEmbedder) Name() string    { return e.Client.Model }
+func (e *OpenAIEmbedder) Dimensions() int { return e.dims.get() }
+
+// Embed 는 texts 를 BatchSize 개씩 /embeddings 로 보냅니다.
+func (e *OpenAIEmbedder) Embed(ctx context.Context, texts []string) (*Embeddings, error) {
+	return embedBatched(ctx, e.Client.provider, texts, e.BatchSize, &e.dims, e.embedBatch)
+}
+
+func (e *OpenAIEmbedder) embedBatch(ctx context.Context, batch []string) (*Embeddings, error) {
+	c := e.Client
+	body := map[string]interface{}{
+		"model":           c.Model,
+		"input":           batch,
+		"encoding_format": "float",
+	}
+	if e.dims.configured > 0 {
+		body["dimensions"] = e.dims.configured
+	}
+	b, _ := json.Marshal(body)
+
+	var decoded struct {
+		Model string `json:"model"`
+		Data  []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+		Usage struct {
+			PromptTokens int `json:"prompt_tokens"`
+		} `json:"usage"`
+	}
+	stats, err := doJSON(ctx, c.httpc, c.Retry, c.provider, func() (*http.Request, error) {
+		req, err := http.NewRequestWithContext(ctx, "POST", joinURL(c.BaseURL, "embeddings"), bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		return req, c.setHeaders(req)
+	}, &decoded)
+	if err != nil {
+		return nil, err
+	}
+
+	// data 는 index 순서가 보장되지 않습니다.
+	sort.Slice(decoded.Data, func(i, j int) bool { return decoded.Data[i].Index < decoded.Data[j].Index })
+	vectors := make([][]float32, len(decoded.Data))
+	for i, d := range decoded.Data {
+		vectors[i] = d.Embedding
+	}
+	model := decoded.Model
+	if model == "" {
+		model = c.Model
+	}
+	return &Embeddings{
+		Vectors:  vectors,
+		Model:    model,
+		Usage:    Usage{InputTokens: decoded.Usage.PromptTokens},
+		Attempts: stats.Attempts,
+	}, nil
+}
@@ -0,0 +1,43 @@
+// internal/llm/redact.go
+package llm
+
+import (
+	"os"
+	"regexp"
+	"strings"
+)
+
+// RedactedPlaceholder 는 가려진 비밀 값 자리에 들어가는 문자열입니다.
+const RedactedPlaceholder = "[REDACTED]"
+
+// secretPatterns 는 알려진 API 키 형식입니다. 첫 그룹은 키 앞의 경계이며 그대로 남깁니다
+// (경계가 없으면 "task-…", "risk-…" 같은 평범한 단어도 가려집니다).
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(^|[^A-Za-z0-9])sk-ant-[A-Za-z0-9_\-]{10,}`),
+	regexp.MustCompile(`(^|[^A-Za-z0-9])sk-(?:proj-)?[A-Za-z0-9_\-]{16,}`),
+	regexp.MustCompile(`(^|[^A-Za-z0-9])AIza[0-9A-Za-z_\-]{30,}`),
+}
+
+// secretQueryParam 은 URL 쿼리에 들어간 키(예: "?key=...")입니다.
+var secretQueryParam = regexp.MustCompile(`([?&](?:key|api_key)=)[^&\s"']+`)
+
+// secretEnvVars 는 값 자체를 가려야 하는 환경 변수 이름입니다.
+var secretEnvVars = []string{"OPENAI_API_KEY", "ANTHROPIC_API_KEY", "GEMINI_API_KEY"}
+
+// RedactSecrets 는 s 안의 API 키를 가립니다: SecretProvider 로 조회했거나 RegisterSecret 으로 등록한 값,
+// 키 환경 변수 값, 알려진 키 형식, URL 쿼리의 key=.
+// 프롬프트, 오류 메시지, 로그에 씁니다. 모델 출력은 바꾸면 재생 결과가 실제 호출과 달라지므로 가리지 않습니다.
+func RedactSecrets(s string) string {
+	for _, v := range registeredSecrets() {
+		s = strings.ReplaceAll(s, v, RedactedPlaceholder)
+	}
+	for _, name := range secretEnvVars {
+		if v := os.Getenv(name); len(v) >= 8 {
+			s = strings.ReplaceAll(s, v, RedactedPlaceholder)
+		}
+	}
+	for _, re := range secretPatterns {
+		s = re.ReplaceAllString(s, "${1}"+RedactedPlaceholder)
+	}
+	return secretQueryParam.ReplaceAllString(s, "${1}"+RedactedPlaceholder)
+}
@@ -0,0 +1,33 @@
+// internal/llm/redact_test.go
+package llm_test
+
+import (
+	"testing"
+
+	"speckit-study/internal/llm"
+)
+
+func TestRedactSecrets(t *testing.T) {
+	llm.RegisterSecret("vault-token-0123456789")
+	cases := []struct {
+		name, in, want string
+	}{
+		{"openai key", "key sk-abcdefghijklmnop0123 rejected", "key [REDACTED] rejected"},
+		{"project key", `"sk-proj-abcdefghijklmnop0123"`, `"[REDACTED]"`},
+		{"anthropic key", "x-api-key: sk-ant-api03-abcdefghij", "x-api-key: [REDACTED]"},
+		{"gemini key", "AIzaSyA0123456789abcdefghijklmnopqrstu", "[REDACTED]"},
+		{"query param", "GET /v1beta/models?key=abc123&alt=sse", "GET /v1beta/models?key=[REDACTED]&alt=sse"},
+		{"registered secret", "token vault-token-0123456789 expired", "token [REDACTED] expired"},
+		// 키 형식의 접두사가 단어 안에 있으면 가리지 않습니다.
+		{"task word", "task-retention-job-nightly-run", "task-retention-job-nightly-run"},
+		{"risk word", "risk-assessment-for-audit-log", "risk-assessment-for-audit-log"},
+		{"disk word", "disk-usage-threshold-exceeded", "disk-usage-threshold-exceeded"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := llm.RedactSecrets(c.in); got != c.want {
+				t.Errorf("RedactSecrets(%q) = %q, want %q", c.in, got, c.want)
+			}
+		})
+	}
+}
@@ -16,6 +16,10 @@ type LLMClient interface {
 // 예: "gpt", "claude", "gemini" 등의 태그를 사용.
 type ModelRegistry struct {
 	tagToClient map[string]LLMClient
+	aliases     map[string]string // alias → tag
+	embedders   map[string]Embedder
+	defaultTag  string
+	digest      string // 설정 파일 내용의 해시 (ConfigDigest)
 	mu          sync.RWMutex
 }
 
@@ -23,6 +27,7 @@ type ModelRegistry struct {
 func NewModelRegistry() *ModelRegistry {
 	return &ModelRegistry{
 		tagToClient: make(map[string]LLMClient),
+		aliases:     make(map[string]string),
 	}
 }
 
@@ -34,24 +39,72 @@ func (r *ModelRegistry) RegisterModel(tag string, client LLMClient) {
 	r.tagToClient[tag] = client
 }
 
-// GetModel은 태그로 모델을 조회합니다.
+// RegisterAlias는 기존 태그에 별칭을 붙입니다.
+// 예: reg.RegisterAlias("sonnet", "claude")
+func (r *ModelRegistry) RegisterAlias(alias, tag string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.aliases[alias] = tag
+}
+
+// SetDefault는 DefaultModel이 반환할 태그(또는 별칭)를 지정합니다.
+func (r *ModelRegistry) SetDefault(tag string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.defaultTag = tag
+}
+
+// DefaultTag는 기본 모델의 태그를 반환합니다 (SetDefault가 없으면 "default").
+func (r *ModelRegistry) DefaultTag() string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.defaultTag == "" {
+		return "default"
+	}
+	return r.resolveLocked(r.defaultTag)
+}
+
+// Resolve는 별칭을 실제 태그로 바꿉니다. 별칭이 아니면 그대로 반환합니다.
+func (r *ModelRegistry) Resolve(tag string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.resolveLocked(tag)
+}
+
+func (r *ModelRegistry) resolveLocked(tag string) string {
+	if _, ok := r.tagToClient[tag]; ok {
+		return tag
+	}
+	if t, ok := r.aliases[tag]; ok {
+		return t
+	}
+	return tag
+}
+
+// GetModel은 태그(또는 별칭)로 모델을 조회합니다.
 // 반환값: (LLMClient, 존재 여부)
 func (r *ModelRegistry) GetModel(tag string) (LLMClient, bool) {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
-	c, ok := r.tagToClient[tag]
+	c, ok := r.tagToClient[r.resolveLocked(tag)]
 	return c, ok
 }
 
+// WrapAll은 등록된 모든 클라이언트를 wrap 결과로 교체합니다.
+// 예: reg.WrapAll(cassette.Wrap) 로 모든 모델 호출을 기록/재생
+func (r *ModelRegistry) WrapAll(wrap func(tag string, c LLMClient) LLMClient) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for tag, c := range r.tagToClient {
+		r.tagToClient[tag] = wrap(tag, c)
+	}
+}
+
 // DefaultModel은 기본 모델을 반환합니다.
-// "default" 태그가 없으면 nil 반환.
+// SetDefault로 지정한 태그, 없으면 "default" 태그를 찾고, 둘 다 없으면 nil 반환.
 func (r *ModelRegistry) DefaultModel() LLMClient {
-	r.mu.RLock()
-	defer r.mu.RUnlock()
-	if c, ok := r.tagToClient["default"]; ok {
-		return c
-	}
-	return nil
+	c, _ := r.GetModel(r.DefaultTag())
+	return c
 }
 
 // MustGetModel은 필수 모델을 조회합니다.
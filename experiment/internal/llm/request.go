@@ -0,0 +1,180 @@
+// internal/llm/request.go
+package llm
+
+import (
+	"context"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultMaxTokens 는 GenerateRequest.MaxTokens 가 0 일 때 사용하는 출력 토큰 상한입니다.
+const DefaultMaxTokens = 2048
+
+// Role 은 대화 메시지의 화자입니다.
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	// RoleTool 은 도구 실행 결과를 모델에게 돌려주는 메시지입니다 (ToolCallID/ToolName 필수).
+	RoleTool Role = "tool"
+)
+
+// Message 는 대화 한 턴입니다.
+type Message struct {
+	Role    Role   `json:"role" yaml:"role"`
+	Content string `json:"content" yaml:"content"`
+
+	// ToolCalls 는 assistant 가 요청한 도구 호출입니다.
+	ToolCalls []ToolCall `json:"tool_calls,omitempty" yaml:"tool_calls,omitempty"`
+	// ToolCallID/ToolName 은 RoleTool 메시지가 어떤 호출의 결과인지 나타냅니다.
+	ToolCallID string `json:"tool_call_id,omitempty" yaml:"tool_call_id,omitempty"`
+	ToolName   string `json:"tool_name,omitempty" yaml:"tool_name,omitempty"`
+	// ToolError 는 도구 실행이 실패했음을 표시합니다 (Content 에 오류 메시지).
+	ToolError bool `json:"tool_error,omitempty" yaml:"tool_error,omitempty"`
+}
+
+// GenerateRequest 는 공급자 중립적인 생성 요청입니다.
+// 각 클라이언트가 자기 wire format 으로 변환하며, 지원하지 않는 항목(예: Anthropic 의 Seed)은 무시합니다.
+// 포인터 필드는 nil 이면 공급자 기본값을 사용합니다.
+type GenerateRequest struct {
+	System      string    `json:"system,omitempty" yaml:"system,omitempty"`
+	Messages    []Message `json:"messages" yaml:"messages"`
+	Temperature *float64  `json:"temperature,omitempty" yaml:"temperature,omitempty"`
+	TopP        *float64  `json:"top_p,omitempty" yaml:"top_p,omitempty"`
+	MaxTokens   int       `json:"max_tokens,omitempty" yaml:"max_tokens,omitempty"`
+	Stop        []string  `json:"stop,omitempty" yaml:"stop,omitempty"`
+	Seed        *int64    `json:"seed,omitempty" yaml:"seed,omitempty"`
+
+	// ResponseFormat 이 있으면 JSON Schema 를 따르는 JSON 출력을 요청합니다 (CompleteJSON 참고).
+	ResponseFormat *ResponseFormat `json:"response_format,omitempty" yaml:"response_format,omitempty"`
+
+	// Tools 는 모델이 호출할 수 있는 도구입니다 (RunTools 참고).
+	Tools []Tool `json:"tools,omitempty" yaml:"tools,omitempty"`
+	// ToolChoice 는 "auto"(기본), "none", "required" 또는 반드시 호출할 도구 이름입니다.
+	ToolChoice string `json:"tool_choice,omitempty" yaml:"tool_choice,omitempty"`
+}
+
+// PromptRequest 는 단일 user 메시지로 된 요청을 만듭니다.
+func PromptRequest(prompt string) GenerateRequest {
+	return GenerateRequest{Messages: []Message{{Role: RoleUser, Content: prompt}}}
+}
+
+// Response 는 한 번의 생성 결과입니다.
+// Attempts/RetriedErrors 는 재시도 정책에 따라 실제로 보낸 요청 수와 재시도를 유발한 오류입니다.
+type Response struct {
+	Text          string   `json:"text" yaml:"text"`
+	Model         string   `json:"model" yaml:"model"`
+	FinishReason  string   `json:"finish_reason,omitempty" yaml:"finish_reason,omitempty"`
+	Usage         Usage    `json:"usage" yaml:"usage"`
+	Attempts      int      `json:"attempts,omitempty" yaml:"attempts,omitempty"`
+	RetriedErrors []string `json:"retried_errors,omitempty" yaml:"retried_errors,omitempty"`
+	// ToolCalls 는 모델이 요청한 도구 호출입니다 (있으면 Text 는 비어 있을 수 있음).
+	ToolCalls []ToolCall `json:"tool_calls,omitempty" yaml:"tool_calls,omitempty"`
+	// Latency 는 Timing 미들웨어가 잰 호출 시간입니다 (QueueWait 포함).
+	Latency time.Duration `json:"latency,omitempty" yaml:"latency,omitempty"`
+	// QueueWait 는 호출 전에 rate limiter(LimitedClient) 에서 기다린 시간입니다.
+	QueueWait time.Duration `json:"queue_wait,omitempty" yaml:"queue_wait,omitempty"`
+	// Repairs 는 CompleteJSON 이 스키마 오류로 다시 요청한 횟수입니다.
+	Repairs int `json:"repairs,omitempty" yaml:"repairs,omitempty"`
+	// Cached 는 ResponseCache 에서 꺼낸 응답임을 나타냅니다 (Usage 는 처음 호출 때의 값, 다시 과금되지 않음).
+	Cached bool `json:"cached,omitempty" yaml:"cached,omitempty"`
+
+	// Tag 는 Fallback/Router 가 실제로 응답한 레지스트리 태그를 기록합니다.
+	// FallbackErrors 는 그 전에 건너뛴 태그와 오류입니다 ("tag: error").
+	Tag            string   `json:"tag,omitempty" yaml:"tag,omitempty"`
+	FallbackErrors []string `json:"fallback_errors,omitempty" yaml:"fallback_errors,omitempty"`
+}
+
+// Completer 는 GenerateRequest 를 직접 처리할 수 있는 클라이언트가 구현합니다.
+type Completer interface {
+	Complete(ctx context.Context, req GenerateRequest) (*Response, error)
+}
+
+// Complete 는 client 가 Completer 를 구현하면 그대로 호출하고,
+// 아니면 요청을 하나의 프롬프트 텍스트로 펼쳐 Generate 로 처리합니다.
+func Complete(ctx context.Context, client LLMClient, req GenerateRequest) (*Response, error) {
+	if c, ok := client.(Completer); ok {
+		return c.Complete(ctx, req)
+	}
+	out, err := client.Generate(ctx, req.Prompt())
+	if err != nil {
+		return nil, err
+	}
+	return &Response{Text: out, Model: client.Name()}, nil
+}
+
+// Prompt 는 요청 전체를 단일 텍스트 프롬프트로 펼칩니다 (Completer 미구현 클라이언트용).
+func (r GenerateRequest) Prompt() string {
+	msgs := r.ChatMessages()
+	sys := r.SystemText()
+	if sys == "" && len(msgs) == 1 && msgs[0].Role == RoleUser {
+		return msgs[0].Content
+	}
+	var sb strings.Builder
+	if sys != "" {
+		sb.WriteString("# System\n")
+		sb.WriteString(sys)
+		sb.WriteString("\n\n")
+	}
+	for _, m := range msgs {
+		if len(msgs) > 1 {
+			sb.WriteString("# " + strings.ToUpper(string(m.Role[:1])) + string(m.Role[1:]) + "\n")
+		}
+		sb.WriteString(m.Content)
+		sb.WriteString("\n\n")
+	}
+	return strings.TrimRight(sb.String(), "\n")
+}
+
+// SystemText 는 System 필드와 RoleSystem 메시지를 합친 시스템 프롬프트입니다.
+func (r GenerateRequest) SystemText() string {
+	parts := make([]string, 0, 1)
+	if s := strings.TrimSpace(r.System); s != "" {
+		parts = append(parts, s)
+	}
+	for _, m := range r.Messages {
+		if m.Role == RoleSystem && strings.TrimSpace(m.Content) != "" {
+			parts = append(parts, m.Content)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// ChatMessages 는 RoleSystem 을 제외한 대화 메시지를 순서대로 반환합니다.
+// Role 이 빈 0 값 Message 는 사용자 메시지로 보고 RoleUser 로 바꿉니다 (공급자는 빈 role 을 거부함).
+func (r GenerateRequest) ChatMessages() []Message {
+	out := make([]Message, 0, len(r.Messages))
+	for _, m := range r.Messages {
+		switch m.Role {
+		case RoleSystem:
+			continue
+		case "":
+			m.Role = RoleUser
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+func (r GenerateRequest) maxTokens() int {
+	if r.MaxTokens > 0 {
+		return r.MaxTokens
+	}
+	return DefaultMaxTokens
+}
+
+// joinURL 은 base 뒤에 경로 조각을 '/' 로 이어 붙입니다 (base 끝의 '/' 는 무시).
+func joinURL(base string, parts ...string) string {
+	return strings.TrimRight(base, "/") + "/" + strings.Join(parts, "/")
+}
+
+// envOr 는 환경 변수 key 가 비어 있으면 def 를 반환합니다.
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,35 @@
+// internal/llm/request_test.go
+package llm_test
+
+import (
+	"testing"
+
+	"speckit-study/internal/llm"
+)
+
+func TestGenerateRequestPrompt(t *testing.T) {
+	cases := []struct {
+		name string
+		req  llm.GenerateRequest
+		want string
+	}{
+		{"single user message", llm.PromptRequest("hello"), "hello"},
+		{"zero-value role alone", llm.GenerateRequest{Messages: []llm.Message{{Content: "hello"}}}, "hello"},
+		{
+			"system and turns",
+			llm.GenerateRequest{System: "be brief", Messages: []llm.Message{
+				{Role: llm.RoleUser, Content: "q1"},
+				{Role: llm.RoleAssistant, Content: "a1"},
+				{Content: "q2"}, // Role 이 비면 사용자 메시지
+			}},
+			"# System\nbe brief\n\n# User\nq1\n\n# Assistant\na1\n\n# User\nq2",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := c.req.Prompt(); got != c.want {
+				t.Errorf("Prompt() = %q, want %q", got, c.want)
+			}
+		})
+	}
+}
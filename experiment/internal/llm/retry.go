@@ -0,0 +1,171 @@
+// internal/llm/retry.go
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RetryPolicy 는 재시도 가능한 오류(429, 500/502/503/504, Anthropic 529, 타임아웃과 연결 오류)에 대한 지수 백오프 설정입니다.
+type RetryPolicy struct {
+	MaxAttempts int           // 첫 시도를 포함한 최대 시도 횟수 (1 이하면 재시도 없음)
+	BaseDelay   time.Duration // 첫 재시도 전 대기 시간, 이후 2배씩 증가
+	MaxDelay    time.Duration // 지수 백오프 대기 시간 상한
+	Jitter      float64       // 0~1, 대기 시간에 ±Jitter 비율의 무작위 편차를 줍니다
+	// MaxRetryAfter 는 서버 Retry-After 를 그대로 기다릴 상한입니다 (0 이면 MaxDelay).
+	// 서버가 이보다 오래 기다리라고 하면 일찍 재시도해 시도 횟수를 낭비하지 않고 그 오류를 바로 반환합니다.
+	MaxRetryAfter time.Duration
+}
+
+// DefaultRetryPolicy 는 클라이언트 생성자가 설정하는 기본 재시도 정책입니다.
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    20 * time.Second,
+	Jitter:      0.2,
+	// 429 가 흔히 요구하는 1분 대기까지는 기다립니다.
+	MaxRetryAfter: 90 * time.Second,
+}
+
+// NoRetry 는 재시도를 하지 않는 정책입니다.
+var NoRetry = RetryPolicy{MaxAttempts: 1}
+
+// Backoff 는 attempt 번째(1부터) 시도가 실패한 뒤 기다릴 시간을 계산합니다.
+// retryAfter 가 주어지면 지수 백오프 대신 그 값을 MaxDelay 로 자르지 않고 그대로 씁니다
+// (RetryAfterLimit 을 넘는 값은 doWithRetry 가 재시도하지 않습니다).
+func (p RetryPolicy) Backoff(attempt int, retryAfter time.Duration) time.Duration {
+	if retryAfter > 0 {
+		return retryAfter
+	}
+	d := p.BaseDelay << (attempt - 1)
+	if p.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * p.Jitter * float64(d))
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	if d < 0 {
+		d = 0
+	}
+	return d
+}
+
+// RetryAfterLimit 은 Retry-After 를 기다려 줄 최대 시간입니다 (MaxRetryAfter, 없으면 MaxDelay, 둘 다 0 이면 제한 없음).
+func (p RetryPolicy) RetryAfterLimit() time.Duration {
+	if p.MaxRetryAfter > 0 {
+		return p.MaxRetryAfter
+	}
+	return p.MaxDelay
+}
+
+// RetryError 는 재시도 끝에 실패한 호출의 최종 오류와 시도 기록입니다.
+// errors.Is / errors.As 는 최종 오류(Err)로 전달됩니다.
+type RetryError struct {
+	Attempts int
+	Retried  []string
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return RedactSecrets(fmt.Sprintf("%v (after %d attempts)", e.Err, e.Attempts))
+}
+
+func (e *RetryError) Unwrap() error { return e.Err }
+
+// callStats 는 한 번의 논리적 호출에 대한 재시도 기록입니다.
+type callStats struct {
+	Attempts int
+	Retried  []string // 재시도를 유발한 오류 메시지 (시도 순)
+}
+
+// doWithRetry 는 newReq 로 만든 요청을 정책에 따라 재시도하며 보냅니다.
+// 2xx 응답이면 본문을 닫지 않고 그대로 반환하고, 그 외에는 ProviderError 를 반환합니다.
+func doWithRetry(
+	ctx context.Context,
+	httpc *http.Client,
+	policy RetryPolicy,
+	provider string,
+	newReq func() (*http.Request, error),
+) (*http.Response, callStats, error) {
+	var stats callStats
+	maxAttempts := max(policy.MaxAttempts, 1)
+
+	for {
+		stats.Attempts++
+		req, err := newReq()
+		if err != nil {
+			return nil, stats, err
+		}
+
+		var retryAfter time.Duration
+		resp, err := httpc.Do(req)
+		if err == nil && resp.StatusCode/100 == 2 {
+			return resp, stats, nil
+		}
+		var ue *url.Error
+		if errors.As(err, &ue) {
+			ue.URL = RedactSecrets(ue.URL) // 전송 오류 메시지에 URL 이 그대로 들어갑니다
+		}
+		if err == nil {
+			pe := parseProviderError(provider, resp)
+			resp.Body.Close()
+			err, retryAfter = pe, pe.RetryAfter
+		}
+
+		if ctx.Err() != nil {
+			return nil, stats, ctx.Err()
+		}
+		// 상한보다 긴 Retry-After 는 일찍 재시도해 봐야 또 거절되므로 바로 반환합니다.
+		tooLong := retryAfter > 0 && policy.RetryAfterLimit() > 0 && retryAfter > policy.RetryAfterLimit()
+		if stats.Attempts >= maxAttempts || !IsRetryable(err) || tooLong {
+			if stats.Attempts > 1 {
+				err = &RetryError{Attempts: stats.Attempts, Retried: stats.Retried, Err: err}
+			}
+			return nil, stats, err
+		}
+		stats.Retried = append(stats.Retried, RedactSecrets(err.Error()))
+
+		t := time.NewTimer(policy.Backoff(stats.Attempts, retryAfter))
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return nil, stats, ctx.Err()
+		case <-t.C:
+		}
+	}
+}
+
+// doJSON 은 doWithRetry 로 요청을 보내고 성공 응답 본문을 out 으로 디코드합니다.
+func doJSON(
+	ctx context.Context,
+	httpc *http.Client,
+	policy RetryPolicy,
+	provider string,
+	newReq func() (*http.Request, error),
+	out interface{},
+) (callStats, error) {
+	resp, stats, err := doWithRetry(ctx, httpc, policy, provider, newReq)
+	if err != nil {
+		return stats, err
+	}
+	defer resp.Body.Close()
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return stats, fmt.Errorf("%s: decode response: %w", provider, err)
+	}
+	return stats, nil
+}
+
+// errEmptyResponse 는 2xx 응답이지만 생성된 내용이 없을 때의 오류입니다.
+func errEmptyResponse(provider, what string) error {
+	return fmt.Errorf("%s: %w: %s", provider, ErrEmptyResponse, what)
+}
+
+// ErrEmptyResponse 는 공급자가 성공 응답을 줬지만 텍스트가 없을 때 반환됩니다.
+var ErrEmptyResponse = errors.New("empty response")
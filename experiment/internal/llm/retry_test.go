@@ -0,0 +1,199 @@
+// internal/llm/retry_test.go
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"speckit-study/internal/fakellm"
+	"speckit-study/internal/llm"
+)
+
+func TestBackoff(t *testing.T) {
+	p := llm.RetryPolicy{BaseDelay: 100 * time.Millisecond, MaxDelay: time.Second}
+	cases := []struct {
+		attempt    int
+		retryAfter time.Duration
+		want       time.Duration
+	}{
+		{1, 0, 100 * time.Millisecond},
+		{3, 0, 400 * time.Millisecond},
+		{10, 0, time.Second},                  // MaxDelay 로 자름
+		{1, 5 * time.Second, 5 * time.Second}, // Retry-After 는 MaxDelay 로 자르지 않음
+	}
+	for _, c := range cases {
+		if got := p.Backoff(c.attempt, c.retryAfter); got != c.want {
+			t.Errorf("Backoff(%d, %s) = %s, want %s", c.attempt, c.retryAfter, got, c.want)
+		}
+	}
+	if got := (llm.RetryPolicy{MaxDelay: time.Second}).RetryAfterLimit(); got != time.Second {
+		t.Errorf("RetryAfterLimit without MaxRetryAfter = %s, want MaxDelay", got)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	cases := []struct {
+		name       string
+		retryAfter time.Duration
+		calls      int
+		wantErr    bool
+	}{
+		{"honoured beyond MaxDelay", 50 * time.Millisecond, 2, false},
+		{"longer than the ceiling fails fast", time.Minute, 1, true},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv := fakellm.NewTestServer(fakellm.Script{
+				Rules:   []fakellm.Rule{{Times: 1, Reply: fakellm.Reply{Status: http.StatusTooManyRequests, RetryAfter: c.retryAfter}}},
+				Default: fakellm.Reply{Text: "ok"},
+			})
+			defer srv.Close()
+			client := llm.NewOpenAICompatibleClient(srv.OpenAIBaseURL(), "m")
+			client.Retry = llm.RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, MaxRetryAfter: time.Second}
+
+			start := time.Now()
+			_, err := client.Generate(context.Background(), "p")
+			elapsed := time.Since(start)
+			if got := len(srv.Fake.Calls()); got != c.calls {
+				t.Errorf("server saw %d calls, want %d", got, c.calls)
+			}
+			if !c.wantErr {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if elapsed < c.retryAfter {
+					t.Errorf("retried after %s, before the server's Retry-After %s", elapsed, c.retryAfter)
+				}
+				return
+			}
+			var pe *llm.ProviderError
+			if !errors.As(err, &pe) || pe.RetryAfter != c.retryAfter || !errors.Is(err, llm.ErrRateLimit) {
+				t.Fatalf("got %v, want the rate limit ProviderError with its Retry-After", err)
+			}
+			if elapsed > 500*time.Millisecond {
+				t.Errorf("waited %s before failing", elapsed)
+			}
+		})
+	}
+}
+
+// 분류에 따라 재시도 여부와 sentinel 비교가 정해지는지 확인합니다.
+func TestProviderErrorKinds(t *testing.T) {
+	cases := []struct {
+		provider  string
+		status    int
+		kind      llm.ErrorKind
+		sentinel  error
+		retryable bool
+	}{
+		{"openai", 429, llm.ErrorKindRateLimit, llm.ErrRateLimit, true},
+		{"openai", 503, llm.ErrorKindOverloaded, llm.ErrOverloaded, true},
+		{"anthropic", 529, llm.ErrorKindOverloaded, llm.ErrOverloaded, true},
+		{"openai-compatible", 529, llm.ErrorKindOverloaded, llm.ErrOverloaded, false},
+		{"openai", 500, llm.ErrorKindServer, llm.ErrServer, true},
+		{"gemini", 502, llm.ErrorKindServer, llm.ErrServer, true},
+		{"openai", 504, llm.ErrorKindServer, llm.ErrServer, true},
+		{"openai", 501, llm.ErrorKindServer, llm.ErrServer, false},
+		{"openai", 505, llm.ErrorKindServer, llm.ErrServer, false},
+		{"ollama", 200, llm.ErrorKindServer, llm.ErrServer, false}, // 스트림 안의 오류
+		{"openai", 401, llm.ErrorKindAuth, llm.ErrAuth, false},
+		{"openai", 400, llm.ErrorKindContextTooLong, llm.ErrContextTooLong, false},
+		{"openai", 400, llm.ErrorKindInvalidRequest, llm.ErrInvalidRequest, false},
+	}
+	for _, c := range cases {
+		err := fmt.Errorf("call: %w", &llm.ProviderError{Provider: c.provider, StatusCode: c.status, Kind: c.kind})
+		if !errors.Is(err, c.sentinel) || llm.IsRetryable(err) != c.retryable {
+			t.Errorf("%s %s %d: is sentinel %v, retryable %v, want %v",
+				c.provider, c.kind, c.status, errors.Is(err, c.sentinel), llm.IsRetryable(err), c.retryable)
+		}
+		if errors.Is(err, llm.ErrAuth) != (c.kind == llm.ErrorKindAuth) {
+			t.Errorf("%s matches ErrAuth", c.kind)
+		}
+	}
+	if llm.IsRetryable(errors.New("plain")) || llm.IsRetryable(nil) {
+		t.Error("plain errors are not retryable")
+	}
+	if !llm.IsRetryable(context.DeadlineExceeded) {
+		t.Error("timeouts are retryable")
+	}
+	if llm.IsRetryable(fmt.Errorf("call: %w", context.Canceled)) {
+		t.Error("cancellation is not retryable")
+	}
+}
+
+// 연결 거부·끊김은 재시도 대상입니다 (서버 재시작, 로드밸런서 교체 등).
+func TestNetworkErrorsRetryable(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close() // 아무도 듣지 않는 주소 → connection refused
+
+	_, dialErr := http.Get("http://" + addr)
+	if dialErr == nil {
+		t.Fatal("request to a closed listener succeeded")
+	}
+	cases := map[string]error{
+		"dial refused":  dialErr,
+		"op error":      &net.OpError{Op: "read", Net: "tcp", Err: errors.New("use of closed network connection")},
+		"ECONNREFUSED":  fmt.Errorf("call: %w", syscall.ECONNREFUSED),
+		"ECONNRESET":    &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET},
+		"wrapped reset": fmt.Errorf("stream: %w", &net.OpError{Op: "read", Net: "tcp", Err: &os.SyscallError{Syscall: "read", Err: syscall.ECONNRESET}}),
+	}
+	for name, err := range cases {
+		if !llm.IsRetryable(err) {
+			t.Errorf("%s: %v is not retryable", name, err)
+		}
+	}
+}
+
+// 공급자별 오류 본문이 공통 ErrorKind 와 sentinel 로 분류되는지 확인합니다.
+func TestProviderErrorClassification(t *testing.T) {
+	cases := []struct {
+		name      string
+		status    int
+		body      string
+		kind      llm.ErrorKind
+		sentinel  error
+		retryable bool
+	}{
+		{"openai context", 400, `{"error":{"message":"This model's maximum context length is 8192 tokens","type":"invalid_request_error","code":"context_length_exceeded"}}`, llm.ErrorKindContextTooLong, llm.ErrContextTooLong, false},
+		{"overloaded", 503, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, llm.ErrorKindOverloaded, llm.ErrOverloaded, true},
+		// 529 는 Anthropic 만 쓰는 상태라 다른 공급자에서는 재시도하지 않습니다.
+		{"529 outside anthropic", 529, `{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`, llm.ErrorKindOverloaded, llm.ErrOverloaded, false},
+		{"gemini quota", 429, `{"error":{"code":429,"message":"Quota exceeded","status":"RESOURCE_EXHAUSTED"}}`, llm.ErrorKindRateLimit, llm.ErrRateLimit, true},
+		{"auth", 401, `{"error":{"message":"Incorrect API key provided","type":"invalid_request_error"}}`, llm.ErrorKindAuth, llm.ErrAuth, false},
+		{"plain text 502", 502, `Bad Gateway`, llm.ErrorKindServer, llm.ErrServer, true},
+		{"not implemented", 501, `Not Implemented`, llm.ErrorKindServer, llm.ErrServer, false},
+		{"bad request", 400, `{"error":{"message":"messages: field required"}}`, llm.ErrorKindInvalidRequest, llm.ErrInvalidRequest, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(c.status)
+				w.Write([]byte(c.body))
+			}))
+			defer srv.Close()
+			client := llm.NewOpenAICompatibleClient(srv.URL+"/v1", "m")
+			client.Retry = llm.NoRetry
+
+			_, err := client.Generate(context.Background(), "p")
+			var pe *llm.ProviderError
+			if !errors.As(err, &pe) {
+				t.Fatalf("got %v, want a ProviderError", err)
+			}
+			if pe.Kind != c.kind || !errors.Is(err, c.sentinel) || llm.IsRetryable(err) != c.retryable {
+				t.Errorf("kind=%s retryable=%v, want %s retryable=%v (err %v)", pe.Kind, llm.IsRetryable(err), c.kind, c.retryable, err)
+			}
+		})
+	}
+}
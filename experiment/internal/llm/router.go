@@ -0,0 +1,154 @@
+// internal/llm/router.go
+package llm
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"unicode/utf8"
+)
+
+// CallInfo 는 호출의 맥락(어떤 태스크의 어떤 산출물인지)입니다.
+// RouterClient 의 규칙 매칭 등에 쓰이며 context 로 전달됩니다.
+type CallInfo struct {
+	Task     string // 예: "basic_test", "specgen"
+	Artifact string // 예: "plan.md", "tasks.yaml"
+}
+
+type callInfoKey struct{}
+
+// WithCallInfo 는 ctx 에 호출 맥락을 붙입니다.
+func WithCallInfo(ctx context.Context, info CallInfo) context.Context {
+	return context.WithValue(ctx, callInfoKey{}, info)
+}
+
+// CallInfoFrom 은 ctx 에 붙은 호출 맥락을 꺼냅니다 (없으면 빈 값).
+func CallInfoFrom(ctx context.Context) CallInfo {
+	info, _ := ctx.Value(callInfoKey{}).(CallInfo)
+	return info
+}
+
+// RouteRule 은 조건에 맞는 호출을 Tags 로 보내는 규칙입니다.
+// 빈 조건은 검사하지 않으며, Tags 가 여러 개면 순서대로 fallback 합니다.
+type RouteRule struct {
+	Name           string   `yaml:"name"`
+	Tags           []string `yaml:"tags"`
+	Task           string   `yaml:"task"`             // CallInfo.Task 에 대한 glob (path.Match)
+	Artifact       string   `yaml:"artifact"`         // CallInfo.Artifact 에 대한 glob
+	MinPromptChars int      `yaml:"min_prompt_chars"` // 프롬프트(시스템 포함) 글자 수 하한
+	MaxPromptChars int      `yaml:"max_prompt_chars"` // 상한 (0 = 제한 없음)
+	MaxCostUSD     float64  `yaml:"max_cost_usd"`     // 호출당 예상 비용 상한 (0 = 제한 없음)
+}
+
+// RouterClient 는 규칙에 따라 호출마다 모델을 고르는 복합 클라이언트입니다.
+// 규칙은 위에서부터 검사하며, 맞는 규칙이 없으면 Default 태그를 사용합니다.
+type RouterClient struct {
+	Rules   []RouteRule
+	Default string
+	Prices  PriceTable // MaxCostUSD 계산용
+
+	reg *ModelRegistry
+}
+
+// NewRouterClient 는 reg 의 모델 중에서 규칙으로 고르는 클라이언트를 만듭니다.
+func NewRouterClient(reg *ModelRegistry, def string, rules ...RouteRule) *RouterClient {
+	return &RouterClient{Rules: rules, Default: def, reg: reg}
+}
+
+func (r *RouterClient) Name() string { return "router(default=" + r.Default + ")" }
+
+func (r *RouterClient) Generate(ctx context.Context, prompt string) (string, error) {
+	resp, err := r.Complete(ctx, PromptRequest(prompt))
+	if err != nil {
+		return "", err
+	}
+	return resp.Text, nil
+}
+
+func (r *RouterClient) Complete(ctx context.Context, req GenerateRequest) (*Response, error) {
+	client, _, err := r.Route(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return client.Complete(ctx, req)
+}
+
+func (r *RouterClient) Stream(ctx context.Context, req GenerateRequest) (<-chan StreamEvent, error) {
+	client, _, err := r.Route(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return client.Stream(ctx, req)
+}
+
+// Route 는 req 에 맞는 규칙을 찾아 그 태그 체인으로 된 FallbackClient 와 규칙 이름을 반환합니다.
+func (r *RouterClient) Route(ctx context.Context, req GenerateRequest) (*FallbackClient, string, error) {
+	info := CallInfoFrom(ctx)
+	size := utf8.RuneCountInString(req.Prompt())
+	for _, rule := range r.Rules {
+		if !rule.matches(info, size) {
+			continue
+		}
+		tags := r.affordable(rule, req, size)
+		if len(tags) == 0 {
+			continue
+		}
+		name := rule.Name
+		if name == "" {
+			name = strings.Join(rule.Tags, ",")
+		}
+		return NewFallbackClient(r.reg, tags...), name, nil
+	}
+	if r.Default == "" {
+		return nil, "", fmt.Errorf("router: no rule matched (task=%q artifact=%q, %d chars) and no default", info.Task, info.Artifact, size)
+	}
+	return NewFallbackClient(r.reg, r.Default), "default", nil
+}
+
+func (rule RouteRule) matches(info CallInfo, size int) bool {
+	if rule.Task != "" {
+		if ok, _ := path.Match(rule.Task, info.Task); !ok {
+			return false
+		}
+	}
+	if rule.Artifact != "" {
+		if ok, _ := path.Match(rule.Artifact, info.Artifact); !ok {
+			return false
+		}
+	}
+	if rule.MinPromptChars > 0 && size < rule.MinPromptChars {
+		return false
+	}
+	if rule.MaxPromptChars > 0 && size > rule.MaxPromptChars {
+		return false
+	}
+	return true
+}
+
+// affordable 은 규칙의 태그 중 예상 비용이 MaxCostUSD 이하인 것만 남깁니다.
+// 가격을 모르는 모델은 상한을 확인할 수 없으므로 제외합니다.
+func (r *RouterClient) affordable(rule RouteRule, req GenerateRequest, size int) []string {
+	if rule.MaxCostUSD <= 0 {
+		return rule.Tags
+	}
+	est := EstimateUsage(req, size)
+	var tags []string
+	for _, tag := range rule.Tags {
+		client, ok := r.reg.GetModel(tag)
+		if !ok {
+			continue
+		}
+		p, ok := r.Prices.Lookup(r.reg.Resolve(tag), client.Name())
+		if ok && p.Cost(est) <= rule.MaxCostUSD {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// EstimateUsage 는 호출 전에 최악의 토큰 사용량을 대략 추정합니다.
+// 입력은 4글자당 1토큰, 출력은 MaxTokens(없으면 DefaultMaxTokens) 전부를 쓴다고 가정합니다.
+func EstimateUsage(req GenerateRequest, promptChars int) Usage {
+	return Usage{InputTokens: promptChars/4 + 1, OutputTokens: req.maxTokens()}
+}
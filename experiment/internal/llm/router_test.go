@@ -0,0 +1,69 @@
+// internal/llm/router_test.go
+package llm_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"speckit-study/internal/llm"
+)
+
+func TestRouterRules(t *testing.T) {
+	reg := llm.NewModelRegistry()
+	for _, tag := range []string{"cheap", "big", "pricey", "unpriced"} {
+		reg.RegisterModel(tag, &stubClient{name: tag, replies: []stubReply{{text: "from " + tag}}})
+	}
+	reg.RegisterModel("down", &stubClient{name: "down", replies: []stubReply{{err: &llm.ProviderError{StatusCode: 503, Kind: llm.ErrorKindServer}}}})
+
+	router := llm.NewRouterClient(reg, "cheap",
+		llm.RouteRule{Name: "plans", Task: "api_*", Artifact: "plan.md", Tags: []string{"down", "big"}},
+		llm.RouteRule{Name: "long", MinPromptChars: 100, Tags: []string{"pricey", "unpriced", "big"}, MaxCostUSD: 0.01},
+	)
+	router.Prices = llm.PriceTable{
+		"pricey": {InputPerMTok: 1000, OutputPerMTok: 1000},
+		"big":    {InputPerMTok: 1, OutputPerMTok: 1},
+	}
+
+	long := strings.Repeat("x", 200)
+	cases := []struct {
+		name   string
+		info   llm.CallInfo
+		prompt string
+		rule   string
+		text   string
+	}{
+		{"glob match falls back within the rule", llm.CallInfo{Task: "api_design", Artifact: "plan.md"}, "p", "plans", "from big"},
+		{"artifact mismatch", llm.CallInfo{Task: "api_design", Artifact: "tasks.md"}, "p", "default", "from cheap"},
+		// pricey 는 상한 초과, unpriced 는 가격을 몰라 제외됩니다.
+		{"cost cap drops tags", llm.CallInfo{}, long, "long", "from big"},
+		{"short prompt", llm.CallInfo{}, "p", "default", "from cheap"},
+		// 한글 50자는 150바이트지만 길이 조건은 글자 수로 셉니다.
+		{"multibyte prompt counts characters", llm.CallInfo{}, strings.Repeat("가", 50), "default", "from cheap"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			ctx := llm.WithCallInfo(context.Background(), c.info)
+			req := llm.PromptRequest(c.prompt)
+			_, rule, err := router.Route(ctx, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rule != c.rule {
+				t.Errorf("rule = %q, want %q", rule, c.rule)
+			}
+			resp, err := llm.Complete(ctx, router, req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Text != c.text {
+				t.Errorf("text = %q, want %q", resp.Text, c.text)
+			}
+		})
+	}
+
+	router.Default = ""
+	if _, _, err := router.Route(context.Background(), llm.PromptRequest("p")); err == nil {
+		t.Error("no rule and no default: want an error")
+	}
+}
@@ -0,0 +1,269 @@
+// internal/llm/schema.go
+package llm
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Schema 는 JSON Schema 문서입니다 (json.Unmarshal 한 map 을 그대로 써도 됩니다).
+// Validate 는 구조화 출력 검증에 필요한 부분만 지원합니다:
+// type, properties, required, additionalProperties, items, enum, minItems, maxItems, minLength, minimum, maximum.
+type Schema map[string]interface{}
+
+// SchemaFor 는 Go 값의 타입으로 JSON Schema 를 만듭니다.
+// 필드 이름은 json 태그를 따르고, omitempty 가 없는 필드는 required 입니다.
+// desc 태그는 description 으로 들어갑니다.
+//
+//	type Task struct {
+//		Name string `json:"name" desc:"snake_case identifier"`
+//	}
+func SchemaFor(v interface{}) Schema {
+	return schemaForType(reflect.TypeOf(v), map[reflect.Type]bool{})
+}
+
+func schemaForType(t reflect.Type, seen map[reflect.Type]bool) Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return Schema{"type": "array", "items": schemaForType(t.Elem(), seen)}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": schemaForType(t.Elem(), seen)}
+	case reflect.Struct:
+		if seen[t] {
+			return Schema{"type": "object"} // 재귀 타입은 한 단계에서 멈춥니다
+		}
+		seen[t] = true
+		defer delete(seen, t)
+
+		props := map[string]interface{}{}
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			fs := schemaForType(f.Type, seen)
+			if d := f.Tag.Get("desc"); d != "" {
+				fs["description"] = d
+			}
+			props[name] = fs
+			if !strings.Contains(opts, "omitempty") {
+				required = append(required, name)
+			}
+		}
+		s := Schema{"type": "object", "properties": props, "additionalProperties": false}
+		if len(required) > 0 {
+			s["required"] = required
+		}
+		return s
+	default:
+		return Schema{} // interface{} 등: 제약 없음
+	}
+}
+
+// Validate 는 v(json.Unmarshal 결과)가 스키마를 따르는지 검사하고 문제 목록을 반환합니다.
+// 문제는 "$.tasks[0].name: ..." 처럼 JSON 경로와 함께 기록됩니다.
+func (s Schema) Validate(v interface{}) []string {
+	var problems []string
+	validate(s, v, "$", &problems)
+	return problems
+}
+
+// ValidateJSON 은 data 를 파싱해 스키마 검사까지 한 결과입니다.
+func (s Schema) ValidateJSON(data []byte) []string {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		return []string{"invalid JSON: " + err.Error()}
+	}
+	return s.Validate(v)
+}
+
+func validate(s Schema, v interface{}, path string, problems *[]string) {
+	fail := func(format string, args ...interface{}) {
+		*problems = append(*problems, path+": "+fmt.Sprintf(format, args...))
+	}
+
+	if types := schemaTypes(s["type"]); len(types) > 0 {
+		got := jsonType(v)
+		ok := false
+		for _, t := range types {
+			if t == got || (t == "number" && got == "integer") {
+				ok = true
+			}
+		}
+		if !ok {
+			fail("expected %s, got %s", strings.Join(types, " or "), got)
+			return
+		}
+	}
+	if enum, ok := s["enum"].([]interface{}); ok && !containsJSON(enum, v) {
+		fail("must be one of %s", compactJSON(enum))
+	}
+	if enum, ok := s["enum"].([]string); ok && !containsJSON(stringsToAny(enum), v) {
+		fail("must be one of %s", compactJSON(enum))
+	}
+
+	switch x := v.(type) {
+	case string:
+		if n, ok := number(s["minLength"]); ok && float64(len([]rune(x))) < n {
+			fail("must be at least %v characters", n)
+		}
+	case float64:
+		if n, ok := number(s["minimum"]); ok && x < n {
+			fail("must be >= %v", n)
+		}
+		if n, ok := number(s["maximum"]); ok && x > n {
+			fail("must be <= %v", n)
+		}
+	case []interface{}:
+		if n, ok := number(s["minItems"]); ok && float64(len(x)) < n {
+			fail("must have at least %v items", n)
+		}
+		if n, ok := number(s["maxItems"]); ok && float64(len(x)) > n {
+			fail("must have at most %v items", n)
+		}
+		if items, ok := asSchema(s["items"]); ok {
+			for i, item := range x {
+				validate(items, item, fmt.Sprintf("%s[%d]", path, i), problems)
+			}
+		}
+	case map[string]interface{}:
+		props, _ := asSchema(s["properties"])
+		for _, name := range requiredNames(s["required"]) {
+			if _, ok := x[name]; !ok {
+				fail("missing required property %q", name)
+			}
+		}
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if ps, ok := asSchema(props[k]); ok {
+				validate(ps, x[k], path+"."+k, problems)
+				continue
+			}
+			switch extra := s["additionalProperties"].(type) {
+			case bool:
+				if !extra {
+					fail("unexpected property %q", k)
+				}
+			default:
+				if es, ok := asSchema(extra); ok {
+					validate(es, x[k], path+"."+k, problems)
+				}
+			}
+		}
+	}
+}
+
+// asSchema 는 Schema 와 map[string]interface{} 를 모두 Schema 로 받습니다.
+func asSchema(v interface{}) (Schema, bool) {
+	switch s := v.(type) {
+	case Schema:
+		return s, true
+	case map[string]interface{}:
+		return Schema(s), true
+	}
+	return nil, false
+}
+
+func schemaTypes(v interface{}) []string {
+	switch t := v.(type) {
+	case string:
+		return []string{t}
+	case []string:
+		return t
+	case []interface{}:
+		out := make([]string, 0, len(t))
+		for _, x := range t {
+			if s, ok := x.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func requiredNames(v interface{}) []string { return schemaTypes(v) }
+
+func jsonType(v interface{}) string {
+	switch x := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if x == math.Trunc(x) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func number(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func containsJSON(list []interface{}, v interface{}) bool {
+	want := compactJSON(v)
+	for _, x := range list {
+		if compactJSON(x) == want {
+			return true
+		}
+	}
+	return false
+}
+
+func stringsToAny(ss []string) []interface{} {
+	out := make([]interface{}, len(ss))
+	for i, s := range ss {
+		out[i] = s
+	}
+	return out
+}
+
+func compactJSON(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
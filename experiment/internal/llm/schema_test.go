@@ -0,0 +1,135 @@
+// internal/llm/schema_test.go
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"speckit-study/internal/llm"
+)
+
+var taskSchema = llm.Schema{
+	"type":                 "object",
+	"required":             []interface{}{"name", "priority"},
+	"additionalProperties": false,
+	"properties": map[string]interface{}{
+		"name":     map[string]interface{}{"type": "string", "minLength": 3},
+		"priority": map[string]interface{}{"type": "integer", "minimum": 1, "maximum": 3},
+		"kind":     map[string]interface{}{"enum": []interface{}{"doc", "code"}},
+		"tags": map[string]interface{}{
+			"type": "array", "minItems": 1, "maxItems": 2,
+			"items": map[string]interface{}{"type": "string"},
+		},
+	},
+}
+
+func TestSchemaValidate(t *testing.T) {
+	cases := []struct {
+		name string
+		json string
+		want []string
+	}{
+		{"valid", `{"name":"api","priority":2,"kind":"doc","tags":["a"]}`, nil},
+		{"not JSON", `{"name":`, []string{"invalid JSON"}},
+		{"wrong root type", `[]`, []string{"$: expected object, got array"}},
+		{"missing required", `{"name":"api"}`, []string{`$: missing required property "priority"`}},
+		{"extra property", `{"name":"api","priority":1,"owner":"x"}`, []string{`$: unexpected property "owner"`}},
+		{"min length", `{"name":"a","priority":1}`, []string{"$.name: must be at least 3 characters"}},
+		{"range", `{"name":"api","priority":5}`, []string{"$.priority: must be <= 3"}},
+		{"integer type", `{"name":"api","priority":1.5}`, []string{"$.priority: expected integer, got number"}},
+		{"enum", `{"name":"api","priority":1,"kind":"test"}`, []string{`$.kind: must be one of ["doc","code"]`}},
+		{"array bounds and items", `{"name":"api","priority":1,"tags":["a",2,"c"]}`, []string{
+			"$.tags: must have at most 2 items", "$.tags[1]: expected string, got integer",
+		}},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			got := taskSchema.ValidateJSON([]byte(c.json))
+			if len(got) != len(c.want) {
+				t.Fatalf("problems = %q, want %q", got, c.want)
+			}
+			for i := range got {
+				if !strings.HasPrefix(got[i], c.want[i]) {
+					t.Errorf("problem %d = %q, want prefix %q", i, got[i], c.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	cases := []struct{ name, in, want string }{
+		{"plain", ` {"a":1} `, `{"a":1}`},
+		{"fenced", "```json\n{\"a\":1}\n```", `{"a":1}`},
+		{"fence without language", "```\n[1,2]\n```", `[1,2]`},
+		{"surrounding prose", `Here it is: {"a":{"b":2}} hope that helps`, `{"a":{"b":2}}`},
+		{"array in prose", `Result: [1, 2] done`, `[1, 2]`},
+		{"no JSON", `no json here`, `no json here`},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := llm.ExtractJSON(c.in); got != c.want {
+				t.Errorf("ExtractJSON(%q) = %q, want %q", c.in, got, c.want)
+			}
+		})
+	}
+}
+
+func TestSchemaFor(t *testing.T) {
+	type step struct {
+		Title string `json:"title" desc:"short title"`
+		Notes string `json:"notes,omitempty"`
+	}
+	type plan struct {
+		Name   string         `json:"name"`
+		Steps  []step         `json:"steps"`
+		Labels map[string]int `json:"labels,omitempty"`
+		Ready  *bool          `json:"ready"`
+		Score  float64        `json:"score"`
+		Hidden string         `json:"-"`
+		secret string
+	}
+	got := llm.SchemaFor(plan{})
+	want := llm.Schema{
+		"type": "object", "additionalProperties": false,
+		"required": []string{"name", "steps", "ready", "score"},
+		"properties": map[string]interface{}{
+			"name": llm.Schema{"type": "string"},
+			"steps": llm.Schema{"type": "array", "items": llm.Schema{
+				"type": "object", "additionalProperties": false,
+				"required": []string{"title"},
+				"properties": map[string]interface{}{
+					"title": llm.Schema{"type": "string", "description": "short title"},
+					"notes": llm.Schema{"type": "string"},
+				},
+			}},
+			"labels": llm.Schema{"type": "object", "additionalProperties": llm.Schema{"type": "integer"}},
+			"ready":  llm.Schema{"type": "boolean"},
+			"score":  llm.Schema{"type": "number"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SchemaFor(plan{}) =\n%v\nwant\n%v", got, want)
+	}
+	// 만든 스키마로 검증이 돌아가는지 함께 확인합니다.
+	if problems := got.ValidateJSON([]byte(`{"name":"p","steps":[{"title":"t"}],"ready":true,"score":0.5}`)); len(problems) > 0 {
+		t.Errorf("valid document rejected: %q", problems)
+	}
+}
+
+// maxRepairs 가 음수여도 한 번은 호출하고 (panic 없이) 스키마 오류를 돌려줍니다.
+func TestCompleteJSONNegativeRepairs(t *testing.T) {
+	client := &stubClient{name: "m", replies: []stubReply{{text: "not json"}}}
+	resp, err := llm.CompleteJSON(context.Background(), client, llm.PromptRequest("p"),
+		llm.ResponseFormat{Name: "task", Schema: taskSchema}, nil, -1)
+	var se *llm.SchemaError
+	if !errors.As(err, &se) || se.Attempts != 1 {
+		t.Fatalf("got %v, want a SchemaError after 1 attempt", err)
+	}
+	if resp == nil || client.calls != 1 {
+		t.Errorf("resp=%v calls=%d, want the last response and one call", resp, client.calls)
+	}
+}
@@ -0,0 +1,240 @@
+// internal/llm/secrets.go
+package llm
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ErrSecretNotFound 는 공급자에 해당 이름의 비밀 값이 없을 때의 오류입니다.
+var ErrSecretNotFound = errors.New("secret not found")
+
+// SecretProvider 는 이름(예: "OPENAI_API_KEY")으로 비밀 값을 찾아 줍니다.
+// 없으면 ErrSecretNotFound 를 (감싸서) 반환합니다.
+type SecretProvider interface {
+	Secret(ctx context.Context, name string) (string, error)
+}
+
+// EnvSecrets 는 환경 변수에서 찾습니다.
+type EnvSecrets struct{}
+
+func (EnvSecrets) Secret(_ context.Context, name string) (string, error) {
+	if v := os.Getenv(name); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("env %s: %w", name, ErrSecretNotFound)
+}
+
+// DotEnvSecrets 는 KEY=VALUE 형식의 .env 파일에서 찾습니다. 파일은 처음 조회할 때 한 번 읽습니다.
+// 파일이 없으면 아무 값도 없는 것으로 봅니다.
+type DotEnvSecrets struct {
+	Path string
+
+	once   sync.Once
+	values map[string]string
+	err    error
+}
+
+func (d *DotEnvSecrets) Secret(_ context.Context, name string) (string, error) {
+	d.once.Do(func() { d.values, d.err = readDotEnv(d.Path) })
+	if d.err != nil {
+		return "", d.err
+	}
+	if v := d.values[name]; v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("%s: %s: %w", d.Path, name, ErrSecretNotFound)
+}
+
+// readDotEnv 는 .env 파일을 읽습니다. '#' 주석, "export " 접두어, 따옴표로 감싼 값을 지원합니다.
+func readDotEnv(path string) (map[string]string, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return map[string]string{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	values := map[string]string{}
+	sc := bufio.NewScanner(bytes.NewReader(b))
+	for n := 1; sc.Scan(); n++ {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("%s:%d: expected KEY=VALUE", path, n)
+		}
+		v = strings.TrimSpace(v)
+		if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+			v = v[1 : len(v)-1]
+		}
+		values[strings.TrimSpace(k)] = v
+	}
+	return values, sc.Err()
+}
+
+// DirSecrets 는 디렉터리의 파일 하나당 비밀 하나(파일 이름 = 비밀 이름)로 찾습니다.
+// Docker/Kubernetes secret 마운트와 같은 형식이며, 앞뒤 공백과 줄바꿈은 무시합니다.
+type DirSecrets struct {
+	Dir string
+}
+
+func (d DirSecrets) Secret(_ context.Context, name string) (string, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return "", fmt.Errorf("invalid secret name %q", name)
+	}
+	b, err := os.ReadFile(filepath.Join(d.Dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("%s: %s: %w", d.Dir, name, ErrSecretNotFound)
+	}
+	if err != nil {
+		return "", err
+	}
+	if v := strings.TrimSpace(string(b)); v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("%s: %s is empty: %w", d.Dir, name, ErrSecretNotFound)
+}
+
+// ChainSecrets 는 순서대로 찾아 처음 나온 값을 씁니다.
+// ErrSecretNotFound 가 아닌 오류(예: 볼트 복호화 실패)는 바로 반환합니다.
+type ChainSecrets []SecretProvider
+
+func (c ChainSecrets) Secret(ctx context.Context, name string) (string, error) {
+	for _, p := range c {
+		v, err := p.Secret(ctx, name)
+		if err == nil {
+			return v, nil
+		}
+		if !errors.Is(err, ErrSecretNotFound) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("%s: %w (checked env, .env, secret dir, vault)", name, ErrSecretNotFound)
+}
+
+// 기본 비밀 위치 (실행 디렉터리 기준)
+const (
+	DefaultSecretDir  = "secret"
+	DefaultDotEnvPath = ".env"
+	// EnvVaultPassphrase 가 설정돼 있으면 secret/vault.enc 볼트도 찾습니다.
+	EnvVaultPassphrase = "SPECKIT_VAULT_PASSPHRASE"
+)
+
+// DefaultVaultPath 는 기본 볼트 파일 경로입니다.
+var DefaultVaultPath = filepath.Join(DefaultSecretDir, "vault.enc")
+
+var (
+	secretsMu sync.RWMutex
+	secrets   SecretProvider = defaultSecrets()
+)
+
+// defaultSecrets 는 env → .env → secret/ → 볼트 순서로 찾습니다.
+func defaultSecrets() SecretProvider {
+	return ChainSecrets{
+		EnvSecrets{},
+		&DotEnvSecrets{Path: DefaultDotEnvPath},
+		&DotEnvSecrets{Path: filepath.Join(DefaultSecretDir, ".env")},
+		DirSecrets{Dir: DefaultSecretDir},
+		&VaultSecrets{Path: DefaultVaultPath, Passphrase: PassphraseFromEnv(EnvVaultPassphrase)},
+	}
+}
+
+// SetSecretProvider 는 클라이언트가 API 키를 찾을 때 쓰는 공급자를 바꿉니다.
+// 이미 만든 클라이언트도 아직 키를 조회하지 않았다면 새 공급자를 씁니다.
+func SetSecretProvider(p SecretProvider) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	secrets = p
+}
+
+func currentSecrets() SecretProvider {
+	secretsMu.RLock()
+	defer secretsMu.RUnlock()
+	return secrets
+}
+
+// secretRef 는 처음 필요할 때 한 번만 조회하는 비밀 값입니다.
+// 조회된 값은 RedactSecrets 가 가리도록 등록됩니다.
+type secretRef struct {
+	name string
+
+	mu       sync.Mutex
+	value    string
+	resolved bool
+}
+
+// secretNamed 는 name 을 현재 SecretProvider 로 늦게 조회하는 참조입니다.
+func secretNamed(name string) *secretRef { return &secretRef{name: name} }
+
+// secretValue 는 이미 알고 있는 값입니다 (SetAPIKey 등).
+func secretValue(v string) *secretRef {
+	registerSecret(v)
+	return &secretRef{value: v, resolved: true}
+}
+
+// get 은 값을 반환합니다. 어디에도 없으면 빈 값(인증 없이 요청)이고,
+// 그 외 조회 오류는 그대로 반환합니다. 실패는 캐시하지 않아 다음 호출에서 다시 시도합니다.
+func (s *secretRef) get(ctx context.Context) (string, error) {
+	if s == nil {
+		return "", nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resolved {
+		return s.value, nil
+	}
+	v, err := currentSecrets().Secret(ctx, s.name)
+	if err != nil && !errors.Is(err, ErrSecretNotFound) {
+		return "", fmt.Errorf("secret %s: %w", s.name, err)
+	}
+	registerSecret(v)
+	s.value, s.resolved = v, true
+	return v, nil
+}
+
+// ---- 가리기 대상 등록 ----
+
+var (
+	knownSecretsMu sync.RWMutex
+	knownSecrets   = map[string]bool{}
+)
+
+// minSecretLen 보다 짧은 값은 흔한 문자열까지 가릴 수 있어 등록하지 않습니다.
+const minSecretLen = 8
+
+// registerSecret 은 v 를 RedactSecrets 가 가릴 값으로 등록합니다.
+func registerSecret(v string) {
+	if len(v) < minSecretLen {
+		return
+	}
+	knownSecretsMu.Lock()
+	defer knownSecretsMu.Unlock()
+	knownSecrets[v] = true
+}
+
+// RegisterSecret 은 클라이언트 밖에서 쓰는 비밀 값(예: 웹훅 토큰)도 로그와 산출물에서 가리도록 등록합니다.
+func RegisterSecret(v string) { registerSecret(v) }
+
+func registeredSecrets() []string {
+	knownSecretsMu.RLock()
+	defer knownSecretsMu.RUnlock()
+	out := make([]string, 0, len(knownSecrets))
+	for v := range knownSecrets {
+		out = append(out, v)
+	}
+	// 긴 값부터 가려야 다른 값을 포함하는 비밀이 일부만 가려지지 않습니다.
+	sort.Slice(out, func(i, j int) bool { return len(out[i]) > len(out[j]) })
+	return out
+}
@@ -0,0 +1,112 @@
+// internal/llm/secrets_test.go
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"speckit-study/internal/llm"
+)
+
+func TestSecretChain(t *testing.T) {
+	dir := t.TempDir()
+	dotenv := filepath.Join(dir, ".env")
+	os.WriteFile(dotenv, []byte("# keys\nexport SPECKIT_TEST_DOTENV_KEY=\"from-dotenv\"\nSPECKIT_TEST_SHARED_KEY='shared-dotenv'\n"), 0o600)
+	secretDir := filepath.Join(dir, "secret")
+	os.MkdirAll(secretDir, 0o700)
+	os.WriteFile(filepath.Join(secretDir, "SPECKIT_TEST_SHARED_KEY"), []byte("shared-file\n"), 0o600)
+	os.WriteFile(filepath.Join(secretDir, "SPECKIT_TEST_FILE_KEY"), []byte("from-file\n"), 0o600)
+	t.Setenv("SPECKIT_TEST_ENV_KEY", "from-env")
+
+	chain := llm.ChainSecrets{llm.EnvSecrets{}, &llm.DotEnvSecrets{Path: dotenv}, llm.DirSecrets{Dir: secretDir}}
+	ctx := context.Background()
+	for name, want := range map[string]string{
+		"SPECKIT_TEST_ENV_KEY":    "from-env",
+		"SPECKIT_TEST_DOTENV_KEY": "from-dotenv",
+		"SPECKIT_TEST_SHARED_KEY": "shared-dotenv", // 앞선 공급자가 이깁니다
+		"SPECKIT_TEST_FILE_KEY":   "from-file",
+	} {
+		if got, err := chain.Secret(ctx, name); err != nil || got != want {
+			t.Errorf("%s = %q, %v, want %q", name, got, err, want)
+		}
+	}
+	if _, err := chain.Secret(ctx, "MISSING_KEY"); !errors.Is(err, llm.ErrSecretNotFound) {
+		t.Errorf("missing: got %v, want ErrSecretNotFound", err)
+	}
+	if _, err := (llm.DirSecrets{Dir: secretDir}).Secret(ctx, "../.env"); err == nil || errors.Is(err, llm.ErrSecretNotFound) {
+		t.Errorf("path traversal: got %v, want an invalid name error", err)
+	}
+
+	bad := filepath.Join(dir, "bad.env")
+	os.WriteFile(bad, []byte("OK=1\nnot a pair\n"), 0o600)
+	if _, err := (&llm.DotEnvSecrets{Path: bad}).Secret(ctx, "OK"); err == nil || errors.Is(err, llm.ErrSecretNotFound) {
+		t.Errorf("malformed .env: got %v, want a parse error", err)
+	}
+}
+
+func TestVaultRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "secret", "vault.enc")
+	v, err := llm.OpenVault(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	v.Set("OPENAI_API_KEY", "vault-openai-key")
+	v.Set("OLD_KEY", "x")
+	v.Delete("OLD_KEY")
+	if err := v.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := llm.OpenVault(path, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := reopened.Get("OPENAI_API_KEY"); !ok || got != "vault-openai-key" || len(reopened.Names()) != 1 {
+		t.Errorf("reopened vault: %q %v names=%v", got, ok, reopened.Names())
+	}
+	if _, err := llm.OpenVault(path, "wrong"); !errors.Is(err, llm.ErrVaultPassphrase) {
+		t.Errorf("wrong passphrase: got %v", err)
+	}
+
+	// 볼트에서 꺼낸 값은 로그에서 가려지고, 틀린 패스프레이즈는 체인을 멈춥니다.
+	if got := llm.RedactSecrets("key=vault-openai-key"); got != "key="+llm.RedactedPlaceholder {
+		t.Errorf("vault value not redacted: %q", got)
+	}
+	wrong := llm.ChainSecrets{&llm.VaultSecrets{Path: path, Passphrase: func() (string, error) { return "wrong", nil }}, llm.EnvSecrets{}}
+	if _, err := wrong.Secret(context.Background(), "OPENAI_API_KEY"); !errors.Is(err, llm.ErrVaultPassphrase) {
+		t.Errorf("chain with a wrong passphrase: got %v", err)
+	}
+	unset := &llm.VaultSecrets{Path: path, Passphrase: llm.PassphraseFromEnv("SPECKIT_TEST_UNSET_PASSPHRASE")}
+	if _, err := unset.Secret(context.Background(), "OPENAI_API_KEY"); !errors.Is(err, llm.ErrSecretNotFound) {
+		t.Errorf("no passphrase: got %v, want the vault skipped", err)
+	}
+}
+
+// 클라이언트는 첫 요청 때 현재 SecretProvider 에서 키를 찾습니다.
+func TestClientUsesSecretProvider(t *testing.T) {
+	var auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth = r.Header.Get("Authorization")
+		w.Write([]byte(`{"choices":[{"message":{"content":"ok"}}]}`))
+	}))
+	defer srv.Close()
+
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "LOCAL_LLM_KEY"), []byte("local-secret-key"), 0o600)
+	llm.SetSecretProvider(llm.DirSecrets{Dir: dir})
+	defer llm.SetSecretProvider(llm.ChainSecrets{llm.EnvSecrets{}})
+
+	client := llm.NewOpenAICompatibleClient(srv.URL+"/v1", "m")
+	client.SetAPIKeySecret("LOCAL_LLM_KEY")
+	if _, err := client.Generate(context.Background(), "p"); err != nil {
+		t.Fatal(err)
+	}
+	if auth != "Bearer local-secret-key" {
+		t.Errorf("Authorization = %q", auth)
+	}
+}
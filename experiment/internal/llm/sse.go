@@ -0,0 +1,60 @@
+// internal/llm/sse.go
+package llm
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// sseEvent 는 Server-Sent Events 스트림의 이벤트 한 건입니다.
+type sseEvent struct {
+	Event string
+	Data  string
+}
+
+// readSSE 는 r 에서 SSE 이벤트를 읽어 fn 으로 넘깁니다.
+// fn 이 false 를 반환하면 읽기를 중단합니다.
+func readSSE(r io.Reader, fn func(ev sseEvent) bool) error {
+	sc := bufio.NewScanner(r)
+	// 긴 JSON 청크가 한 줄로 올 수 있으므로 버퍼를 넉넉히 잡습니다.
+	sc.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+
+	var ev sseEvent
+	var data []string
+	flush := func() bool {
+		if len(data) == 0 && ev.Event == "" {
+			return true
+		}
+		ev.Data = strings.Join(data, "\n")
+		ok := fn(ev)
+		ev, data = sseEvent{}, nil
+		return ok
+	}
+
+	for sc.Scan() {
+		line := sc.Text()
+		if line == "" {
+			if !flush() {
+				return nil
+			}
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // 주석(keep-alive)
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "event":
+			ev.Event = value
+		case "data":
+			data = append(data, value)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	flush()
+	return nil
+}
@@ -0,0 +1,142 @@
+// internal/llm/stream.go
+package llm
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Usage 는 한 번의 모델 호출에서 소비된 토큰 수입니다.
+// CachedTokens 는 InputTokens 중 프롬프트 캐시에서 읽은 토큰입니다 (InputTokens 에 포함).
+type Usage struct {
+	InputTokens  int `json:"input_tokens" yaml:"input_tokens"`
+	OutputTokens int `json:"output_tokens" yaml:"output_tokens"`
+	CachedTokens int `json:"cached_tokens,omitempty" yaml:"cached_tokens,omitempty"`
+}
+
+// TotalTokens 는 입력 + 출력 토큰 합계입니다.
+func (u Usage) TotalTokens() int { return u.InputTokens + u.OutputTokens }
+
+// StreamEvent 는 스트리밍 응답의 조각입니다.
+// Delta 에는 새로 도착한 텍스트가, 마지막 이벤트(Done)에는 Usage 요약이 담깁니다.
+// 스트림 도중 오류가 나면 Err 가 채워진 이벤트를 마지막으로 채널이 닫힙니다.
+// Model/Tag 는 Fallback/Router 처럼 실제 응답한 모델이 달라질 수 있는 래퍼가 Done 이벤트에 채웁니다.
+type StreamEvent struct {
+	Delta string
+	Usage *Usage
+	Done  bool
+	Err   error
+	Model string
+	Tag   string
+	// QueueWait 는 Done 이벤트에만 채워지며, 호출 전에 rate limiter 에서 기다린 시간입니다.
+	QueueWait time.Duration
+	// Latency 는 Done 이벤트에만 채워지며, Timing 미들웨어가 잰 호출 시간입니다.
+	Latency time.Duration
+	// Cached 는 Done 이벤트에만 채워지며, 응답 캐시에서 꺼낸 결과임을 나타냅니다.
+	Cached bool
+	// ToolCalls 는 Done 이벤트에만 채워지며, 스트림 동안 조각으로 온 도구 호출을 합친 것입니다.
+	ToolCalls []ToolCall
+	// Attempts, RetriedErrors 는 Done 이벤트에만 채워지며, 스트림을 열 때까지의 재시도 기록입니다 (Response 와 같음).
+	Attempts      int
+	RetriedErrors []string
+}
+
+// Streamer 는 토큰 단위 스트리밍을 지원하는 클라이언트가 선택적으로 구현합니다.
+// 반환된 채널은 ctx 가 취소되거나 스트림이 끝나면 닫힙니다.
+type Streamer interface {
+	Stream(ctx context.Context, req GenerateRequest) (<-chan StreamEvent, error)
+}
+
+// StreamTo 는 client 가 Streamer 를 구현하면 도착하는 토큰을 w 에 바로 쓰고,
+// 아니면 Complete 결과를 한 번에 씁니다. 모인 전체 텍스트와 Usage 를 Response 로 반환합니다.
+// 스트림 도중 실패하면 그때까지 받은 텍스트가 담긴 Response 와 오류를 함께 반환합니다.
+func StreamTo(ctx context.Context, client LLMClient, req GenerateRequest, w io.Writer) (*Response, error) {
+	s, ok := client.(Streamer)
+	if !ok {
+		resp, err := Complete(ctx, client, req)
+		if err != nil {
+			return nil, err
+		}
+		io.WriteString(w, resp.Text)
+		return resp, nil
+	}
+
+	events, err := s.Stream(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	var sb strings.Builder
+	resp := &Response{Model: client.Name()}
+	for ev := range events {
+		if ev.Err != nil {
+			resp.Text = sb.String()
+			return resp, ev.Err
+		}
+		if ev.Delta != "" {
+			sb.WriteString(ev.Delta)
+			io.WriteString(w, ev.Delta)
+		}
+		if ev.Usage != nil {
+			resp.Usage = *ev.Usage
+		}
+		if ev.Model != "" {
+			resp.Model = ev.Model
+		}
+		if ev.Tag != "" {
+			resp.Tag = ev.Tag
+		}
+		resp.QueueWait += ev.QueueWait
+		if ev.Latency > 0 {
+			resp.Latency = ev.Latency
+		}
+		resp.ToolCalls = append(resp.ToolCalls, ev.ToolCalls...)
+		resp.Cached = resp.Cached || ev.Cached
+		if ev.Done {
+			resp.Attempts, resp.RetriedErrors = ev.Attempts, ev.RetriedErrors
+		}
+	}
+	resp.Text = sb.String()
+	if err := ctx.Err(); err != nil {
+		return resp, err
+	}
+	return resp, nil
+}
+
+// emit 는 ctx 취소를 존중하면서 이벤트를 채널에 보냅니다.
+func emit(ctx context.Context, ch chan<- StreamEvent, ev StreamEvent) bool {
+	select {
+	case ch <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// streamingHTTPClient 는 base 와 같은 Transport 를 쓰되 전체 Timeout 을 없앤 클라이언트를 반환합니다.
+// 스트림은 응답 본문을 오래 읽으므로 종료/취소는 ctx 로만 제어합니다.
+func streamingHTTPClient(base *http.Client) *http.Client {
+	c := *base
+	c.Timeout = 0
+	return &c
+}
+
+// streamOrComplete 는 client 가 Streamer 면 그대로 스트리밍하고,
+// 아니면 Complete 결과를 델타 하나와 완료 이벤트로 흘려보냅니다 (래퍼 클라이언트용).
+func streamOrComplete(ctx context.Context, client LLMClient, req GenerateRequest) (<-chan StreamEvent, error) {
+	if s, ok := client.(Streamer); ok {
+		return s.Stream(ctx, req)
+	}
+	resp, err := Complete(ctx, client, req)
+	if err != nil {
+		return nil, err
+	}
+	ch := make(chan StreamEvent, 2)
+	ch <- StreamEvent{Delta: resp.Text}
+	ch <- StreamEvent{Done: true, Usage: &resp.Usage, Model: resp.Model, QueueWait: resp.QueueWait, ToolCalls: resp.ToolCalls, Cached: resp.Cached,
+		Attempts: resp.Attempts, RetriedErrors: resp.RetriedErrors}
+	close(ch)
+	return ch, nil
+}
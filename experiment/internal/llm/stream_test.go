@@ -0,0 +1,35 @@
+// internal/llm/stream_test.go
+package llm_test
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"speckit-study/internal/fakellm"
+	"speckit-study/internal/llm"
+)
+
+func TestStreamReportsRetries(t *testing.T) {
+	for _, provider := range []string{"openai", "anthropic", "gemini"} {
+		t.Run(provider, func(t *testing.T) {
+			srv := fakellm.NewTestServer(fakellm.Script{
+				Rules:   []fakellm.Rule{{Times: 1, Reply: fakellm.Reply{Status: 503}}},
+				Default: fakellm.Reply{Text: "## Steps\n1. run"},
+			})
+			defer srv.Close()
+			client := fakeClients(t, srv)[provider]
+
+			resp, err := llm.StreamTo(context.Background(), client, llm.PromptRequest("p"), io.Discard)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if resp.Text != "## Steps\n1. run" {
+				t.Errorf("text = %q", resp.Text)
+			}
+			if resp.Attempts != 2 || len(resp.RetriedErrors) != 1 {
+				t.Errorf("attempts=%d retried=%q, want 2 attempts and one retried error", resp.Attempts, resp.RetriedErrors)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+// internal/llm/structured.go
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultMaxRepairs 는 CompleteJSON 이 스키마 오류를 고쳐 달라고 다시 요청하는 기본 횟수입니다.
+const DefaultMaxRepairs = 2
+
+// ResponseFormat 은 JSON Schema 를 따르는 구조화 출력 요청입니다.
+// 공급자별 네이티브 기능으로 변환됩니다:
+// OpenAI/호환 서버는 response_format(json_schema), Gemini 는 responseJsonSchema,
+// Anthropic 은 input_schema 가 스키마인 도구를 강제 호출, Ollama 는 format.
+type ResponseFormat struct {
+	Name   string `json:"name" yaml:"name"` // 영문/숫자/_/- (도구 이름 등으로 쓰임)
+	Schema Schema `json:"schema" yaml:"schema"`
+}
+
+var formatNameRe = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// name 은 공급자가 받아들이는 형태로 정리한 이름입니다.
+func (f *ResponseFormat) name() string {
+	n := strings.Trim(formatNameRe.ReplaceAllString(f.Name, "_"), "_")
+	if n == "" {
+		return "response"
+	}
+	return n
+}
+
+// isObject 는 스키마 최상위가 object 인지 봅니다 (Anthropic 도구 입력은 object 만 허용).
+func (f *ResponseFormat) isObject() bool {
+	for _, t := range schemaTypes(f.Schema["type"]) {
+		if t == "object" {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrSchemaMismatch 는 재요청 횟수를 다 써도 출력이 스키마를 따르지 않을 때의 오류입니다.
+var ErrSchemaMismatch = errors.New("output does not match schema")
+
+// SchemaError 는 마지막 출력과 그 검증 문제입니다.
+type SchemaError struct {
+	Format   string
+	Attempts int
+	Problems []string
+	Output   string
+}
+
+func (e *SchemaError) Error() string {
+	return fmt.Sprintf("%s: %v after %d attempt(s): %s", e.Format, ErrSchemaMismatch, e.Attempts, strings.Join(e.Problems, "; "))
+}
+
+func (e *SchemaError) Unwrap() error { return ErrSchemaMismatch }
+
+// CompleteJSON 은 format 의 스키마를 따르는 JSON 을 생성해 out 으로 디코딩합니다 (out 이 nil 이면 검증만).
+// 출력이 JSON 이 아니거나 스키마와 다르면 그 문제를 알려 주며 최대 maxRepairs 번 다시 요청합니다.
+// 반환하는 Response.Text 는 정리된 JSON 이고, Usage 는 모든 시도의 합계입니다.
+// 실패해도 지금까지의 Response 를 함께 반환하므로 비용 집계에 쓸 수 있습니다. maxRepairs 가 음수면 0 으로 봅니다.
+func CompleteJSON(ctx context.Context, client LLMClient, req GenerateRequest, format ResponseFormat, out interface{}, maxRepairs int) (*Response, error) {
+	maxRepairs = max(maxRepairs, 0)
+	req.ResponseFormat = &format
+	req.System = strings.TrimSpace(req.System + "\n\n" + jsonInstruction(format))
+	req.Messages = append([]Message(nil), req.Messages...)
+
+	var total Usage
+	var wait time.Duration
+	var last *Response
+	var problems []string
+	for attempt := 0; attempt <= maxRepairs; attempt++ {
+		resp, err := Complete(ctx, client, req)
+		if err != nil {
+			if last != nil {
+				last.Usage = total
+			}
+			return last, err
+		}
+		total.InputTokens += resp.Usage.InputTokens
+		total.OutputTokens += resp.Usage.OutputTokens
+		total.CachedTokens += resp.Usage.CachedTokens
+		wait += resp.QueueWait
+		resp.Usage, resp.QueueWait = total, wait
+		resp.Repairs = attempt
+		last = resp
+
+		text := ExtractJSON(resp.Text)
+		problems = format.Schema.ValidateJSON([]byte(text))
+		if len(problems) == 0 && out != nil {
+			if err := json.Unmarshal([]byte(text), out); err != nil {
+				problems = []string{"decode: " + err.Error()}
+			}
+		}
+		if len(problems) == 0 {
+			resp.Text = text
+			return resp, nil
+		}
+		req.Messages = append(req.Messages,
+			Message{Role: RoleAssistant, Content: resp.Text},
+			Message{Role: RoleUser, Content: repairPrompt(problems)},
+		)
+	}
+	return last, &SchemaError{Format: format.name(), Attempts: maxRepairs + 1, Problems: problems, Output: last.Text}
+}
+
+// CompleteAs 는 T 의 스키마(SchemaFor)로 CompleteJSON 을 호출해 T 값을 반환합니다.
+//
+//	tf, resp, err := llm.CompleteAs[speckit.TaskFile](ctx, client, req, llm.DefaultMaxRepairs)
+func CompleteAs[T any](ctx context.Context, client LLMClient, req GenerateRequest, maxRepairs int) (T, *Response, error) {
+	var v T
+	name := reflect.TypeOf(v).Name()
+	resp, err := CompleteJSON(ctx, client, req, ResponseFormat{Name: name, Schema: SchemaFor(v)}, &v, maxRepairs)
+	return v, resp, err
+}
+
+func jsonInstruction(f ResponseFormat) string {
+	b, _ := json.Marshal(f.Schema)
+	return "Respond with a single JSON value that conforms to this JSON Schema. " +
+		"Output only the JSON, with no markdown fences or commentary.\n" + string(b)
+}
+
+func repairPrompt(problems []string) string {
+	var sb strings.Builder
+	sb.WriteString("Your previous output did not match the required JSON Schema:\n")
+	for _, p := range problems {
+		sb.WriteString("- " + p + "\n")
+	}
+	sb.WriteString("Return the corrected JSON only.")
+	return sb.String()
+}
+
+var fenceRe = regexp.MustCompile("(?s)```[a-zA-Z]*\\s*\\n(.*?)```")
+
+// ExtractJSON 은 모델 출력에서 JSON 부분만 꺼냅니다.
+// 마크다운 코드 펜스를 벗기고, 앞뒤 설명 문장이 붙어 있으면 첫 '{' 또는 '[' 부터 마지막 '}' 또는 ']' 까지 잘라냅니다.
+func ExtractJSON(s string) string {
+	s = strings.TrimSpace(s)
+	if m := fenceRe.FindStringSubmatch(s); m != nil {
+		s = strings.TrimSpace(m[1])
+	}
+	if json.Valid([]byte(s)) {
+		return s
+	}
+	start := strings.IndexAny(s, "{[")
+	if start < 0 {
+		return s
+	}
+	closer := "}"
+	if s[start] == '[' {
+		closer = "]"
+	}
+	end := strings.LastIndex(s, closer)
+	if end <= start {
+		return s
+	}
+	return s[start : end+1]
+}
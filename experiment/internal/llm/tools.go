@@ -0,0 +1,208 @@
+// internal/llm/tools.go
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Tool 은 모델이 호출할 수 있는 함수 선언입니다.
+type Tool struct {
+	Name        string `json:"name" yaml:"name"` // 영문/숫자/_/- (공급자 공통 제약)
+	Description string `json:"description" yaml:"description"`
+	Parameters  Schema `json:"parameters" yaml:"parameters"` // 인자 JSON Schema (최상위 object)
+}
+
+// ToolCall 은 모델이 요청한 도구 호출 하나를 공급자와 무관하게 정규화한 것입니다.
+// Gemini 처럼 호출 ID 를 주지 않는 공급자는 클라이언트가 ID 를 만들어 채웁니다.
+type ToolCall struct {
+	ID        string          `json:"id" yaml:"id"`
+	Name      string          `json:"name" yaml:"name"`
+	Arguments json.RawMessage `json:"arguments"` // JSON object (YAML 에는 문자열로 기록)
+}
+
+// toolCallYAML 은 카세트 등 YAML 에 인자를 JSON 문자열로 남기기 위한 형식입니다.
+type toolCallYAML struct {
+	ID        string `yaml:"id"`
+	Name      string `yaml:"name"`
+	Arguments string `yaml:"arguments"`
+}
+
+func (c ToolCall) MarshalYAML() (interface{}, error) {
+	return toolCallYAML{ID: c.ID, Name: c.Name, Arguments: string(c.Arguments)}, nil
+}
+
+func (c *ToolCall) UnmarshalYAML(n *yaml.Node) error {
+	var y toolCallYAML
+	if err := n.Decode(&y); err != nil {
+		return err
+	}
+	*c = ToolCall{ID: y.ID, Name: y.Name, Arguments: json.RawMessage(y.Arguments)}
+	return nil
+}
+
+// toolParameters 는 공급자에 보낼 인자 스키마입니다 (비어 있으면 인자 없는 object).
+func (t Tool) toolParameters() Schema {
+	if len(t.Parameters) == 0 {
+		return Schema{"type": "object", "properties": map[string]interface{}{}}
+	}
+	return t.Parameters
+}
+
+// args 는 비었거나 깨진 인자를 "{}" 로 바꿉니다 (공급자는 object 를 요구하고, 깨진 JSON 은 요청 본문을 망가뜨립니다).
+func (c ToolCall) args() json.RawMessage {
+	if len(strings.TrimSpace(string(c.Arguments))) == 0 || !json.Valid(c.Arguments) {
+		return json.RawMessage("{}")
+	}
+	return c.Arguments
+}
+
+// ToolFunc 는 도구 구현입니다. 반환한 문자열이 모델에게 결과로 전달되고,
+// 오류는 실행을 멈추지 않고 "error: ..." 결과로 전달되어 모델이 다른 방법을 시도할 수 있습니다.
+type ToolFunc func(ctx context.Context, args json.RawMessage) (string, error)
+
+// Toolbox 는 도구 선언과 구현의 모음입니다.
+type Toolbox struct {
+	tools []Tool
+	funcs map[string]ToolFunc
+}
+
+// NewToolbox 는 빈 도구 모음을 만듭니다.
+func NewToolbox() *Toolbox { return &Toolbox{funcs: map[string]ToolFunc{}} }
+
+// Add 는 도구를 추가합니다. 같은 이름이면 교체합니다.
+func (b *Toolbox) Add(tool Tool, fn ToolFunc) *Toolbox {
+	if _, ok := b.funcs[tool.Name]; ok {
+		for i := range b.tools {
+			if b.tools[i].Name == tool.Name {
+				b.tools[i] = tool
+			}
+		}
+	} else {
+		b.tools = append(b.tools, tool)
+	}
+	b.funcs[tool.Name] = fn
+	return b
+}
+
+// Tools 는 선언 목록입니다 (GenerateRequest.Tools 에 넣는 값).
+func (b *Toolbox) Tools() []Tool { return append([]Tool(nil), b.tools...) }
+
+// AddFunc 는 인자 타입 T 의 스키마(SchemaFor)로 도구를 선언하고, 인자를 T 로 디코딩해 fn 을 부릅니다.
+//
+//	llm.AddFunc(box, "read_file", "Read a file under the spec root", func(ctx context.Context, a struct {
+//		Path string `json:"path"`
+//	}) (string, error) { ... })
+func AddFunc[T any](b *Toolbox, name, description string, fn func(ctx context.Context, args T) (string, error)) *Toolbox {
+	var zero T
+	return b.Add(Tool{Name: name, Description: description, Parameters: SchemaFor(zero)},
+		func(ctx context.Context, raw json.RawMessage) (string, error) {
+			var args T
+			if err := json.Unmarshal(raw, &args); err != nil {
+				return "", fmt.Errorf("invalid arguments: %w", err)
+			}
+			return fn(ctx, args)
+		})
+}
+
+// Call 은 도구 하나를 실행합니다. 인자가 스키마와 맞지 않으면 실행하지 않고 오류를 반환합니다.
+func (b *Toolbox) Call(ctx context.Context, call ToolCall) (string, error) {
+	fn, ok := b.funcs[call.Name]
+	if !ok {
+		return "", fmt.Errorf("unknown tool %q", call.Name)
+	}
+	for _, t := range b.tools {
+		if t.Name == call.Name && len(t.Parameters) > 0 {
+			if problems := t.Parameters.ValidateJSON(call.args()); len(problems) > 0 {
+				return "", fmt.Errorf("invalid arguments: %s", strings.Join(problems, "; "))
+			}
+		}
+	}
+	return fn(ctx, call.args())
+}
+
+// DefaultMaxToolSteps 는 RunTools 가 모델을 부르는 기본 최대 횟수입니다.
+const DefaultMaxToolSteps = 8
+
+// ErrToolSteps 는 MaxSteps 안에 모델이 도구 호출을 끝내지 않았을 때의 오류입니다.
+var ErrToolSteps = errors.New("tool loop did not finish")
+
+// ToolCallLog 는 실행한 도구 호출 하나의 기록입니다.
+type ToolCallLog struct {
+	Step     int           `json:"step"`
+	Call     ToolCall      `json:"call"`
+	Result   string        `json:"result"`
+	Error    string        `json:"error,omitempty"`
+	Duration time.Duration `json:"duration"`
+}
+
+// ToolRun 은 RunTools 의 결과입니다.
+type ToolRun struct {
+	Response *Response     // 마지막(도구 호출 없는) 응답. Usage 는 모든 단계의 합계
+	Messages []Message     // 도구 호출/결과를 포함한 전체 대화 (다음 요청에 이어 쓸 수 있음)
+	Calls    []ToolCallLog // 실행한 도구 호출 (순서대로)
+	Steps    int           // 모델 호출 횟수
+}
+
+// RunTools 는 모델이 도구 호출을 멈출 때까지 "모델 호출 → 도구 실행 → 결과 전달" 을 반복합니다.
+// maxSteps 는 모델 호출 횟수 상한입니다 (0 이면 DefaultMaxToolSteps). 상한에 닿으면 ErrToolSteps 와 함께
+// 지금까지의 ToolRun 을 반환합니다. 도구 실행 오류는 모델에게 결과로 전달되며 루프를 멈추지 않습니다.
+func RunTools(ctx context.Context, client LLMClient, req GenerateRequest, box *Toolbox, maxSteps int) (*ToolRun, error) {
+	if maxSteps <= 0 {
+		maxSteps = DefaultMaxToolSteps
+	}
+	req.Tools = box.Tools()
+	req.Messages = append([]Message(nil), req.Messages...)
+
+	run := &ToolRun{}
+	var total Usage
+	var wait time.Duration
+	for run.Steps < maxSteps {
+		run.Steps++
+		resp, err := Complete(ctx, client, req)
+		if err != nil {
+			run.Messages = req.Messages
+			return run, err
+		}
+		total.InputTokens += resp.Usage.InputTokens
+		total.OutputTokens += resp.Usage.OutputTokens
+		total.CachedTokens += resp.Usage.CachedTokens
+		wait += resp.QueueWait
+		resp.Usage, resp.QueueWait = total, wait
+		run.Response = resp
+
+		req.Messages = append(req.Messages, Message{Role: RoleAssistant, Content: resp.Text, ToolCalls: resp.ToolCalls})
+		if len(resp.ToolCalls) == 0 {
+			run.Messages = req.Messages
+			return run, nil
+		}
+		// 첫 단계에서 특정 도구를 강제했다면 이후에는 모델이 자유롭게 끝낼 수 있어야 합니다.
+		if req.ToolChoice != "none" {
+			req.ToolChoice = ""
+		}
+		for _, call := range resp.ToolCalls {
+			start := time.Now()
+			result, err := box.Call(ctx, call)
+			entry := ToolCallLog{Step: run.Steps, Call: call, Result: result, Duration: time.Since(start)}
+			msg := Message{Role: RoleTool, Content: result, ToolCallID: call.ID, ToolName: call.Name}
+			if err != nil {
+				if ctx.Err() != nil {
+					run.Messages = req.Messages
+					return run, ctx.Err()
+				}
+				entry.Error = RedactSecrets(err.Error())
+				msg.Content, msg.ToolError = "error: "+entry.Error, true
+			}
+			run.Calls = append(run.Calls, entry)
+			req.Messages = append(req.Messages, msg)
+		}
+	}
+	run.Messages = req.Messages
+	return run, fmt.Errorf("%w after %d model calls", ErrToolSteps, maxSteps)
+}
@@ -0,0 +1,84 @@
+// internal/llm/tools_test.go
+package llm_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"speckit-study/internal/fakellm"
+	"speckit-study/internal/llm"
+)
+
+// readFileBox 는 read_file 도구 하나를 가진 도구 모음입니다. 호출된 경로를 paths 에 남깁니다.
+func readFileBox(paths *[]string) *llm.Toolbox {
+	return llm.AddFunc(llm.NewToolbox(), "read_file", "Read a spec file", func(ctx context.Context, a struct {
+		Path string `json:"path"`
+	}) (string, error) {
+		*paths = append(*paths, a.Path)
+		return "contents of " + a.Path, nil
+	})
+}
+
+// 세 공급자 모두에서 도구 호출 → 결과 전달 → 최종 답변의 왕복이 이루어지는지 확인합니다.
+func TestRunToolsLoop(t *testing.T) {
+	for _, provider := range providers {
+		t.Run(provider, func(t *testing.T) {
+			srv := fakellm.NewTestServer(fakellm.Script{
+				Rules: []fakellm.Rule{{Times: 1, Reply: fakellm.Reply{ToolCalls: []fakellm.ToolCall{
+					{Name: "delete_file", Args: `{"path":"spec.md"}`},
+					{Name: "read_file", Args: `{"path":"spec.md"}`},
+				}}}},
+				Default: fakellm.Reply{Text: "summary written"},
+			})
+			defer srv.Close()
+
+			var paths []string
+			run, err := llm.RunTools(context.Background(), fakeClients(t, srv)[provider], llm.PromptRequest("summarize the spec"), readFileBox(&paths), 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if run.Steps != 2 || run.Response.Text != "summary written" {
+				t.Fatalf("steps=%d text=%q", run.Steps, run.Response.Text)
+			}
+			if len(paths) != 1 || paths[0] != "spec.md" {
+				t.Errorf("read_file called with %q", paths)
+			}
+			// 없는 도구는 실행을 멈추지 않고 오류 결과로 모델에게 전달됩니다.
+			if len(run.Calls) != 2 || !strings.Contains(run.Calls[0].Error, "unknown tool") || run.Calls[1].Error != "" {
+				t.Errorf("calls = %+v", run.Calls)
+			}
+			calls := srv.Fake.Calls()
+			if len(calls) != 2 || !strings.Contains(calls[1].ToolResult, "contents of spec.md") {
+				t.Errorf("server did not receive the tool result: %+v", calls)
+			}
+			if len(calls[0].Tools) != 1 || calls[0].Tools[0] != "read_file" {
+				t.Errorf("tools declared = %v", calls[0].Tools)
+			}
+			// 대화는 user → assistant(호출) → tool × 2 → assistant(답) 순서입니다.
+			roles := make([]string, len(run.Messages))
+			for i, m := range run.Messages {
+				roles[i] = string(m.Role)
+			}
+			if got := strings.Join(roles, ","); got != "user,assistant,tool,tool,assistant" {
+				t.Errorf("messages = %s", got)
+			}
+		})
+	}
+}
+
+func TestRunToolsStepLimit(t *testing.T) {
+	srv := fakellm.NewTestServer(fakellm.Script{Default: fakellm.Reply{ToolCalls: []fakellm.ToolCall{{Name: "read_file", Args: `{"path":1}`}}}})
+	defer srv.Close()
+
+	var paths []string
+	run, err := llm.RunTools(context.Background(), fakeClients(t, srv)["openai"], llm.PromptRequest("loop"), readFileBox(&paths), 3)
+	if !errors.Is(err, llm.ErrToolSteps) || run.Steps != 3 {
+		t.Fatalf("got %v after %d steps, want ErrToolSteps after 3", err, run.Steps)
+	}
+	// 스키마에 맞지 않는 인자는 도구를 실행하지 않습니다.
+	if len(paths) != 0 || len(run.Calls) != 3 || !strings.Contains(run.Calls[0].Error, "invalid arguments") {
+		t.Errorf("paths=%q calls=%+v", paths, run.Calls)
+	}
+}
@@ -0,0 +1,195 @@
+// internal/llm/vault.go
+package llm
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// vaultIterations 는 패스프레이즈에서 키를 만드는 PBKDF2 반복 횟수입니다.
+const vaultIterations = 600_000
+
+// ErrVaultPassphrase 는 패스프레이즈가 틀렸거나 볼트 파일이 손상됐을 때의 오류입니다.
+var ErrVaultPassphrase = errors.New("vault: wrong passphrase or corrupted file")
+
+// vaultFile 은 볼트의 디스크 형식입니다. data 는 이름→값 JSON 을 AES-256-GCM 으로 암호화한 것입니다.
+type vaultFile struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Data       []byte `json:"data"`
+}
+
+// Vault 는 패스프레이즈로 암호화한 로컬 비밀 저장소입니다 (기본 secret/vault.enc).
+// cmd/vault 로 값을 넣고 빼며, 파일은 저장소에 커밋해도 패스프레이즈 없이는 읽을 수 없습니다.
+type Vault struct {
+	Path       string
+	passphrase string
+	values     map[string]string
+}
+
+// OpenVault 는 볼트 파일을 엽니다. 파일이 없으면 빈 볼트를 반환합니다 (Save 때 만들어짐).
+func OpenVault(path, passphrase string) (*Vault, error) {
+	if passphrase == "" {
+		return nil, errors.New("vault: empty passphrase")
+	}
+	v := &Vault{Path: path, passphrase: passphrase, values: map[string]string{}}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return v, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var f vaultFile
+	if err := json.Unmarshal(b, &f); err != nil {
+		return nil, fmt.Errorf("vault %s: %w", path, err)
+	}
+	if f.Version != 1 || f.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("vault %s: unsupported format (version %d, kdf %q)", path, f.Version, f.KDF)
+	}
+	gcm, err := vaultCipher(passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	plain, err := gcm.Open(nil, f.Nonce, f.Data, nil)
+	if err != nil {
+		return nil, ErrVaultPassphrase
+	}
+	if err := json.Unmarshal(plain, &v.values); err != nil {
+		return nil, ErrVaultPassphrase
+	}
+	for _, s := range v.values {
+		registerSecret(s)
+	}
+	return v, nil
+}
+
+func vaultCipher(passphrase string, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Get 은 name 의 값을 반환합니다.
+func (v *Vault) Get(name string) (string, bool) {
+	s, ok := v.values[name]
+	return s, ok
+}
+
+// Set 은 값을 넣습니다 (Save 해야 파일에 반영).
+func (v *Vault) Set(name, value string) {
+	registerSecret(value)
+	v.values[name] = value
+}
+
+// Delete 는 값을 지웁니다 (Save 해야 파일에 반영).
+func (v *Vault) Delete(name string) { delete(v.values, name) }
+
+// Names 는 저장된 이름 목록입니다 (값은 노출하지 않음).
+func (v *Vault) Names() []string {
+	names := make([]string, 0, len(v.values))
+	for n := range v.values {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save 는 새 salt/nonce 로 다시 암호화해 파일에 씁니다 (0600, 임시 파일 후 rename).
+func (v *Vault) Save() error {
+	plain, err := json.Marshal(v.values)
+	if err != nil {
+		return err
+	}
+	f := vaultFile{Version: 1, KDF: "pbkdf2-sha256", Iterations: vaultIterations,
+		Salt: make([]byte, 16)}
+	if _, err := rand.Read(f.Salt); err != nil {
+		return err
+	}
+	gcm, err := vaultCipher(v.passphrase, f.Salt, f.Iterations)
+	if err != nil {
+		return err
+	}
+	f.Nonce = make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(f.Nonce); err != nil {
+		return err
+	}
+	f.Data = gcm.Seal(nil, f.Nonce, plain, nil)
+
+	b, err := json.MarshalIndent(f, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(v.Path), 0o700); err != nil {
+		return err
+	}
+	tmp := v.Path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, v.Path)
+}
+
+// PassphraseFromEnv 는 환경 변수에서 패스프레이즈를 읽는 함수를 만듭니다.
+// 변수가 비어 있으면 ErrSecretNotFound 이므로 VaultSecrets 는 볼트를 건너뜁니다.
+func PassphraseFromEnv(name string) func() (string, error) {
+	return func() (string, error) {
+		if v := os.Getenv(name); v != "" {
+			return v, nil
+		}
+		return "", fmt.Errorf("vault passphrase %s: %w", name, ErrSecretNotFound)
+	}
+}
+
+// VaultSecrets 는 암호화된 볼트에서 찾는 SecretProvider 입니다. 볼트는 처음 조회할 때 한 번 엽니다.
+// 볼트 파일이나 패스프레이즈가 없으면 값이 없는 것으로 보고, 패스프레이즈가 틀리면 오류를 반환합니다.
+type VaultSecrets struct {
+	Path       string
+	Passphrase func() (string, error)
+
+	once  sync.Once
+	vault *Vault
+	err   error
+}
+
+func (s *VaultSecrets) Secret(_ context.Context, name string) (string, error) {
+	s.once.Do(func() {
+		if _, err := os.Stat(s.Path); err != nil {
+			s.err = fmt.Errorf("vault %s: %w", s.Path, ErrSecretNotFound)
+			return
+		}
+		pass, err := s.Passphrase()
+		if err != nil {
+			s.err = err
+			return
+		}
+		s.vault, s.err = OpenVault(s.Path, pass)
+	})
+	if s.err != nil {
+		return "", s.err
+	}
+	if v, ok := s.vault.Get(name); ok && v != "" {
+		return v, nil
+	}
+	return "", fmt.Errorf("vault %s: %s: %w", s.Path, name, ErrSecretNotFound)
+}
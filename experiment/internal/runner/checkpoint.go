@@ -0,0 +1,122 @@
+// internal/runner/checkpoint.go
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// CheckpointFile : 실행 디렉터리 안의 체크포인트 파일 이름
+const CheckpointFile = "checkpoint.json"
+
+// CheckpointVersion : Checkpoint 형식 버전 (입력 해시에 넣는 값이 바뀌면 올립니다)
+const CheckpointVersion = 1
+
+// Checkpoint 는 실행 디렉터리에서 끝난 단계를 입력 해시와 함께 기록합니다 (<실행 디렉터리>/checkpoint.json).
+// 단계가 끝날 때마다 다시 쓰므로, 중간에 실패하거나 중단된 실행도 끝난 단계까지는 남습니다.
+// 이어 하기(resume)는 성공했고 입력이 같으며 출력 사본이 그대로인 단계만 건너뛰고,
+// 실패했거나 입력·출력이 바뀐(stale) 단계는 다시 실행합니다.
+type Checkpoint struct {
+	Version int                        `json:"version"`
+	Steps   map[string]CheckpointEntry `json:"steps"` // 키: 단계 이름 (specgen 은 산출물, run_task 는 태스크 이름)
+}
+
+// CheckpointEntry : 끝난 단계 하나
+type CheckpointEntry struct {
+	InputHash  string    `json:"input_hash"`
+	Status     string    `json:"status"`                // StepOK | StepCached | StepFail | StepError
+	Output     string    `json:"output,omitempty"`      // 실행 디렉터리 기준 출력 사본
+	OutputHash string    `json:"output_hash,omitempty"` // Output 내용의 PromptHash
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+// NewCheckpoint 는 빈 체크포인트를 만듭니다.
+func NewCheckpoint() *Checkpoint {
+	return &Checkpoint{Version: CheckpointVersion, Steps: map[string]CheckpointEntry{}}
+}
+
+// ReadCheckpoint 는 실행 디렉터리의 checkpoint.json 을 읽습니다 (없으면 os.ErrNotExist).
+// 형식 버전이 다르면 입력 해시를 비교할 수 없으므로 오류입니다.
+func ReadCheckpoint(dir string) (*Checkpoint, error) {
+	b, err := os.ReadFile(filepath.Join(dir, CheckpointFile))
+	if err != nil {
+		return nil, err
+	}
+	var c Checkpoint
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, fmt.Errorf("%s: %w", CheckpointFile, err)
+	}
+	if c.Version != CheckpointVersion {
+		return nil, fmt.Errorf("%s: version %d is not supported (want %d)", CheckpointFile, c.Version, CheckpointVersion)
+	}
+	if c.Steps == nil {
+		c.Steps = map[string]CheckpointEntry{}
+	}
+	return &c, nil
+}
+
+// Write 는 dir/checkpoint.json 을 임시 파일 + rename 으로 씁니다.
+func (c *Checkpoint) Write(dir string) error {
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, CheckpointFile), b)
+}
+
+// Record 는 key 단계의 결과를 기록합니다. output 은 실행 디렉터리 dir 안의 출력 사본 경로입니다 (없으면 "").
+func (c *Checkpoint) Record(dir, key, inputHash, status, output string) error {
+	e := CheckpointEntry{InputHash: inputHash, Status: status, FinishedAt: time.Now()}
+	if output != "" {
+		b, err := os.ReadFile(output)
+		if err != nil {
+			return err
+		}
+		e.Output, e.OutputHash = relPath(dir, output), PromptHash(string(b))
+	}
+	c.Steps[key] = e
+	return nil
+}
+
+// Reusable 은 key 단계를 다시 실행하지 않고 건너뛸 수 있는지 봅니다.
+// 건너뛸 수 있으면 reason 이 "" 이고, 아니면 다시 실행하는 이유를 돌려줍니다.
+func (c *Checkpoint) Reusable(dir, key, inputHash string) (e CheckpointEntry, reason string) {
+	e, ok := c.Steps[key]
+	switch {
+	case !ok:
+		return e, "not run yet"
+	case e.Status != StepOK && e.Status != StepCached:
+		return e, "previous attempt " + e.Status
+	case e.InputHash != inputHash:
+		return e, "stale: inputs changed"
+	case e.Output == "":
+		return e, ""
+	}
+	b, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(e.Output)))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return e, "stale: output copy is missing"
+	case err != nil:
+		return e, "stale: " + err.Error()
+	case PromptHash(string(b)) != e.OutputHash:
+		return e, "stale: output copy was modified"
+	}
+	return e, ""
+}
+
+// InputHash 는 단계 출력을 결정하는 입력(프롬프트, 모델 태그, 설정 등)으로 만든 해시입니다 (sha256 앞 16자).
+// 값 사이에 구분자를 넣으므로 ("ab", "c") 와 ("a", "bc") 는 다른 해시가 됩니다.
+func InputHash(parts ...string) string {
+	h := sha256.New()
+	for _, p := range parts {
+		h.Write([]byte(NormalizeNewlines(p)))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
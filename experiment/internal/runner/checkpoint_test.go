@@ -0,0 +1,187 @@
+// internal/runner/checkpoint_test.go
+package runner_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"speckit-study/internal/fakellm"
+	"speckit-study/internal/llm"
+	"speckit-study/internal/runner"
+)
+
+func TestCheckpointReusable(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "plan.md")
+	writeRunFile(t, out, "## Steps\n")
+
+	c := runner.NewCheckpoint()
+	for _, step := range []struct{ key, hash, status, output string }{
+		{"plan", "h1", runner.StepOK, out},
+		{"notes", "h2", runner.StepCached, ""},
+		{"tasks", "h3", runner.StepFail, ""},
+	} {
+		if err := c.Record(dir, step.key, step.hash, step.status, step.output); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := c.Write(dir); err != nil {
+		t.Fatal(err)
+	}
+	c, err := runner.ReadCheckpoint(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e := c.Steps["plan"]; e.Output != "plan.md" || e.OutputHash == "" {
+		t.Errorf("plan entry = %+v, want the output relative to the run dir", e)
+	}
+
+	reason := func(key, hash string) string {
+		_, r := c.Reusable(dir, key, hash)
+		return r
+	}
+	for _, cc := range []struct{ key, hash, want string }{
+		{"plan", "h1", ""},
+		{"notes", "h2", ""},
+		{"spec", "h0", "not run yet"},
+		{"tasks", "h3", "previous attempt fail"},
+		{"plan", "other", "stale: inputs changed"},
+	} {
+		if got := reason(cc.key, cc.hash); got != cc.want {
+			t.Errorf("Reusable(%s, %s) = %q, want %q", cc.key, cc.hash, got, cc.want)
+		}
+	}
+	// CRLF 로만 바뀐 사본은 그대로로 봅니다.
+	writeRunFile(t, out, "## Steps\r\n")
+	if got := reason("plan", "h1"); got != "" {
+		t.Errorf("CRLF copy: %q", got)
+	}
+	writeRunFile(t, out, "## Steps\nedited by hand\n")
+	if got := reason("plan", "h1"); got != "stale: output copy was modified" {
+		t.Errorf("modified copy: %q", got)
+	}
+	os.Remove(out)
+	if got := reason("plan", "h1"); got != "stale: output copy is missing" {
+		t.Errorf("missing copy: %q", got)
+	}
+
+	writeRunFile(t, filepath.Join(dir, runner.CheckpointFile), `{"version": 99, "steps": {}}`)
+	if _, err := runner.ReadCheckpoint(dir); err == nil || !strings.Contains(err.Error(), "version 99") {
+		t.Errorf("future version: got %v", err)
+	}
+	if _, err := runner.ReadCheckpoint(t.TempDir()); !os.IsNotExist(err) {
+		t.Errorf("no checkpoint: got %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestInputHash(t *testing.T) {
+	if runner.InputHash("ab", "c") == runner.InputHash("a", "bc") {
+		t.Error("parts are not separated")
+	}
+	if runner.InputHash("a\r\nb") != runner.InputHash("a\nb") {
+		t.Error("line endings change the hash")
+	}
+	if h := runner.InputHash("x"); len(h) != 16 {
+		t.Errorf("hash %q, want 16 hex chars", h)
+	}
+}
+
+const resumeTasks = `tasks:
+  - name: archive
+    description: Describe the archive job
+    required_sections: ["Steps", "Failure Handling"]
+    max_attempts: 1
+  - name: purge
+    description: Describe the purge job
+    required_sections: ["Steps", "Failure Handling"]
+    max_attempts: 1
+  - name: export
+    description: Describe the export job
+    required_sections: ["Steps", "Failure Handling"]
+    max_attempts: 1
+`
+
+// 이어 하기는 통과했고 입력과 출력 사본이 그대로인 태스크만 건너뜁니다.
+func TestRunTasksResume(t *testing.T) {
+	// export 는 첫 실행에서만 섹션이 빠져 실패합니다.
+	srv := fakellm.NewTestServer(fakellm.Script{
+		Rules:   []fakellm.Rule{{Match: "export job", Times: 1, Reply: fakellm.Reply{Text: halfDraft}}},
+		Default: fakellm.Reply{Text: fullDraft},
+	})
+	defer srv.Close()
+	reg := llm.NewModelRegistry()
+	reg.RegisterModel("writer", llm.NewOpenAICompatibleClient(srv.OpenAIBaseURL(), "writer-model"))
+
+	feature := writeFeature(t, resumeTasks)
+	opts := runner.TaskRunOptions{FeatureDir: feature, ModelTag: "writer", OutDir: t.TempDir()}
+	// run 은 이어 하기 한 번을 돌리고, 다시 실행된 태스크 이름을 돌려줍니다.
+	run := func(resume bool) (*runner.TaskReport, string) {
+		t.Helper()
+		before := len(srv.Fake.Calls())
+		opts.Resume = resume
+		report, err := runner.RunTasks(context.Background(), reg, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var rerun []string
+		for _, res := range report.Results {
+			if !res.Resumed {
+				rerun = append(rerun, res.Name)
+			}
+		}
+		if calls := len(srv.Fake.Calls()) - before; calls != len(rerun) {
+			t.Errorf("server saw %d calls for %d re-run tasks", calls, len(rerun))
+		}
+		return report, strings.Join(rerun, ",")
+	}
+
+	report, _ := run(false)
+	if report.Failed() != 1 || report.Results[2].Passed {
+		t.Fatalf("first run: %d failed", report.Failed())
+	}
+	archive := report.Results[0].Output
+
+	// 실패한 태스크만 다시 실행합니다.
+	report, rerun := run(true)
+	if rerun != "export" || report.Failed() != 0 {
+		t.Errorf("resume after failure re-ran %q, failed=%d", rerun, report.Failed())
+	}
+	if res := report.Results[0]; res.Output != archive || !res.Passed {
+		t.Errorf("resumed archive = %+v", res)
+	}
+
+	// 출력 사본을 손으로 고치면 그 태스크를 다시 실행합니다.
+	writeRunFile(t, archive, "## Steps\nedited by hand\n")
+	if _, rerun := run(true); rerun != "archive" {
+		t.Errorf("modified output copy: re-ran %q", rerun)
+	}
+	if b, _ := os.ReadFile(archive); string(b) != fullDraft {
+		t.Errorf("archive output not regenerated: %q", b)
+	}
+
+	// 태스크 설명(프롬프트)이 바뀌면 입력 해시가 달라져 다시 실행합니다.
+	writeRunFile(t, filepath.Join(feature, "tasks.yaml"), strings.Replace(resumeTasks, "the purge job", "the nightly purge job", 1))
+	if _, rerun := run(true); rerun != "purge" {
+		t.Errorf("changed input: re-ran %q", rerun)
+	}
+	if _, rerun := run(true); rerun != "" {
+		t.Errorf("nothing changed: re-ran %q", rerun)
+	}
+
+	// 이어 하기는 같은 매니페스트를 다시 열어 이어 씁니다.
+	man, err := runner.ReadManifest(opts.OutDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if man.Kind != runner.RunKindTask || man.Status != runner.RunCompleted || len(man.ResumedAt) != 4 || len(man.Steps) != 3 {
+		t.Errorf("manifest kind=%s status=%s resumed=%d steps=%d", man.Kind, man.Status, len(man.ResumedAt), len(man.Steps))
+	}
+
+	opts.OutDir = t.TempDir()
+	if _, err := runner.RunTasks(context.Background(), reg, opts); err == nil || !strings.Contains(err.Error(), runner.CheckpointFile) {
+		t.Errorf("resume without a checkpoint: got %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+// internal/runner/diff.go
+package runner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// maxDiffLines : 이보다 긴 문서는 LCS 표가 너무 커지므로 줄 단위 비교를 포기하고 통째로 바뀐 것으로 봅니다.
+const maxDiffLines = 3000
+
+// DiffOp : 줄 단위 비교 결과 한 줄 (' ' 같음, '-' a 에만, '+' b 에만)
+type DiffOp struct {
+	Kind byte
+	Text string
+}
+
+// DiffLines 는 a 와 b 를 줄 단위 최장 공통 부분열(LCS)로 비교합니다.
+func DiffLines(a, b string) []DiffOp {
+	al := splitLines(a)
+	bl := splitLines(b)
+	if len(al) > maxDiffLines || len(bl) > maxDiffLines {
+		ops := make([]DiffOp, 0, len(al)+len(bl))
+		for _, l := range al {
+			ops = append(ops, DiffOp{'-', l})
+		}
+		for _, l := range bl {
+			ops = append(ops, DiffOp{'+', l})
+		}
+		return ops
+	}
+
+	// lcs[i][j] : al[i:] 와 bl[j:] 의 LCS 길이
+	lcs := make([][]int, len(al)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(bl)+1)
+	}
+	for i := len(al) - 1; i >= 0; i-- {
+		for j := len(bl) - 1; j >= 0; j-- {
+			if al[i] == bl[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var ops []DiffOp
+	i, j := 0, 0
+	for i < len(al) && j < len(bl) {
+		switch {
+		case al[i] == bl[j]:
+			ops = append(ops, DiffOp{' ', al[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			ops = append(ops, DiffOp{'-', al[i]})
+			i++
+		default:
+			ops = append(ops, DiffOp{'+', bl[j]})
+			j++
+		}
+	}
+	for ; i < len(al); i++ {
+		ops = append(ops, DiffOp{'-', al[i]})
+	}
+	for ; j < len(bl); j++ {
+		ops = append(ops, DiffOp{'+', bl[j]})
+	}
+	return ops
+}
+
+// UnifiedDiff 는 DiffLines 결과를 변경 주변 context 줄만 남긴 unified diff 형식으로 씁니다 (같으면 "").
+func UnifiedDiff(aName, bName, a, b string, context int) string {
+	ops := DiffLines(a, b)
+	changed := make([]bool, len(ops))
+	hasChange := false
+	for k, op := range ops {
+		if op.Kind != ' ' {
+			for c := max(0, k-context); c <= min(len(ops)-1, k+context); c++ {
+				changed[c] = true
+			}
+			hasChange = true
+		}
+	}
+	if !hasChange {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+	ai, bi := 1, 1 // 다음 줄 번호
+	for k := 0; k < len(ops); {
+		if !changed[k] {
+			ai, bi = advance(ops[k], ai, bi)
+			k++
+			continue
+		}
+		end := k
+		for end < len(ops) && changed[end] {
+			end++
+		}
+		an, bn := 0, 0
+		for _, op := range ops[k:end] {
+			if op.Kind != '+' {
+				an++
+			}
+			if op.Kind != '-' {
+				bn++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", ai, an, bi, bn)
+		for _, op := range ops[k:end] {
+			fmt.Fprintf(&sb, "%c%s\n", op.Kind, op.Text)
+			ai, bi = advance(op, ai, bi)
+		}
+		k = end
+	}
+	return sb.String()
+}
+
+func advance(op DiffOp, ai, bi int) (int, int) {
+	if op.Kind != '+' {
+		ai++
+	}
+	if op.Kind != '-' {
+		bi++
+	}
+	return ai, bi
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(NormalizeNewlines(s), "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
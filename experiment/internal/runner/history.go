@@ -0,0 +1,398 @@
+// internal/runner/history.go
+package runner
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RunInfo 는 _runs 아래 실행 하나입니다.
+// manifest.json 이 없는 예전 형식(스모크 출력 디렉터리, run_task 의 report.json, <ts>_specgen.log)은
+// 디렉터리 내용으로 Manifest 를 재구성하고 Legacy 를 참으로 둡니다.
+type RunInfo struct {
+	Dir      string // 실행 디렉터리 (예전 specgen 로그는 _runs 디렉터리)
+	Manifest *RunManifest
+	Legacy   bool
+	Files    []string // 디렉터리 대신 파일로만 남은 실행(예전 specgen 로그)의 파일들
+}
+
+// OutputPath 는 단계 출력의 실제 경로입니다 (출력 사본이 없으면 "").
+func (r RunInfo) OutputPath(s RunStep) string {
+	if s.Output == "" {
+		return ""
+	}
+	return filepath.Join(r.Dir, filepath.FromSlash(s.Output))
+}
+
+// Step 은 Artifact 가 artifact 인 단계를 찾습니다.
+func (r RunInfo) Step(artifact string) (RunStep, bool) { return r.Manifest.Step(artifact) }
+
+var (
+	runTSRe        = regexp.MustCompile(`^\d{8}_\d{6}(-\d+)?$`)
+	legacySpecgen  = regexp.MustCompile(`^(\d{8}_\d{6})_specgen\.log$`)
+	legacySmokeOut = regexp.MustCompile(`^(.+?)-try(\d+)-(.+)\.md$`)
+)
+
+// ScanRuns 는 runsDir(<.specify>/_runs) 아래 실행을 최신순으로 모두 읽습니다.
+// 읽을 수 없는 실행은 건너뛰지 않고 오류를 모아 함께 반환합니다.
+func ScanRuns(runsDir string) ([]RunInfo, error) {
+	entries, err := os.ReadDir(runsDir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var runs []RunInfo
+	var errs []error
+	for _, e := range entries {
+		if !e.IsDir() {
+			if m := legacySpecgen.FindStringSubmatch(e.Name()); m != nil {
+				r, err := readLegacySpecgen(runsDir, m[1])
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				runs = append(runs, r)
+			}
+			continue
+		}
+		name := e.Name()
+		sub, err := os.ReadDir(filepath.Join(runsDir, name))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, s := range sub {
+			if !s.IsDir() || !runTSRe.MatchString(s.Name()) {
+				continue
+			}
+			r, err := readRun(filepath.Join(runsDir, name, s.Name()), name, s.Name())
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			runs = append(runs, r)
+		}
+	}
+	sort.SliceStable(runs, func(i, j int) bool {
+		a, b := runs[i].Manifest, runs[j].Manifest
+		if !a.StartedAt.Equal(b.StartedAt) {
+			return a.StartedAt.After(b.StartedAt)
+		}
+		return a.RunID > b.RunID
+	})
+	return runs, errors.Join(errs...)
+}
+
+// readRun : manifest.json → run_task 의 report.json → 스모크 출력 파일 순서로 해석합니다.
+// 어느 것도 없으면 Kind 를 비운 채 돌려줍니다.
+func readRun(dir, name, ts string) (RunInfo, error) {
+	m, err := ReadManifest(dir)
+	if err == nil {
+		return RunInfo{Dir: dir, Manifest: m}, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return RunInfo{}, fmt.Errorf("%s: %w", dir, err)
+	}
+
+	m = &RunManifest{RunID: name + "/" + ts, Name: name, StartedAt: parseRunTS(ts), Status: "unknown"}
+	if b, err := os.ReadFile(filepath.Join(dir, "report.json")); err == nil {
+		var rep TaskReport
+		if err := json.Unmarshal(b, &rep); err != nil {
+			return RunInfo{}, fmt.Errorf("%s: report.json: %w", dir, err)
+		}
+		m.Kind, m.Status, m.StartedAt = RunKindTask, RunCompleted, rep.StartedAt
+		m.Planned = len(rep.Results)
+		for _, res := range rep.Results {
+			m.Steps = append(m.Steps, taskStep(res))
+		}
+		return RunInfo{Dir: dir, Manifest: m, Legacy: true}, nil
+	}
+
+	steps, err := legacySmokeSteps(dir)
+	if err != nil {
+		return RunInfo{}, fmt.Errorf("%s: %w", dir, err)
+	}
+	_, logErr := os.Stat(filepath.Join(dir, "calls.log"))
+	if len(steps) == 0 && logErr != nil {
+		// 스모크 흔적도 없는 디렉터리는 종류를 짐작하지 않습니다 (Kind "").
+		return RunInfo{Dir: dir, Manifest: m, Legacy: true}, nil
+	}
+	m.Kind = RunKindSmoke
+	m.Steps, m.Planned = steps, len(steps)
+	if logErr == nil {
+		m.Status = RunCompleted
+	}
+	return RunInfo{Dir: dir, Manifest: m, Legacy: true}, nil
+}
+
+func taskStep(res TaskResult) RunStep {
+	s := RunStep{
+		Artifact: res.Name + ".md",
+		Tag:      res.Tag,
+		Model:    res.Model,
+		Status:   StepOK,
+		Latency:  res.Latency,
+		Usage:    res.Usage,
+		Attempts: res.Attempts,
+		Cached:   res.Cached,
+		Error:    res.Error,
+	}
+	if res.Output != "" {
+		s.Output = filepath.ToSlash(filepath.Base(res.Output))
+	}
+	switch {
+	case res.Error != "":
+		s.Status = StepError
+	case !res.Passed:
+		s.Status = StepFail
+	}
+	if res.Error == "" {
+		s.Validation = &StepValidation{Passed: res.Passed, Judge: res.Judgement}
+		for _, sec := range res.Missing {
+			s.Validation.Problems = append(s.Validation.Problems, fmt.Sprintf("missing section %q", sec))
+		}
+		if res.JudgeError != "" {
+			s.Validation.Problems = append(s.Validation.Problems, res.JudgeError)
+		} else if res.Judgement != nil && !res.Judgement.Passed {
+			s.Validation.Problems = append(s.Validation.Problems, res.Judgement.Problem())
+		}
+	}
+	return s
+}
+
+// legacySmokeSteps : calls.log 가 있으면 그 순서대로, 없으면 <tag>-try<N>-<model>.md 파일 이름으로 단계를 만듭니다.
+func legacySmokeSteps(dir string) ([]RunStep, error) {
+	var steps []RunStep
+	if f, err := os.Open(filepath.Join(dir, "calls.log")); err == nil {
+		defer f.Close()
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			line := sc.Text()
+			if strings.HasPrefix(line, "\t") { // "\tretried: ..." 줄
+				if n := len(steps); n > 0 {
+					steps[n-1].Retried = append(steps[n-1].Retried, strings.TrimPrefix(strings.TrimSpace(line), "retried: "))
+				}
+				continue
+			}
+			fields := strings.Split(line, "\t")
+			if len(fields) < 2 {
+				continue
+			}
+			s := RunStep{Tag: fields[0], Status: StepOK}
+			for _, f := range fields[1:] {
+				k, v, _ := strings.Cut(f, "=")
+				switch {
+				case f == "cached":
+					s.Status, s.Cached = StepCached, true
+				case strings.HasPrefix(f, "error: "):
+					s.Status, s.Error = StepError, strings.TrimPrefix(f, "error: ")
+				case k == "try":
+					s.Try, _ = strconv.Atoi(v)
+				case k == "model":
+					s.Model = v
+				case k == "attempts":
+					s.Attempts, _ = strconv.Atoi(v)
+				case k == "latency":
+					s.Latency, _ = time.ParseDuration(v)
+				}
+			}
+			s.Artifact = fmt.Sprintf("%s-try%d", s.Tag, s.Try)
+			s.Output = fmt.Sprintf("%s-try%d-%s.md", s.Tag, s.Try, s.Model)
+			steps = append(steps, s)
+		}
+		return steps, sc.Err()
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		m := legacySmokeOut.FindStringSubmatch(e.Name())
+		if m == nil {
+			continue
+		}
+		try, _ := strconv.Atoi(m[2])
+		s := RunStep{Artifact: fmt.Sprintf("%s-try%d", m[1], try), Tag: m[1], Try: try, Model: m[3], Output: e.Name(), Status: StepOK}
+		if b, err := os.ReadFile(filepath.Join(dir, e.Name())); err == nil && strings.HasPrefix(string(b), "ERROR calling model") {
+			s.Status, s.Error = StepError, strings.TrimSpace(string(b))
+		}
+		steps = append(steps, s)
+	}
+	return steps, nil
+}
+
+// readLegacySpecgen : 예전 specgen 의 _runs/<ts>_specgen.log (대상별 "path\ttag=\tmodel=\tsource=\tlatency=" 줄)
+func readLegacySpecgen(runsDir, ts string) (RunInfo, error) {
+	logPath := filepath.Join(runsDir, ts+"_specgen.log")
+	b, err := os.ReadFile(logPath)
+	if err != nil {
+		return RunInfo{}, err
+	}
+	m := &RunManifest{RunID: "specgen/" + ts, Kind: RunKindSpecgen, Name: "specgen", StartedAt: parseRunTS(ts), Status: "unknown"}
+	for _, line := range strings.Split(string(b), "\n") {
+		if line == "specgen completed" {
+			m.Status = RunCompleted
+		}
+		fields := strings.Split(line, "\t")
+		if len(fields) < 2 || !strings.Contains(fields[1], "=") {
+			continue
+		}
+		s := RunStep{Artifact: filepath.Base(fields[0]), Target: fields[0], Status: StepOK}
+		for _, f := range fields[1:] {
+			k, v, _ := strings.Cut(f, "=")
+			switch k {
+			case "tag":
+				s.Tag = v
+			case "model":
+				s.Model = v
+			case "source":
+				if v == "cache" {
+					s.Status, s.Cached = StepCached, true
+				}
+			case "latency":
+				s.Latency, _ = time.ParseDuration(v)
+			}
+		}
+		if s.Tag == "" { // "cache\thits=..\tmisses=.." 줄
+			continue
+		}
+		m.Steps = append(m.Steps, s)
+	}
+	m.Planned = len(m.Steps)
+	files := []string{logPath}
+	if cost := filepath.Join(runsDir, ts+"_specgen_cost.json"); fileExists(cost) {
+		files = append(files, cost)
+	}
+	return RunInfo{Dir: runsDir, Manifest: m, Legacy: true, Files: files}, nil
+}
+
+func parseRunTS(ts string) time.Time {
+	base, _, _ := strings.Cut(ts, "-")
+	t, err := time.ParseInLocation("20060102_150405", base, time.Local)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// ---- 조회 ----
+
+// RunFilter : ListRuns 조건 (빈 값은 조건 없음)
+type RunFilter struct {
+	Name   string // 실행 이름(스모크 ID, 기능 이름, "specgen")
+	Model  string // 어떤 단계의 태그나 모델 이름과 같으면 통과
+	Status string
+	Since  time.Time
+	Until  time.Time
+}
+
+// Match 는 r 이 조건을 모두 만족하는지 반환합니다.
+func (f RunFilter) Match(r RunInfo) bool {
+	m := r.Manifest
+	switch {
+	case f.Name != "" && m.Name != f.Name:
+		return false
+	case f.Status != "" && m.Status != f.Status:
+		return false
+	case !f.Since.IsZero() && m.StartedAt.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !m.StartedAt.Before(f.Until):
+		return false
+	case f.Model != "" && !slices.ContainsFunc(m.Steps, func(s RunStep) bool { return s.Tag == f.Model || s.Model == f.Model }):
+		return false
+	}
+	return true
+}
+
+// FindRun 은 실행 ID("<이름>/<타임스탬프>"), 그 앞부분, 또는 실행 디렉터리 경로로 실행 하나를 찾습니다.
+func FindRun(runs []RunInfo, ref string) (RunInfo, error) {
+	ref = strings.TrimSuffix(filepath.ToSlash(ref), "/")
+	var found []RunInfo
+	for _, r := range runs {
+		id := r.Manifest.RunID
+		switch {
+		case id == ref, r.Files == nil && filepath.ToSlash(r.Dir) == ref:
+			return r, nil
+		case strings.HasPrefix(id, ref), strings.HasSuffix(filepath.ToSlash(r.Dir), "/"+ref):
+			found = append(found, r)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return RunInfo{}, fmt.Errorf("no run matches %q", ref)
+	case 1:
+		return found[0], nil
+	}
+	ids := make([]string, len(found))
+	for i, r := range found {
+		ids[i] = r.Manifest.RunID
+	}
+	return RunInfo{}, fmt.Errorf("run %q is ambiguous: %s", ref, strings.Join(ids, ", "))
+}
+
+// ---- 정리 ----
+
+// RunPruneOptions : PruneRuns 조건. 둘 다 주면 어느 하나에 걸리는 실행을 지웁니다.
+type RunPruneOptions struct {
+	OlderThan time.Duration // 시작한 지 이보다 오래된 실행
+	Keep      int           // 이름별로 최신 Keep 개만 남김 (0 이면 개수 제한 없음)
+}
+
+// PruneRuns 는 runs(최신순) 중 조건에 걸리는 실행을 지우고 지운 실행을 반환합니다. dryRun 이면 지우지 않습니다.
+// running 상태의 실행은 OlderThan 에 걸릴 때만 지웁니다 (Keep 만으로는 진행 중일 수 있는 실행을 지우지 않음).
+func PruneRuns(runs []RunInfo, opts RunPruneOptions, now time.Time, dryRun bool) ([]RunInfo, error) {
+	var removed []RunInfo
+	var errs []error
+	perName := map[string]int{}
+	for _, r := range runs {
+		m := r.Manifest
+		perName[m.Name]++
+		old := opts.OlderThan > 0 && now.Sub(m.StartedAt) > opts.OlderThan
+		extra := opts.Keep > 0 && perName[m.Name] > opts.Keep
+		if !(old || extra) || (m.Status == RunRunning && !old) {
+			continue
+		}
+		if !dryRun {
+			if err := r.remove(); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		removed = append(removed, r)
+	}
+	return removed, errors.Join(errs...)
+}
+
+func (r RunInfo) remove() error {
+	if r.Files != nil {
+		var errs []error
+		for _, f := range r.Files {
+			errs = append(errs, os.Remove(f))
+		}
+		return errors.Join(errs...)
+	}
+	if err := os.RemoveAll(r.Dir); err != nil {
+		return err
+	}
+	// 이름 디렉터리가 비면 같이 지웁니다 (비어 있지 않으면 실패를 무시).
+	_ = os.Remove(filepath.Dir(r.Dir))
+	return nil
+}
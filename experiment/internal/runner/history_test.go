@@ -0,0 +1,222 @@
+// internal/runner/history_test.go
+package runner_test
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"speckit-study/internal/runner"
+)
+
+func writeRunFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// historyFixture 는 새 형식(manifest.json)과 예전 형식 실행이 섞인 _runs 디렉터리를 만듭니다.
+func historyFixture(t *testing.T) string {
+	t.Helper()
+	runs := t.TempDir()
+	started := time.Date(2024, 5, 1, 12, 0, 0, 0, time.Local)
+
+	m := &runner.RunManifest{Version: runner.ManifestVersion, RunID: "smoke-a/20240501_120000", Kind: runner.RunKindSmoke, Name: "smoke-a",
+		StartedAt: started, Status: runner.RunCompleted, Planned: 2,
+		Steps: []runner.RunStep{{Artifact: "claude-try1", Tag: "claude", Try: 1, Model: "claude-x", Status: runner.StepOK}}}
+	if err := m.Write(filepath.Join(runs, "smoke-a", "20240501_120000")); err != nil {
+		t.Fatal(err)
+	}
+	// 중단된 채 남은 실행 (running)
+	m.RunID, m.StartedAt, m.Status = "smoke-a/20240430_120000", started.Add(-24*time.Hour), runner.RunRunning
+	if err := m.Write(filepath.Join(runs, "smoke-a", "20240430_120000")); err != nil {
+		t.Fatal(err)
+	}
+
+	// 예전 스모크: calls.log 가 있는 실행과 출력 파일만 남은 실행
+	writeRunFile(t, filepath.Join(runs, "smoke-a", "20240301_090000", "calls.log"),
+		"gpt\ttry=1\tmodel=gpt-x\tattempts=2\tlatency=1.5s\tqueued=0s\tok\n\tretried: HTTP 429\n"+
+			"gpt\ttry=2\tmodel=gpt-x\tattempts=1\tlatency=0s\tqueued=0s\terror: HTTP 400 bad request\n"+
+			"gpt\ttry=3\tmodel=gpt-x\tattempts=1\tlatency=10ms\tqueued=0s\tcached\n")
+	writeRunFile(t, filepath.Join(runs, "smoke-a", "20240201_090000", "fast-try1-m.md"), "## Steps\n")
+	writeRunFile(t, filepath.Join(runs, "smoke-a", "20240201_090000", "slow-try1-x.md"), "ERROR calling model x: timeout\n")
+
+	// 예전 run_task: report.json 만 있는 실행
+	rep := runner.TaskReport{Feature: "audit-log", StartedAt: time.Date(2024, 4, 1, 10, 0, 0, 0, time.Local), Results: []runner.TaskResult{
+		{Name: "api_design", Tag: "writer", Output: "/old/place/api_design.md", Passed: true},
+		{Name: "retention_job", Tag: "writer", Output: "/old/place/retention_job.md", Missing: []string{"Failure Handling"}},
+	}}
+	b, _ := json.Marshal(rep)
+	writeRunFile(t, filepath.Join(runs, "audit-log", "20240401_100000", "report.json"), string(b))
+
+	// 예전 specgen 로그와 비용 파일
+	writeRunFile(t, filepath.Join(runs, "20240115_080000_specgen.log"),
+		"specs/a/plan.md\ttag=claude\tmodel=claude-x\tsource=cache\tlatency=0s\n"+
+			"specs/a/tasks.yaml\ttag=gpt\tmodel=gpt-x\tsource=live\tlatency=2s\n"+
+			"cache\thits=1\tmisses=1\nspecgen completed\n")
+	writeRunFile(t, filepath.Join(runs, "20240115_080000_specgen_cost.json"), "{}")
+	return runs
+}
+
+func TestScanRunsLegacy(t *testing.T) {
+	runsDir := historyFixture(t)
+	writeRunFile(t, filepath.Join(runsDir, "broken", "20240101_000000", "manifest.json"), "{")
+
+	runs, err := runner.ScanRuns(runsDir)
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("got %v, want the unreadable manifest reported", err)
+	}
+	var ids []string
+	for _, r := range runs {
+		ids = append(ids, r.Manifest.RunID)
+	}
+	want := "smoke-a/20240501_120000 smoke-a/20240430_120000 audit-log/20240401_100000 smoke-a/20240301_090000 smoke-a/20240201_090000 specgen/20240115_080000"
+	if got := strings.Join(ids, " "); got != want {
+		t.Fatalf("runs (newest first) =\n%s\nwant\n%s", got, want)
+	}
+	if runs[0].Legacy || !runs[2].Legacy {
+		t.Errorf("legacy flags: %v %v", runs[0].Legacy, runs[2].Legacy)
+	}
+
+	task := runs[2].Manifest
+	if task.Kind != runner.RunKindTask || task.Status != runner.RunCompleted || len(task.Steps) != 2 {
+		t.Fatalf("task run = %+v", task)
+	}
+	if s := task.Steps[1]; s.Status != runner.StepFail || s.Output != "retention_job.md" || s.Validation.Problems[0] != `missing section "Failure Handling"` {
+		t.Errorf("task step = %+v", s)
+	}
+
+	calls := runs[3].Manifest
+	if calls.Status != runner.RunCompleted || len(calls.Steps) != 3 {
+		t.Fatalf("calls.log run = %+v", calls)
+	}
+	s1, s2, s3 := calls.Steps[0], calls.Steps[1], calls.Steps[2]
+	if s1.Attempts != 2 || s1.Latency != 1500*time.Millisecond || len(s1.Retried) != 1 || s1.Retried[0] != "HTTP 429" || s1.Output != "gpt-try1-gpt-x.md" {
+		t.Errorf("step 1 = %+v", s1)
+	}
+	if s2.Status != runner.StepError || s2.Error != "HTTP 400 bad request" || s3.Status != runner.StepCached {
+		t.Errorf("steps 2, 3 = %+v, %+v", s2, s3)
+	}
+
+	files := runs[4].Manifest
+	if files.Status != "unknown" || len(files.Steps) != 2 || files.Steps[1].Status != runner.StepError || files.Steps[0].Artifact != "fast-try1" {
+		t.Errorf("output-only run = %+v", files)
+	}
+
+	spec := runs[5]
+	if spec.Manifest.Status != runner.RunCompleted || len(spec.Manifest.Steps) != 2 || !spec.Manifest.Steps[0].Cached || len(spec.Files) != 2 {
+		t.Errorf("specgen log run = %+v files=%v", spec.Manifest, spec.Files)
+	}
+	if s, ok := spec.Step("tasks.yaml"); !ok || s.Latency != 2*time.Second || s.Target != "specs/a/tasks.yaml" {
+		t.Errorf("specgen step = %+v, %v", s, ok)
+	}
+}
+
+// 아무것도 남기지 않은 실행 디렉터리는 스모크로 짐작하지 않습니다.
+func TestScanRunsEmptyRunDir(t *testing.T) {
+	runsDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(runsDir, "audit-log", "20240601_120000"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	runs, err := runner.ScanRuns(runsDir)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("runs = %v, %v", runs, err)
+	}
+	if m := runs[0].Manifest; m.Kind != "" || m.Status != "unknown" || m.RunID != "audit-log/20240601_120000" {
+		t.Errorf("empty run = %+v", m)
+	}
+}
+
+func TestFindRunAndFilter(t *testing.T) {
+	runs, err := runner.ScanRuns(historyFixture(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for ref, want := range map[string]string{
+		"smoke-a/20240501_120000": "smoke-a/20240501_120000",
+		"smoke-a/20240430":        "smoke-a/20240430_120000",
+		"audit-log":               "audit-log/20240401_100000",
+		runs[3].Dir + "/":         "smoke-a/20240301_090000",
+		"20240115_080000":         "",
+		"specgen/20240115_080000": "specgen/20240115_080000",
+	} {
+		r, err := runner.FindRun(runs, ref)
+		if want == "" {
+			if err == nil {
+				t.Errorf("FindRun(%q) = %s, want no match", ref, r.Manifest.RunID)
+			}
+			continue
+		}
+		if err != nil || r.Manifest.RunID != want {
+			t.Errorf("FindRun(%q) = %v, %v, want %s", ref, r.Manifest.RunID, err, want)
+		}
+	}
+	if _, err := runner.FindRun(runs, "smoke-a"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Errorf("prefix of several runs: got %v", err)
+	}
+
+	count := func(f runner.RunFilter) int {
+		n := 0
+		for _, r := range runs {
+			if f.Match(r) {
+				n++
+			}
+		}
+		return n
+	}
+	if n := count(runner.RunFilter{Model: "gpt-x"}); n != 2 {
+		t.Errorf("model filter matched %d, want the calls.log run and the specgen log", n)
+	}
+	if n := count(runner.RunFilter{Name: "smoke-a", Since: time.Date(2024, 4, 1, 0, 0, 0, 0, time.Local)}); n != 2 {
+		t.Errorf("name+since filter matched %d", n)
+	}
+}
+
+func TestPruneRuns(t *testing.T) {
+	runsDir := historyFixture(t)
+	runs, err := runner.ScanRuns(runsDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 이름별로 최신 Keep 개를 남기지만, Keep 만으로는 running 실행을 지우지 않습니다.
+	removed, err := runner.PruneRuns(runs, runner.RunPruneOptions{Keep: 1}, time.Now(), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, r := range removed {
+		ids = append(ids, r.Manifest.RunID)
+	}
+	if got := strings.Join(ids, " "); got != "smoke-a/20240301_090000 smoke-a/20240201_090000" {
+		t.Fatalf("dry run would remove %s", got)
+	}
+	if after, _ := runner.ScanRuns(runsDir); len(after) != len(runs) {
+		t.Fatal("dry run removed files")
+	}
+
+	now := time.Date(2024, 5, 2, 0, 0, 0, 0, time.Local)
+	removed, err = runner.PruneRuns(runs, runner.RunPruneOptions{OlderThan: 70 * 24 * time.Hour}, now, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 2 {
+		t.Errorf("removed %d runs older than 70 days, want the output-only smoke run and the specgen log", len(removed))
+	}
+	after, _ := runner.ScanRuns(runsDir)
+	if len(after) != len(runs)-2 {
+		t.Errorf("%d runs left, want %d", len(after), len(runs)-2)
+	}
+	for _, f := range []string{"20240115_080000_specgen.log", "20240115_080000_specgen_cost.json"} {
+		if _, err := os.Stat(filepath.Join(runsDir, f)); !os.IsNotExist(err) {
+			t.Errorf("%s still exists", f)
+		}
+	}
+}
@@ -0,0 +1,184 @@
+// internal/runner/judge.go
+package runner
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"speckit-study/internal/llm"
+	"speckit-study/internal/speckit"
+)
+
+// MaxCriterionScore : 심사 모델이 기준 하나에 줄 수 있는 최고 점수
+const MaxCriterionScore = 10
+
+// Evaluator 는 생성된 산출물을 루브릭으로 채점합니다.
+// 제목 검사(ValidateRequiredSections)로는 알 수 없는 내용의 질을 보는 자리이며,
+// 기본 구현은 LLMJudge 입니다.
+type Evaluator interface {
+	Evaluate(ctx context.Context, artifact string, rubric speckit.Rubric) (*Evaluation, error)
+}
+
+// CriterionScore : 기준 하나의 점수와 근거
+type CriterionScore struct {
+	Name      string  `json:"name"`
+	Weight    float64 `json:"weight"`
+	Score     int     `json:"score"` // 0..MaxCriterionScore
+	Rationale string  `json:"rationale"`
+}
+
+// Evaluation : 루브릭 채점 결과
+type Evaluation struct {
+	Judge     string           `json:"judge"` // 심사 모델 태그
+	Model     string           `json:"model"`
+	Score     float64          `json:"score"` // 가중 평균 0..1
+	PassScore float64          `json:"pass_score"`
+	Passed    bool             `json:"passed"`
+	Criteria  []CriterionScore `json:"criteria"` // 루브릭 순서
+	Summary   string           `json:"summary,omitempty"`
+	Usage     llm.Usage        `json:"usage"`
+	Repairs   int              `json:"repairs,omitempty"`
+}
+
+// Problem 은 통과하지 못한 채점을 검사 실패 문구로 씁니다 (통과면 "").
+func (e *Evaluation) Problem() string {
+	if e.Passed {
+		return ""
+	}
+	var low []string
+	for _, c := range e.Criteria {
+		if c.Score*2 < MaxCriterionScore {
+			low = append(low, fmt.Sprintf("%s %d/%d", c.Name, c.Score, MaxCriterionScore))
+		}
+	}
+	p := fmt.Sprintf("judge score %.2f < %.2f", e.Score, e.PassScore)
+	if len(low) > 0 {
+		p += " (" + strings.Join(low, ", ") + ")"
+	}
+	return p
+}
+
+// LLMJudge 는 레지스트리의 모델 하나를 심사 모델로 쓰는 Evaluator 입니다.
+// 구조화 출력(CompleteJSON)만 쓰므로 등록된 어떤 클라이언트(로컬 fakellm 포함)로도 동작합니다.
+type LLMJudge struct {
+	Tag        string
+	Client     llm.LLMClient
+	MaxRepairs int // 스키마 오류 재요청 횟수 (0 이면 llm.DefaultMaxRepairs)
+}
+
+// NewLLMJudge 는 레지스트리에서 tag 모델을 찾아 심사 모델로 씁니다.
+func NewLLMJudge(reg *llm.ModelRegistry, tag string) (*LLMJudge, error) {
+	client, ok := reg.GetModel(tag)
+	if !ok {
+		return nil, fmt.Errorf("judge model not registered: %s", tag)
+	}
+	return &LLMJudge{Tag: tag, Client: client}, nil
+}
+
+const judgeSystem = "You are a strict reviewer of software specification documents. " +
+	"Score the document against each rubric criterion independently, from 0 (absent or unusable) to 10 (fully meets the description). " +
+	"Base every score on the document text only and quote or point to the passage that justifies it in the rationale."
+
+// judgeVerdict : 심사 모델 응답. Criteria 의 키는 루브릭 기준 이름입니다.
+type judgeVerdict struct {
+	Criteria map[string]struct {
+		Score     int    `json:"score"`
+		Rationale string `json:"rationale"`
+	} `json:"criteria"`
+	Summary string `json:"summary"`
+}
+
+// Evaluate 는 산출물과 루브릭을 심사 모델에 보내고 기준별 점수를 가중 평균합니다.
+func (j *LLMJudge) Evaluate(ctx context.Context, artifact string, rubric speckit.Rubric) (*Evaluation, error) {
+	if len(rubric.Criteria) == 0 {
+		return nil, fmt.Errorf("judge: rubric has no criteria")
+	}
+	repairs := j.MaxRepairs
+	if repairs == 0 {
+		repairs = llm.DefaultMaxRepairs
+	}
+	req := llm.GenerateRequest{
+		System:   judgeSystem,
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: judgePrompt(artifact, rubric)}},
+	}
+	var v judgeVerdict
+	resp, err := llm.CompleteJSON(llm.WithCallInfo(ctx, llm.CallInfo{Task: "judge"}), j.Client, req,
+		llm.ResponseFormat{Name: "rubric_scores", Schema: judgeSchema(rubric)}, &v, repairs)
+	if err != nil {
+		return nil, fmt.Errorf("judge %s: %w", j.Tag, err)
+	}
+
+	ev := &Evaluation{
+		Judge:     j.Tag,
+		Model:     resp.Model,
+		PassScore: rubric.Threshold(),
+		Summary:   strings.TrimSpace(v.Summary),
+		Usage:     resp.Usage,
+		Repairs:   resp.Repairs,
+	}
+	var sum, weights float64
+	for _, c := range rubric.Criteria {
+		got := v.Criteria[c.Name]
+		w := c.Weight
+		if w == 0 {
+			w = 1
+		}
+		s := min(max(got.Score, 0), MaxCriterionScore)
+		ev.Criteria = append(ev.Criteria, CriterionScore{Name: c.Name, Weight: w, Score: s, Rationale: strings.TrimSpace(got.Rationale)})
+		sum += w * float64(s)
+		weights += w
+	}
+	if weights > 0 {
+		ev.Score = sum / (weights * MaxCriterionScore)
+	}
+	// 가중 평균의 부동소수점 오차로 기준 점수와 같은 점수가 떨어지지 않도록 조금 여유를 둡니다.
+	ev.Passed = ev.Score >= ev.PassScore-1e-9
+	return ev, nil
+}
+
+func judgePrompt(artifact string, rubric speckit.Rubric) string {
+	var sb strings.Builder
+	sb.WriteString("## Rubric\n\n")
+	for _, c := range rubric.Criteria {
+		fmt.Fprintf(&sb, "- **%s**: %s\n", c.Name, strings.TrimSpace(c.Description))
+	}
+	sb.WriteString("\n## Document\n\n")
+	f := fence(artifact)
+	sb.WriteString(f + "markdown\n" + strings.TrimRight(NormalizeNewlines(artifact), "\n") + "\n" + f + "\n\n")
+	fmt.Fprintf(&sb, "Give every criterion an integer score from 0 to %d with a one or two sentence rationale, then a short overall summary.", MaxCriterionScore)
+	return sb.String()
+}
+
+// judgeSchema : 기준 이름을 속성으로 갖는 객체. 모든 기준이 필수라 빠뜨리거나 중복할 수 없습니다.
+func judgeSchema(rubric speckit.Rubric) llm.Schema {
+	props := map[string]interface{}{}
+	var names []string
+	for _, c := range rubric.Criteria {
+		props[c.Name] = llm.Schema{
+			"type":        "object",
+			"description": c.Description,
+			"properties": map[string]interface{}{
+				"score":     llm.Schema{"type": "integer", "minimum": 0, "maximum": MaxCriterionScore},
+				"rationale": llm.Schema{"type": "string"},
+			},
+			"required":             []string{"score", "rationale"},
+			"additionalProperties": false,
+		}
+		names = append(names, c.Name)
+	}
+	return llm.Schema{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"criteria": llm.Schema{
+				"type":                 "object",
+				"properties":           props,
+				"required":             names,
+				"additionalProperties": false,
+			},
+			"summary": llm.Schema{"type": "string"},
+		},
+		"required":             []string{"criteria", "summary"},
+		"additionalProperties": false,
+	}
+}
@@ -0,0 +1,141 @@
+// internal/runner/judge_test.go
+package runner_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"speckit-study/internal/fakellm"
+	"speckit-study/internal/llm"
+	"speckit-study/internal/runner"
+	"speckit-study/internal/speckit"
+)
+
+var judgeRubric = speckit.Rubric{Criteria: []speckit.Criterion{
+	{Name: "completeness", Description: "every step is listed", Weight: 3},
+	{Name: "clarity", Description: "steps are unambiguous"},
+}}
+
+// newJudge 는 replies 순서로 답하는 서버를 심사 모델로 쓰는 LLMJudge 입니다.
+func newJudge(t *testing.T, replies ...string) (*runner.LLMJudge, *fakellm.TestServer) {
+	t.Helper()
+	script := fakellm.Script{Default: fakellm.Reply{Text: replies[len(replies)-1]}}
+	for _, r := range replies[:len(replies)-1] {
+		script.Rules = append(script.Rules, fakellm.Rule{Times: 1, Reply: fakellm.Reply{Text: r}})
+	}
+	srv := fakellm.NewTestServer(script)
+	t.Cleanup(srv.Close)
+	reg := llm.NewModelRegistry()
+	reg.RegisterModel("judge", llm.NewOpenAICompatibleClient(srv.OpenAIBaseURL(), "judge-model"))
+	j, err := runner.NewLLMJudge(reg, "judge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return j, srv
+}
+
+func TestJudgeWeightedScore(t *testing.T) {
+	cases := []struct {
+		name   string
+		reply  string
+		rubric speckit.Rubric
+		score  float64
+		passed bool
+	}{
+		// (3*8 + 1*4) / (4*10)
+		{"weighted", `{"criteria":{"completeness":{"score":8,"rationale":"all steps"},"clarity":{"score":4,"rationale":"vague"}},"summary":"ok"}`, judgeRubric, 0.7, true},
+		// (3*6 + 1*6) / 40 = 0.6 은 기본 기준과 같아 통과합니다.
+		{"at threshold", `{"criteria":{"completeness":{"score":6,"rationale":"r"},"clarity":{"score":6,"rationale":"r"}},"summary":""}`, judgeRubric, 0.6, true},
+		{"custom pass score", `{"criteria":{"completeness":{"score":8,"rationale":"r"},"clarity":{"score":4,"rationale":"r"}},"summary":""}`,
+			speckit.Rubric{Criteria: judgeRubric.Criteria, PassScore: 0.75}, 0.7, false},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			j, srv := newJudge(t, c.reply)
+			ev, err := j.Evaluate(context.Background(), "## Steps\n1. copy\n", c.rubric)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := ev.Score - c.score; diff > 1e-9 || diff < -1e-9 || ev.Passed != c.passed || ev.PassScore != c.rubric.Threshold() {
+				t.Errorf("score %v passed %v (pass %v), want %v %v", ev.Score, ev.Passed, ev.PassScore, c.score, c.passed)
+			}
+			if ev.Judge != "judge" || ev.Model != "judge-model" || len(ev.Criteria) != 2 || ev.Criteria[0].Name != "completeness" || ev.Criteria[1].Weight != 1 {
+				t.Errorf("evaluation = %+v", ev)
+			}
+			// 산출물과 기준 설명이 프롬프트에, 기준 이름이 스키마에 들어갑니다.
+			call := srv.Fake.Calls()[0]
+			if !strings.Contains(call.Prompt, "1. copy") || !strings.Contains(call.Prompt, "every step is listed") || call.Schema == nil {
+				t.Errorf("judge request prompt=%q schema=%v", call.Prompt, call.Schema)
+			}
+		})
+	}
+}
+
+// 기준을 빠뜨린 응답은 스키마 오류로 다시 요청합니다.
+func TestJudgeRepairsMissingCriterion(t *testing.T) {
+	j, srv := newJudge(t,
+		`{"criteria":{"completeness":{"score":9,"rationale":"r"}},"summary":""}`,
+		`{"criteria":{"completeness":{"score":9,"rationale":"r"},"clarity":{"score":9,"rationale":"r"}},"summary":"good"}`)
+	ev, err := j.Evaluate(context.Background(), "doc", judgeRubric)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ev.Repairs != 1 || len(srv.Fake.Calls()) != 2 || !ev.Passed || ev.Summary != "good" {
+		t.Errorf("repairs=%d calls=%d evaluation=%+v", ev.Repairs, len(srv.Fake.Calls()), ev)
+	}
+
+	if _, err := j.Evaluate(context.Background(), "doc", speckit.Rubric{}); err == nil {
+		t.Error("empty rubric: want an error")
+	}
+	if _, err := runner.NewLLMJudge(llm.NewModelRegistry(), "nope"); err == nil {
+		t.Error("unregistered judge tag: want an error")
+	}
+}
+
+func TestEvaluationProblem(t *testing.T) {
+	ev := &runner.Evaluation{Score: 0.35, PassScore: 0.6, Criteria: []runner.CriterionScore{
+		{Name: "completeness", Score: 3}, {Name: "clarity", Score: 5}, {Name: "accuracy", Score: 4},
+	}}
+	if got, want := ev.Problem(), "judge score 0.35 < 0.60 (completeness 3/10, accuracy 4/10)"; got != want {
+		t.Errorf("Problem() = %q, want %q", got, want)
+	}
+	ev.Passed = true
+	if got := ev.Problem(); got != "" {
+		t.Errorf("passed evaluation: Problem() = %q", got)
+	}
+}
+
+// 섹션 검사를 통과한 태스크도 심사 점수가 기준에 못 미치면 실패합니다.
+func TestRunTasksJudgeFailsTask(t *testing.T) {
+	srv := fakellm.NewTestServer(fakellm.Script{
+		Rules: []fakellm.Rule{{Model: "judge-model", Reply: fakellm.Reply{
+			Text: `{"criteria":{"completeness":{"score":2,"rationale":"only one step"}},"summary":"thin"}`}}},
+		Default: fakellm.Reply{Text: fullDraft},
+	})
+	defer srv.Close()
+	reg := llm.NewModelRegistry()
+	reg.RegisterModel("writer", llm.NewOpenAICompatibleClient(srv.OpenAIBaseURL(), "writer-model"))
+	reg.RegisterModel("judge", llm.NewOpenAICompatibleClient(srv.OpenAIBaseURL(), "judge-model"))
+
+	out := t.TempDir()
+	report, err := runner.RunTasks(context.Background(), reg, runner.TaskRunOptions{
+		FeatureDir: writeFeature(t, retention(1, "    rubric:\n      criteria:\n        - name: completeness\n          description: every step is listed\n")),
+		ModelTag:   "writer",
+		JudgeTag:   "judge",
+		OutDir:     out,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := report.Results[0]
+	if res.Passed || len(res.Missing) != 0 || res.Judgement == nil || res.Judgement.Score != 0.2 {
+		t.Fatalf("passed=%v missing=%v judgement=%+v", res.Passed, res.Missing, res.Judgement)
+	}
+	md, _ := os.ReadFile(filepath.Join(out, "report.md"))
+	if report.JudgeTag != "judge" || !strings.Contains(string(md), "judge score 0.20 < 0.60 (completeness 2/10)") {
+		t.Errorf("report.md:\n%s", md)
+	}
+}
@@ -0,0 +1,537 @@
+// internal/runner/leaderboard.go
+package runner
+
+import (
+	"fmt"
+	"html/template"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"speckit-study/internal/llm"
+)
+
+// OutputCheck : 스모크 출력에 대한 사용자 정의 검사 (Check 가 error 를 반환하면 실패)
+type OutputCheck struct {
+	Name  string
+	Check func(output string) error
+}
+
+// TryScore : 스모크 호출(tag, try) 한 건의 채점 결과
+type TryScore struct {
+	Tag      string        `json:"tag"`
+	Model    string        `json:"model"`
+	Try      int           `json:"try"`
+	File     string        `json:"file"`
+	Passed   bool          `json:"passed"`
+	Score    float64       `json:"score"` // 통과한 검사 비율 (0..1), 호출 오류면 0
+	Failures []string      `json:"failures,omitempty"`
+	Chars    int           `json:"chars"`
+	Latency  time.Duration `json:"latency"`
+	Usage    llm.Usage     `json:"usage"`
+	Error    string        `json:"error,omitempty"`
+	Cached   bool          `json:"cached,omitempty"`
+	Judge    *Evaluation   `json:"judge,omitempty"` // rubric 채점 (심사하지 않았으면 nil)
+	Output   string        `json:"-"`               // 비교(diff)용 원문
+}
+
+// ScoreOutput 은 출력 하나를 in 의 필수 섹션, 길이 범위, 사용자 정의 검사로 채점합니다.
+// 검사마다 1점이고 score 는 통과 비율입니다 (검사가 없으면 1).
+// rubric 심사는 모델 호출이 필요하므로 여기서 하지 않고 RunSmokeTest 가 검사 하나로 더합니다.
+func ScoreOutput(output string, in SmokeTaskInput) (score float64, failures []string) {
+	failures, total := scoreChecks(output, in)
+	return checkScore(total, len(failures)), failures
+}
+
+func checkScore(total, failed int) float64 {
+	if total == 0 {
+		return 1
+	}
+	return float64(total-failed) / float64(total)
+}
+
+// scoreChecks : 실패한 검사와 검사 수
+func scoreChecks(output string, in SmokeTaskInput) (failures []string, total int) {
+	output = NormalizeNewlines(output)
+	for _, sec := range ValidateRequiredSections(output, in.RequiredSections) {
+		failures = append(failures, fmt.Sprintf("missing section %q", sec))
+	}
+	total += len(in.RequiredSections)
+
+	if in.MinChars > 0 || in.MaxChars > 0 {
+		total++
+		n := utf8.RuneCountInString(strings.TrimSpace(output))
+		switch {
+		case in.MinChars > 0 && n < in.MinChars:
+			failures = append(failures, fmt.Sprintf("too short: %d chars (min %d)", n, in.MinChars))
+		case in.MaxChars > 0 && n > in.MaxChars:
+			failures = append(failures, fmt.Sprintf("too long: %d chars (max %d)", n, in.MaxChars))
+		}
+	}
+
+	for _, c := range in.Checks {
+		total++
+		if err := c.Check(output); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", c.Name, err))
+		}
+	}
+	return failures, total
+}
+
+// checkNames : 보고서 머리말에 적는 검사 목록
+func checkNames(in SmokeTaskInput) []string {
+	var names []string
+	for _, sec := range in.RequiredSections {
+		names = append(names, "section "+sec)
+	}
+	switch {
+	case in.MinChars > 0 && in.MaxChars > 0:
+		names = append(names, fmt.Sprintf("length %d..%d", in.MinChars, in.MaxChars))
+	case in.MinChars > 0:
+		names = append(names, fmt.Sprintf("length >= %d", in.MinChars))
+	case in.MaxChars > 0:
+		names = append(names, fmt.Sprintf("length <= %d", in.MaxChars))
+	}
+	for _, c := range in.Checks {
+		names = append(names, c.Name)
+	}
+	if in.Rubric != nil && in.Judge != nil {
+		names = append(names, fmt.Sprintf("judge >= %.2f", in.Rubric.Threshold()))
+	}
+	return names
+}
+
+// TagStats : 태그 하나의 IterationsPerTier 회 집계
+type TagStats struct {
+	Rank            int           `json:"rank"`
+	Tag             string        `json:"tag"`
+	Models          []string      `json:"models"` // 폴백 등으로 여러 모델이 응답했을 수 있음
+	Tries           int           `json:"tries"`
+	Passed          int           `json:"passed"`
+	Errors          int           `json:"errors"`
+	PassRate        float64       `json:"pass_rate"`
+	ErrorRate       float64       `json:"error_rate"`
+	MeanScore       float64       `json:"mean_score"`
+	ScoreStdDev     float64       `json:"score_stddev"`
+	MeanChars       float64       `json:"mean_chars"` // 오류 없는 시도만
+	CharsStdDev     float64       `json:"chars_stddev"`
+	MeanLatency     time.Duration `json:"mean_latency"`
+	MaxLatency      time.Duration `json:"max_latency"`
+	AvgInputTokens  float64       `json:"avg_input_tokens"`
+	AvgOutputTokens float64       `json:"avg_output_tokens"`
+	Judged          int           `json:"judged,omitempty"` // rubric 채점을 받은 시도 수
+	MeanJudge       float64       `json:"mean_judge,omitempty"`
+	JudgeStdDev     float64       `json:"judge_stddev,omitempty"`
+	Best            int           `json:"best_try,omitempty"` // 대표 출력 (점수 최고, 같으면 앞선 시도), 없으면 0
+}
+
+// Leaderboard : 스모크 실행 하나의 모델 비교 결과
+type Leaderboard struct {
+	ID        string     `json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	Checks    []string   `json:"checks"`
+	Judge     string     `json:"judge,omitempty"` // rubric 심사 모델 (심사하지 않았으면 "")
+	Tags      []TagStats `json:"tags"`            // 순위순
+	Tries     []TryScore `json:"tries"`
+}
+
+// BuildLeaderboard 는 채점된 시도를 태그별로 묶어 순위를 매깁니다.
+// 순위는 통과율, 평균 점수, 평균 심사 점수(높은 순), 오류율, 평균 지연(낮은 순) 순서로 비교합니다.
+func BuildLeaderboard(id string, checks []string, tries []TryScore) *Leaderboard {
+	lb := &Leaderboard{ID: id, CreatedAt: time.Now(), Checks: checks, Tries: tries}
+	byTag := map[string][]TryScore{}
+	var order []string
+	for _, t := range tries {
+		if _, ok := byTag[t.Tag]; !ok {
+			order = append(order, t.Tag)
+		}
+		byTag[t.Tag] = append(byTag[t.Tag], t)
+	}
+
+	for _, tag := range order {
+		ts := byTag[tag]
+		st := TagStats{Tag: tag, Tries: len(ts)}
+		var scores, chars, judged []float64
+		var latency time.Duration
+		bestScore := -1.0
+		for _, t := range ts {
+			if !slices.Contains(st.Models, t.Model) {
+				st.Models = append(st.Models, t.Model)
+			}
+			scores = append(scores, t.Score)
+			latency += t.Latency
+			st.MaxLatency = max(st.MaxLatency, t.Latency)
+			if t.Error != "" {
+				st.Errors++
+				continue
+			}
+			if t.Passed {
+				st.Passed++
+			}
+			chars = append(chars, float64(t.Chars))
+			if t.Judge != nil {
+				judged = append(judged, t.Judge.Score)
+			}
+			st.AvgInputTokens += float64(t.Usage.InputTokens)
+			st.AvgOutputTokens += float64(t.Usage.OutputTokens)
+			if t.Score > bestScore {
+				bestScore, st.Best = t.Score, t.Try
+			}
+		}
+		n := float64(len(ts))
+		st.PassRate = float64(st.Passed) / n
+		st.ErrorRate = float64(st.Errors) / n
+		st.MeanScore, st.ScoreStdDev = meanStdDev(scores)
+		st.MeanChars, st.CharsStdDev = meanStdDev(chars)
+		st.Judged = len(judged)
+		st.MeanJudge, st.JudgeStdDev = meanStdDev(judged)
+		st.MeanLatency = latency / time.Duration(len(ts))
+		if ok := len(ts) - st.Errors; ok > 0 {
+			st.AvgInputTokens /= float64(ok)
+			st.AvgOutputTokens /= float64(ok)
+		}
+		lb.Tags = append(lb.Tags, st)
+	}
+
+	sort.SliceStable(lb.Tags, func(i, j int) bool {
+		a, b := lb.Tags[i], lb.Tags[j]
+		switch {
+		case a.PassRate != b.PassRate:
+			return a.PassRate > b.PassRate
+		case a.MeanScore != b.MeanScore:
+			return a.MeanScore > b.MeanScore
+		case a.MeanJudge != b.MeanJudge:
+			return a.MeanJudge > b.MeanJudge
+		case a.ErrorRate != b.ErrorRate:
+			return a.ErrorRate < b.ErrorRate
+		}
+		return a.MeanLatency < b.MeanLatency
+	})
+	for i := range lb.Tags {
+		lb.Tags[i].Rank = i + 1
+	}
+	return lb
+}
+
+// meanStdDev : 모표준편차 (값이 없으면 0, 0)
+func meanStdDev(xs []float64) (mean, sd float64) {
+	if len(xs) == 0 {
+		return 0, 0
+	}
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	for _, x := range xs {
+		sd += (x - mean) * (x - mean)
+	}
+	return mean, math.Sqrt(sd / float64(len(xs)))
+}
+
+// best : 태그의 대표 출력 (없으면 nil)
+func (lb *Leaderboard) best(st TagStats) *TryScore {
+	for i, t := range lb.Tries {
+		if t.Tag == st.Tag && t.Try == st.Best {
+			return &lb.Tries[i]
+		}
+	}
+	return nil
+}
+
+// comparison : 1위 태그의 대표 출력과 다른 태그의 대표 출력 비교
+type comparison struct {
+	Leader, Other *TryScore
+}
+
+func (lb *Leaderboard) comparisons() []comparison {
+	if len(lb.Tags) == 0 {
+		return nil
+	}
+	leader := lb.best(lb.Tags[0])
+	if leader == nil {
+		return nil
+	}
+	var out []comparison
+	for _, st := range lb.Tags[1:] {
+		if other := lb.best(st); other != nil {
+			out = append(out, comparison{leader, other})
+		}
+	}
+	return out
+}
+
+// ---- Markdown ----
+
+// Markdown 은 순위표, 시도별 결과, 1위 대비 diff 를 담은 보고서입니다.
+func (lb *Leaderboard) Markdown() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Smoke leaderboard: %s\n\n", lb.ID)
+	fmt.Fprintf(&sb, "- created: %s\n", lb.CreatedAt.Format(time.RFC3339))
+	if len(lb.Checks) > 0 {
+		fmt.Fprintf(&sb, "- checks: %s\n", strings.Join(lb.Checks, "; "))
+	}
+	if lb.Judge != "" {
+		fmt.Fprintf(&sb, "- judge: %s\n", lb.Judge)
+	}
+	sb.WriteString("\n| # | tag | model | pass rate | score (mean ± sd) |")
+	if lb.Judge != "" {
+		sb.WriteString(" judge (mean ± sd) |")
+	}
+	sb.WriteString(" length (mean ± sd) | errors | latency (mean / max) | tokens in / out (avg) |\n")
+	sep := "|---|---|---|---|---|"
+	if lb.Judge != "" {
+		sep += "---|"
+	}
+	sb.WriteString(sep + "---|---|---|---|\n")
+	for _, st := range lb.Tags {
+		fmt.Fprintf(&sb, "| %d | %s | %s | %.0f%% (%d/%d) | %.2f ± %.2f |",
+			st.Rank, st.Tag, strings.Join(st.Models, ", "), st.PassRate*100, st.Passed, st.Tries,
+			st.MeanScore, st.ScoreStdDev)
+		if lb.Judge != "" {
+			fmt.Fprintf(&sb, " %s |", judgeStats(st))
+		}
+		fmt.Fprintf(&sb, " %.0f ± %.0f | %d | %s / %s | %.0f / %.0f |\n",
+			st.MeanChars, st.CharsStdDev, st.Errors,
+			st.MeanLatency.Round(time.Millisecond), st.MaxLatency.Round(time.Millisecond),
+			st.AvgInputTokens, st.AvgOutputTokens)
+	}
+
+	sb.WriteString("\n## Tries\n\n| tag | try | result | score | judge | length | latency | file | failures |\n|---|---|---|---|---|---|---|---|---|\n")
+	for _, t := range lb.Tries {
+		fmt.Fprintf(&sb, "| %s | %d | %s | %.2f | %s | %d | %s | %s | %s |\n",
+			t.Tag, t.Try, tryResult(t), t.Score, judgeScore(t), t.Chars, t.Latency.Round(time.Millisecond),
+			filepath.Base(t.File), mdCell(tryDetail(t)))
+	}
+
+	for _, st := range lb.Tags {
+		b := lb.best(st)
+		if b == nil || b.Judge == nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n## Judgement: %s (try %d)\n\n", b.Tag, b.Try)
+		sb.WriteString("| criterion | weight | score | rationale |\n|---|---|---|---|\n")
+		for _, c := range b.Judge.Criteria {
+			fmt.Fprintf(&sb, "| %s | %g | %d/%d | %s |\n", mdCell(c.Name), c.Weight, c.Score, MaxCriterionScore, mdCell(c.Rationale))
+		}
+		if b.Judge.Summary != "" {
+			fmt.Fprintf(&sb, "\n%s\n", b.Judge.Summary)
+		}
+	}
+
+	for _, c := range lb.comparisons() {
+		fmt.Fprintf(&sb, "\n## %s (try %d) vs %s (try %d)\n\n", c.Other.Tag, c.Other.Try, c.Leader.Tag, c.Leader.Try)
+		d := UnifiedDiff(filepath.Base(c.Leader.File), filepath.Base(c.Other.File), c.Leader.Output, c.Other.Output, 3)
+		if d == "" {
+			sb.WriteString("Outputs are identical.\n")
+			continue
+		}
+		f := fence(d)
+		fmt.Fprintf(&sb, "%sdiff\n%s%s\n", f, d, f)
+	}
+	return sb.String()
+}
+
+func tryResult(t TryScore) string {
+	switch {
+	case t.Error != "":
+		return "❌ error"
+	case !t.Passed:
+		return "❌ fail"
+	case t.Cached:
+		return "✅ pass (cached)"
+	}
+	return "✅ pass"
+}
+
+// judgeScore : 시도의 심사 점수 (심사하지 않았으면 "-")
+func judgeScore(t TryScore) string {
+	if t.Judge == nil {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f", t.Judge.Score)
+}
+
+// judgeStats : 태그의 평균 심사 점수 (심사한 시도가 없으면 "-")
+func judgeStats(st TagStats) string {
+	if st.Judged == 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%.2f ± %.2f", st.MeanJudge, st.JudgeStdDev)
+}
+
+// tryDetail : 실패한 검사와 호출 오류
+func tryDetail(t TryScore) string {
+	if t.Error != "" {
+		return t.Error
+	}
+	return strings.Join(t.Failures, "; ")
+}
+
+func mdCell(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "|", `\|`), "\n", " ")
+}
+
+// fence : 본문에 든 가장 긴 ``` 보다 긴 코드 펜스
+func fence(s string) string {
+	longest, run := 0, 0
+	for _, r := range s {
+		if r == '`' {
+			run++
+			longest = max(longest, run)
+		} else {
+			run = 0
+		}
+	}
+	return strings.Repeat("`", max(3, longest+1))
+}
+
+// ---- HTML ----
+
+// sideRow : 나란히 보기 diff 한 줄 (Kind: same | change | del | ins)
+type sideRow struct {
+	Kind        string
+	Left, Right string
+}
+
+// sideBySide 는 연속한 삭제/추가 묶음을 한 줄씩 짝지어 두 열로 배치합니다.
+func sideBySide(ops []DiffOp) []sideRow {
+	var rows []sideRow
+	for k := 0; k < len(ops); {
+		if ops[k].Kind == ' ' {
+			rows = append(rows, sideRow{Kind: "same", Left: ops[k].Text, Right: ops[k].Text})
+			k++
+			continue
+		}
+		var dels, inss []string
+		for ; k < len(ops) && ops[k].Kind != ' '; k++ {
+			if ops[k].Kind == '-' {
+				dels = append(dels, ops[k].Text)
+			} else {
+				inss = append(inss, ops[k].Text)
+			}
+		}
+		for i := 0; i < max(len(dels), len(inss)); i++ {
+			row := sideRow{Kind: "change"}
+			switch {
+			case i >= len(inss):
+				row.Kind, row.Left = "del", dels[i]
+			case i >= len(dels):
+				row.Kind, row.Right = "ins", inss[i]
+			default:
+				row.Left, row.Right = dels[i], inss[i]
+			}
+			rows = append(rows, row)
+		}
+	}
+	return rows
+}
+
+var leaderboardHTML = template.Must(template.New("leaderboard").Funcs(template.FuncMap{
+	"pct":      func(f float64) string { return fmt.Sprintf("%.0f%%", f*100) },
+	"f2":       func(f float64) string { return fmt.Sprintf("%.2f", f) },
+	"f0":       func(f float64) string { return fmt.Sprintf("%.0f", f) },
+	"ms":       func(d time.Duration) string { return d.Round(time.Millisecond).String() },
+	"join":     strings.Join,
+	"base":     filepath.Base,
+	"result":   tryResult,
+	"detail":   tryDetail,
+	"judge":    judgeScore,
+	"jstats":   judgeStats,
+	"maxScore": func() int { return MaxCriterionScore },
+	"time":     func(t time.Time) string { return t.Format(time.RFC3339) },
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Smoke leaderboard: {{.ID}}</title>
+<style>
+body { font-family: system-ui, sans-serif; margin: 2rem; color: #222; }
+table { border-collapse: collapse; margin: 1rem 0; }
+th, td { border: 1px solid #ccc; padding: .3rem .6rem; text-align: left; vertical-align: top; }
+th { background: #f3f3f3; }
+td.num { text-align: right; font-variant-numeric: tabular-nums; }
+tr.rank1 td { background: #f1f8e9; }
+.diff { font-family: ui-monospace, monospace; font-size: .85rem; width: 100%; table-layout: fixed; }
+.diff td { white-space: pre-wrap; word-break: break-word; border: none; border-right: 1px solid #ddd; }
+.diff .del, .diff .change .l { background: #fdecea; }
+.diff .ins, .diff .change .r { background: #e8f5e9; }
+.muted { color: #777; }
+</style>
+</head>
+<body>
+<h1>Smoke leaderboard: {{.ID}}</h1>
+<p class="muted">created {{time .CreatedAt}}{{if .Checks}} · checks: {{join .Checks "; "}}{{end}}{{if .Judge}} · judge: {{.Judge}}{{end}}</p>
+
+<table>
+<tr><th>#</th><th>tag</th><th>model</th><th>pass rate</th><th>score (mean ± sd)</th>{{if $.Judge}}<th>judge (mean ± sd)</th>{{end}}<th>length (mean ± sd)</th><th>errors</th><th>latency (mean / max)</th><th>tokens in / out (avg)</th></tr>
+{{range .Tags}}<tr{{if eq .Rank 1}} class="rank1"{{end}}><td class="num">{{.Rank}}</td><td>{{.Tag}}</td><td>{{join .Models ", "}}</td><td class="num">{{pct .PassRate}} ({{.Passed}}/{{.Tries}})</td><td class="num">{{f2 .MeanScore}} ± {{f2 .ScoreStdDev}}</td>{{if $.Judge}}<td class="num">{{jstats .}}</td>{{end}}<td class="num">{{f0 .MeanChars}} ± {{f0 .CharsStdDev}}</td><td class="num">{{.Errors}}</td><td class="num">{{ms .MeanLatency}} / {{ms .MaxLatency}}</td><td class="num">{{f0 .AvgInputTokens}} / {{f0 .AvgOutputTokens}}</td></tr>
+{{end}}</table>
+
+<h2>Tries</h2>
+<table>
+<tr><th>tag</th><th>try</th><th>result</th><th>score</th><th>judge</th><th>length</th><th>latency</th><th>file</th><th>failures</th></tr>
+{{range .Tries}}<tr><td>{{.Tag}}</td><td class="num">{{.Try}}</td><td>{{result .}}</td><td class="num">{{f2 .Score}}</td><td class="num">{{judge .}}</td><td class="num">{{.Chars}}</td><td class="num">{{ms .Latency}}</td><td>{{base .File}}</td><td>{{detail .}}</td></tr>
+{{end}}</table>
+
+{{range .Judged}}<h2>Judgement: {{.Tag}} (try {{.Try}})</h2>
+<table>
+<tr><th>criterion</th><th>weight</th><th>score</th><th>rationale</th></tr>
+{{range .Judge.Criteria}}<tr><td>{{.Name}}</td><td class="num">{{.Weight}}</td><td class="num">{{.Score}}/{{maxScore}}</td><td>{{.Rationale}}</td></tr>
+{{end}}</table>
+{{if .Judge.Summary}}<p>{{.Judge.Summary}}</p>{{end}}
+{{end}}
+{{range .Diffs}}<h2>{{.Other.Tag}} (try {{.Other.Try}}) vs {{.Leader.Tag}} (try {{.Leader.Try}})</h2>
+{{if .Rows}}<table class="diff">
+<tr><th>{{.Leader.Tag}} · {{base .Leader.File}}</th><th>{{.Other.Tag}} · {{base .Other.File}}</th></tr>
+{{range .Rows}}<tr class="{{.Kind}}"><td class="l">{{.Left}}</td><td class="r">{{.Right}}</td></tr>
+{{end}}</table>{{else}}<p class="muted">Outputs are identical.</p>{{end}}
+{{end}}</body>
+</html>
+`))
+
+// HTML 은 Markdown 과 같은 내용을 외부 리소스 없는 HTML 한 페이지로 만듭니다 (diff 는 나란히 보기).
+func (lb *Leaderboard) HTML() (string, error) {
+	type htmlDiff struct {
+		Leader, Other *TryScore
+		Rows          []sideRow // 같으면 nil
+	}
+	data := struct {
+		*Leaderboard
+		Judged []*TryScore // 태그별 대표 출력 중 심사를 받은 것 (순위순)
+		Diffs  []htmlDiff
+	}{Leaderboard: lb}
+	for _, st := range lb.Tags {
+		if b := lb.best(st); b != nil && b.Judge != nil {
+			data.Judged = append(data.Judged, b)
+		}
+	}
+	for _, c := range lb.comparisons() {
+		d := htmlDiff{Leader: c.Leader, Other: c.Other}
+		if c.Leader.Output != c.Other.Output {
+			d.Rows = sideBySide(DiffLines(c.Leader.Output, c.Other.Output))
+		}
+		data.Diffs = append(data.Diffs, d)
+	}
+	var sb strings.Builder
+	if err := leaderboardHTML.Execute(&sb, data); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
+
+// WriteFiles 는 dir 에 leaderboard.md 와 leaderboard.html 을 씁니다.
+func (lb *Leaderboard) WriteFiles(dir string) error {
+	page, err := lb.HTML()
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "leaderboard.md"), []byte(lb.Markdown()), 0o644); err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "leaderboard.html"), []byte(page), 0o644)
+}
@@ -0,0 +1,101 @@
+// internal/runner/leaderboard_test.go
+package runner_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"speckit-study/internal/runner"
+)
+
+func TestScoreOutput(t *testing.T) {
+	in := runner.SmokeTaskInput{
+		RequiredSections: []string{"Steps", "Risks"},
+		MinChars:         20,
+		MaxChars:         200,
+		Checks: []runner.OutputCheck{{Name: "no TODO", Check: func(out string) error {
+			if strings.Contains(out, "TODO") {
+				return errors.New("contains TODO")
+			}
+			return nil
+		}}},
+	}
+	cases := []struct {
+		name     string
+		output   string
+		score    float64
+		failures []string
+	}{
+		{"all pass", "## Steps\n1. copy\n\n## Risks\n- none\n", 1, nil},
+		{"missing section and TODO", "## Steps\nTODO: fill in later\n", 0.5, []string{`missing section "Risks"`, "no TODO: contains TODO"}},
+		{"too short", "## Steps\n## Risks\n", 0.75, []string{"too short"}},
+		{"CRLF is normalized", "## Steps\r\n1. copy\r\n\r\n## Risks\r\n- none\r\n", 1, nil},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			score, failures := runner.ScoreOutput(c.output, in)
+			if score != c.score || len(failures) != len(c.failures) {
+				t.Fatalf("score %v failures %q, want %v %q", score, failures, c.score, c.failures)
+			}
+			for i := range failures {
+				if !strings.HasPrefix(failures[i], c.failures[i]) {
+					t.Errorf("failure %d = %q, want prefix %q", i, failures[i], c.failures[i])
+				}
+			}
+		})
+	}
+	if score, _ := runner.ScoreOutput("", runner.SmokeTaskInput{}); score != 1 {
+		t.Errorf("no checks: score %v, want 1", score)
+	}
+}
+
+func TestBuildLeaderboard(t *testing.T) {
+	ms := time.Millisecond
+	tries := []runner.TryScore{
+		// slow: 모두 통과하지만 느림
+		{Tag: "slow", Model: "m-slow", Try: 1, Passed: true, Score: 1, Chars: 100, Latency: 300 * ms},
+		{Tag: "slow", Model: "m-slow", Try: 2, Passed: true, Score: 1, Chars: 200, Latency: 500 * ms},
+		// fast: slow 와 통과율/점수가 같고 더 빠름
+		{Tag: "fast", Model: "m-fast", Try: 1, Passed: true, Score: 1, Chars: 150, Latency: 100 * ms},
+		{Tag: "fast", Model: "m-fallback", Try: 2, Passed: true, Score: 1, Chars: 150, Latency: 100 * ms},
+		// flaky: 한 번은 오류, 한 번은 일부만 통과
+		{Tag: "flaky", Model: "m-flaky", Try: 1, Error: "HTTP 503", Latency: 50 * ms},
+		{Tag: "flaky", Model: "m-flaky", Try: 2, Score: 0.5, Chars: 80, Latency: 50 * ms},
+	}
+	lb := runner.BuildLeaderboard("smoke", []string{"section Steps"}, tries)
+
+	var order []string
+	for _, st := range lb.Tags {
+		order = append(order, st.Tag)
+	}
+	if got := strings.Join(order, ","); got != "fast,slow,flaky" {
+		t.Fatalf("ranking = %s, want fast,slow,flaky", got)
+	}
+	fast, slow, flaky := lb.Tags[0], lb.Tags[1], lb.Tags[2]
+	if fast.Rank != 1 || flaky.Rank != 3 {
+		t.Errorf("ranks = %d, %d", fast.Rank, flaky.Rank)
+	}
+	if len(fast.Models) != 2 {
+		t.Errorf("fast models = %v, want both responders", fast.Models)
+	}
+	if slow.MeanChars != 150 || slow.CharsStdDev != 50 || slow.MeanLatency != 400*ms || slow.MaxLatency != 500*ms {
+		t.Errorf("slow stats = %+v", slow)
+	}
+	// 오류 시도는 점수 0 으로 평균에 들어가고, 글자 수 평균에서는 빠집니다.
+	if flaky.Errors != 1 || flaky.ErrorRate != 0.5 || flaky.PassRate != 0 || flaky.MeanScore != 0.25 || flaky.ScoreStdDev != 0.25 || flaky.MeanChars != 80 {
+		t.Errorf("flaky stats = %+v", flaky)
+	}
+	if flaky.Best != 2 || slow.Best != 1 {
+		t.Errorf("best tries: flaky %d slow %d", flaky.Best, slow.Best)
+	}
+	if flaky.Judged != 0 || flaky.MeanJudge != 0 {
+		t.Errorf("unjudged tag: judged %d mean %v", flaky.Judged, flaky.MeanJudge)
+	}
+
+	md := lb.Markdown()
+	if !strings.Contains(md, "fast") || strings.Index(md, "| 1 ") > strings.Index(md, "| 3 ") {
+		t.Errorf("markdown table out of order:\n%s", md)
+	}
+}
@@ -0,0 +1,238 @@
+// internal/runner/manifest.go
+package runner
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"speckit-study/internal/llm"
+)
+
+// ManifestFile : 실행 디렉터리 안의 매니페스트 파일 이름
+const ManifestFile = "manifest.json"
+
+// ManifestVersion : RunManifest 형식 버전 (필드 의미가 바뀌면 올립니다)
+const ManifestVersion = 1
+
+// RunKind : 실행 종류 (RunManifest.Kind)
+type RunKind string
+
+// 실행 종류
+const (
+	RunKindSpecgen RunKind = "specgen" // cmd/specgen
+	RunKindSmoke   RunKind = "smoke"   // RunSmokeTest
+	RunKindTask    RunKind = "task"    // RunTasks (cmd/run_task)
+)
+
+// 실행(RunManifest.Status) 상태
+const (
+	RunRunning   = "running"
+	RunCompleted = "completed"
+	RunCancelled = "cancelled" // ctx 취소나 SIGINT 로 중단 (Steps 는 끝난 것만)
+	RunFailed    = "failed"    // 모델 호출이나 파일 쓰기 오류로 중단
+)
+
+// 단계(RunStep.Status) 상태
+const (
+	StepOK     = "ok"
+	StepFail   = "fail" // 응답은 받았지만 검사 실패
+	StepError  = "error"
+	StepCached = "cached"
+)
+
+// RunManifest 는 specgen, 스모크, 태스크 실행이 공통으로 남기는 실행 기록입니다 (<실행 디렉터리>/manifest.json).
+// 실행 디렉터리는 <.specify>/_runs/<Name>/<타임스탬프> 이고 RunID 는 그중 "<Name>/<타임스탬프>" 입니다.
+// 단계가 끝날 때마다 다시 쓰므로, 중단된 실행도 마지막으로 끝난 단계까지는 디렉터리 내용과 일치합니다.
+type RunManifest struct {
+	Version    int         `json:"version"`
+	RunID      string      `json:"run_id"`
+	Kind       RunKind     `json:"kind"`
+	Name       string      `json:"name"` // 스모크 태스크 ID, 태스크 실행이면 기능 이름, specgen 이면 "specgen"
+	GitCommit  string      `json:"git_commit,omitempty"`
+	Args       []string    `json:"args,omitempty"`
+	StartedAt  time.Time   `json:"started_at"`
+	FinishedAt time.Time   `json:"finished_at,omitzero"`
+	ResumedAt  []time.Time `json:"resumed_at,omitempty"` // 이어 하기(resume)로 다시 시작한 시각
+	Status     string      `json:"status"`
+	Error      string      `json:"error,omitempty"`
+	Planned    int         `json:"planned"` // 계획한 단계 수 (len(Steps) 보다 크면 일부만 끝남)
+	Steps      []RunStep   `json:"steps"`   // 끝난 단계 (계획 순서)
+}
+
+// RunStep : 끝난 단계(모델 호출 하나). 경로는 실행 디렉터리 기준 상대 경로입니다.
+type RunStep struct {
+	Artifact   string          `json:"artifact"` // 예: "plan.md", 스모크는 "<tag>-try<N>"
+	Tag        string          `json:"tag"`
+	Try        int             `json:"try,omitempty"`
+	Model      string          `json:"model"`
+	PromptHash string          `json:"prompt_hash"`
+	Prompt     string          `json:"prompt"`
+	Output     string          `json:"output,omitempty"`
+	Target     string          `json:"target,omitempty"` // specgen: 결과를 쓴 작업 트리 경로
+	Status     string          `json:"status"`
+	Latency    time.Duration   `json:"latency"`
+	Usage      llm.Usage       `json:"usage"`
+	Attempts   int             `json:"attempts"`
+	Retried    []string        `json:"retried,omitempty"`
+	Repairs    int             `json:"repairs,omitempty"`
+	Cached     bool            `json:"cached,omitempty"`
+	Validation *StepValidation `json:"validation,omitempty"` // 검사하지 않은 산출물이면 nil
+	Error      string          `json:"error,omitempty"`
+}
+
+// StepValidation : 단계 출력의 검사 결과
+type StepValidation struct {
+	Passed   bool        `json:"passed"`
+	Problems []string    `json:"problems,omitempty"`
+	Judge    *Evaluation `json:"judge,omitempty"` // rubric 채점 (심사하지 않았으면 nil)
+}
+
+// NewRunManifest 는 runsDir/<name>/<타임스탬프> 실행 디렉터리를 만들고 running 상태의 매니페스트를 반환합니다.
+// 같은 초에 시작한 실행이 이미 있으면 타임스탬프 뒤에 -2, -3 … 을 붙입니다.
+// 실행 디렉터리는 dir 로 돌려 줍니다. Args 는 os.Args 에서 비밀 값을 가린 것입니다.
+func NewRunManifest(runsDir string, kind RunKind, name string) (m *RunManifest, dir string, err error) {
+	started := time.Now()
+	if err := os.MkdirAll(filepath.Join(runsDir, name), 0o755); err != nil {
+		return nil, "", err
+	}
+	ts := started.Format("20060102_150405")
+	for n := 2; ; n++ {
+		dir = filepath.Join(runsDir, name, ts)
+		err := os.Mkdir(dir, 0o755)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, "", err
+		}
+		ts = fmt.Sprintf("%s-%d", started.Format("20060102_150405"), n)
+	}
+	return newManifest(kind, name, name+"/"+ts, dir, started), dir, nil
+}
+
+// newManifest 는 이미 있는 실행 디렉터리 dir 의 running 상태 매니페스트를 만듭니다.
+func newManifest(kind RunKind, name, runID, dir string, started time.Time) *RunManifest {
+	m := &RunManifest{
+		Version:   ManifestVersion,
+		RunID:     runID,
+		Kind:      kind,
+		Name:      name,
+		GitCommit: GitCommit(dir),
+		StartedAt: started,
+		Status:    RunRunning,
+	}
+	for _, a := range os.Args {
+		m.Args = append(m.Args, llm.RedactSecrets(a))
+	}
+	return m
+}
+
+// Finish 는 종료 시각과 상태를 채웁니다. err 가 nil 이 아니면 failed, cancelled 가 참이면 cancelled 입니다.
+func (m *RunManifest) Finish(err error, cancelled bool) {
+	m.FinishedAt = time.Now()
+	switch {
+	case err != nil:
+		m.Status, m.Error = RunFailed, llm.RedactSecrets(err.Error())
+	case cancelled:
+		m.Status = RunCancelled
+	default:
+		m.Status = RunCompleted
+	}
+}
+
+// Resume 은 끝난 실행을 이어 하기 위해 running 상태로 되돌립니다 (끝난 단계는 그대로 둠).
+func (m *RunManifest) Resume() {
+	m.ResumedAt = append(m.ResumedAt, time.Now())
+	m.FinishedAt, m.Status, m.Error = time.Time{}, RunRunning, ""
+}
+
+// Step 은 Artifact 가 artifact 인 단계를 찾습니다.
+func (m *RunManifest) Step(artifact string) (RunStep, bool) {
+	for _, s := range m.Steps {
+		if s.Artifact == artifact {
+			return s, true
+		}
+	}
+	return RunStep{}, false
+}
+
+// SetStep 은 Artifact 가 같은 단계를 s 로 바꾸고, 없으면 끝에 붙입니다 (이어 하기에서 다시 실행한 단계).
+func (m *RunManifest) SetStep(s RunStep) {
+	for i := range m.Steps {
+		if m.Steps[i].Artifact == s.Artifact {
+			m.Steps[i] = s
+			return
+		}
+	}
+	m.Steps = append(m.Steps, s)
+}
+
+// Write 는 dir/manifest.json 을 임시 파일 + rename 으로 씁니다.
+func (m *RunManifest) Write(dir string) error {
+	b, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(dir, ManifestFile), b)
+}
+
+// ReadManifest 는 실행 디렉터리의 manifest.json 을 읽습니다.
+func ReadManifest(dir string) (*RunManifest, error) {
+	b, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var m RunManifest
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// PromptHash : 프롬프트 sha256 의 앞 16자 (같은 입력의 실행을 비교하는 키)
+func PromptHash(prompt string) string {
+	sum := sha256.Sum256([]byte(NormalizeNewlines(prompt)))
+	return hex.EncodeToString(sum[:])[:16]
+}
+
+// GitCommit 은 dir 이 속한 git 저장소의 HEAD 커밋입니다 (커밋하지 않은 변경이 있으면 "-dirty").
+// git 이 없거나 저장소가 아니면 "" 입니다.
+func GitCommit(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+	commit := strings.TrimSpace(string(out))
+	if st, err := exec.Command("git", "-C", dir, "status", "--porcelain", "--untracked-files=no").Output(); err == nil && len(st) > 0 {
+		commit += "-dirty"
+	}
+	return commit
+}
+
+// relPath : 실행 디렉터리 기준 상대 경로 (실패하면 그대로)
+func relPath(dir, path string) string {
+	if rel, err := filepath.Rel(dir, path); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
+
+// writeFileAtomic : 중단돼도 반쯤 쓴 파일이 남지 않도록 임시 파일에 쓰고 rename 합니다.
+func writeFileAtomic(path string, b []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
@@ -0,0 +1,89 @@
+// internal/runner/manifest_test.go
+package runner_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"speckit-study/internal/runner"
+)
+
+// 같은 초에 시작한 실행은 -2, -3 … 을 붙인 별도 디렉터리를 받습니다.
+func TestNewRunManifest(t *testing.T) {
+	runs := t.TempDir()
+	kinds := []runner.RunKind{runner.RunKindSpecgen, runner.RunKindSmoke, runner.RunKindTask}
+	seen := map[string]bool{}
+	for _, kind := range kinds {
+		m, dir, err := runner.NewRunManifest(runs, kind, "demo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if m.Kind != kind || m.Name != "demo" || m.Status != runner.RunRunning || m.Version != runner.ManifestVersion {
+			t.Errorf("manifest = %+v", m)
+		}
+		if m.RunID != "demo/"+filepath.Base(dir) || filepath.Dir(dir) != filepath.Join(runs, "demo") {
+			t.Errorf("run ID %s for %s", m.RunID, dir)
+		}
+		if seen[dir] {
+			t.Errorf("%s handed out twice", dir)
+		}
+		seen[dir] = true
+		if fi, err := os.Stat(dir); err != nil || !fi.IsDir() {
+			t.Errorf("run directory not created: %v", err)
+		}
+	}
+}
+
+func TestRunManifestRoundTrip(t *testing.T) {
+	m, dir, err := runner.NewRunManifest(t.TempDir(), runner.RunKindTask, "retention")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Planned = 2
+	m.SetStep(runner.RunStep{Artifact: "steps.md", Tag: "writer", Status: runner.StepFail})
+	m.SetStep(runner.RunStep{Artifact: "rollout.md", Tag: "writer", Status: runner.StepOK})
+	// 이어 하기에서 다시 실행한 단계는 자리를 지킨 채 바뀝니다.
+	m.SetStep(runner.RunStep{Artifact: "steps.md", Tag: "writer", Status: runner.StepOK,
+		Validation: &runner.StepValidation{Passed: true}})
+	m.Finish(errors.New("disk full"), false)
+	if err := m.Write(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	b, _ := os.ReadFile(filepath.Join(dir, runner.ManifestFile))
+	if !strings.Contains(string(b), `"kind": "task"`) {
+		t.Errorf("manifest.json does not record the kind:\n%s", b)
+	}
+	if _, err := os.Stat(filepath.Join(dir, runner.ManifestFile+".tmp")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("temporary file left behind: %v", err)
+	}
+	got, err := runner.ReadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Kind != runner.RunKindTask || got.Status != runner.RunFailed || got.Error != "disk full" || got.FinishedAt.IsZero() {
+		t.Errorf("read back %+v", got)
+	}
+	if len(got.Steps) != 2 || got.Steps[0].Artifact != "steps.md" || got.Steps[0].Status != runner.StepOK || got.Steps[0].Validation == nil {
+		t.Errorf("steps = %+v", got.Steps)
+	}
+	if s, ok := got.Step("rollout.md"); !ok || s.Status != runner.StepOK {
+		t.Errorf("Step(rollout.md) = %+v, %v", s, ok)
+	}
+
+	got.Resume()
+	if got.Status != runner.RunRunning || got.Error != "" || !got.FinishedAt.IsZero() || len(got.ResumedAt) != 1 || len(got.Steps) != 2 {
+		t.Errorf("after Resume: %+v", got)
+	}
+	got.Finish(nil, true)
+	if got.Status != runner.RunCancelled {
+		t.Errorf("cancelled run status = %s", got.Status)
+	}
+	got.Finish(nil, false)
+	if got.Status != runner.RunCompleted {
+		t.Errorf("finished run status = %s", got.Status)
+	}
+}
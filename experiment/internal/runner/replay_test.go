@@ -0,0 +1,126 @@
+// internal/runner/replay_test.go
+package runner_test
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"speckit-study/internal/fakellm"
+	"speckit-study/internal/golden"
+	"speckit-study/internal/llm"
+	"speckit-study/internal/runner"
+)
+
+var record = flag.Bool("record", false, "re-record testdata/replay/*/cassette.yaml from script.yaml with a local fakellm server")
+
+// 재생 케이스 디렉터리 (testdata/replay/<케이스>/):
+//
+//	feature/       specify.md, plan.md, tasks.yaml (RunTasks 의 FeatureDir)
+//	cassette.yaml  기록된 모델 응답 (작성 모델 "replay-writer", 심사 모델 "replay-judge")
+//	script.yaml    -record 때 cassette.yaml 을 다시 기록할 fakellm 스크립트
+//	golden/        report.json (시각·지연·경로를 지운 보고서) 과 태스크별 최종 출력
+//
+// 재생은 네트워크 없이 카세트에서만 응답하므로, 프롬프트가 바뀌면 cassette miss 로 실패합니다.
+// 프롬프트를 의도적으로 바꿨다면 -record -update 로 카세트와 골든 파일을 함께 다시 만드세요.
+const (
+	replayWriter = "replay-writer"
+	replayJudge  = "replay-judge"
+)
+
+func TestReplayGolden(t *testing.T) {
+	cases, err := filepath.Glob(filepath.Join("testdata", "replay", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cases) == 0 {
+		t.Fatal("no cases under testdata/replay")
+	}
+	for _, dir := range cases {
+		t.Run(filepath.Base(dir), func(t *testing.T) { replayCase(t, dir) })
+	}
+}
+
+func replayCase(t *testing.T, dir string) {
+	// 재생 모드에서는 카세트에 없는 요청이 실제로 나가지 않도록 닿지 않는 주소를 씁니다.
+	baseURL, mode := "http://127.0.0.1:0/v1", llm.ModeReplay
+	if *record {
+		script, err := fakellm.LoadScript(filepath.Join(dir, "script.yaml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		srv := fakellm.NewTestServer(script)
+		defer srv.Close()
+		baseURL, mode = srv.OpenAIBaseURL(), llm.ModeRecord
+	}
+	cassette, err := llm.OpenCassette(filepath.Join(dir, "cassette.yaml"), mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := llm.NewModelRegistry()
+	reg.RegisterModel("writer", cassette.Wrap("writer", llm.NewOpenAICompatibleClient(baseURL, replayWriter)))
+	reg.RegisterModel("judge", cassette.Wrap("judge", llm.NewOpenAICompatibleClient(baseURL, replayJudge)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	report, err := runner.RunTasks(ctx, reg, runner.TaskRunOptions{
+		FeatureDir: filepath.Join(dir, "feature"),
+		ModelTag:   "writer",
+		JudgeTag:   "judge", // rubric 이 있는 태스크만 심사합니다
+		OutDir:     t.TempDir(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, res := range report.Results {
+		// 카세트에 없는 요청을 골든 파일로 굳히지 않도록 -update 여도 멈춥니다.
+		for _, e := range []string{res.Error, res.JudgeError} {
+			if strings.Contains(e, llm.ErrCassetteMiss.Error()) {
+				t.Fatalf("task %s: prompt is not in the cassette (re-record with -record -update if the prompt change is intended):\n%s", res.Name, e)
+			}
+		}
+	}
+	for _, res := range report.Results {
+		if res.Output == "" {
+			continue
+		}
+		b, err := os.ReadFile(res.Output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		golden.Assert(t, filepath.Join(dir, "golden", filepath.Base(res.Output)), string(b))
+	}
+	golden.Assert(t, filepath.Join(dir, "golden", "report.json"), stableReport(t, report))
+}
+
+// stableReport : 실행마다 달라지는 값(시작 시각, 지연, 출력 디렉터리)을 지우고 파일은 이름만 남긴 report.json
+func stableReport(t *testing.T, r *runner.TaskReport) string {
+	t.Helper()
+	c := *r
+	c.StartedAt, c.OutDir = time.Time{}, ""
+	c.Results = make([]runner.TaskResult, len(r.Results))
+	for i, res := range r.Results {
+		res.Latency = 0
+		if res.Output != "" {
+			res.Output = filepath.Base(res.Output)
+		}
+		drafts := make([]runner.Draft, len(res.Drafts))
+		for k, d := range res.Drafts {
+			d.File = filepath.Base(d.File)
+			drafts[k] = d
+		}
+		res.Drafts = drafts
+		c.Results[i] = res
+	}
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b) + "\n"
+}
@@ -1,55 +1,348 @@
-﻿// internal/runner/smoke_runner.go
+// internal/runner/smoke_runner.go
 package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"os"
 	"path/filepath"
+	"strings"
+	"sync"
 	"time"
 
 	"speckit-study/internal/llm"
+	"speckit-study/internal/speckit"
 )
 
 type SmokeTaskInput struct {
 	ID                 string
 	Prompt             string
 	CandidateModelTags []string
-	IterationsPerTier  int // 보통 3
+	IterationsPerTier  int            // 보통 3
+	Prices             llm.PriceTable // 비용 계산용 가격표 (nil 이면 토큰만 집계)
+
+	// 채점 기준 (leaderboard.md / leaderboard.html). 모두 비우면 호출 오류만 실패로 봅니다.
+	RequiredSections []string
+	MinChars         int // 0 이면 하한 없음
+	MaxChars         int // 0 이면 상한 없음
+	Checks           []OutputCheck
+
+	// Rubric 이 있으면 오류 없는 출력마다 심사 모델이 채점하고, 통과 여부를 검사 하나로 더합니다.
+	// 심사 모델은 Judge, 없으면 레지스트리의 JudgeTag 입니다 (둘 다 비우면 심사하지 않음).
+	Rubric   *speckit.Rubric
+	JudgeTag string
+	Judge    Evaluator
+
+	Concurrency int // 동시에 보내는 호출 수 (0 이면 DefaultSmokeConcurrency)
 }
 
+// DefaultSmokeConcurrency : SmokeTaskInput.Concurrency 를 비웠을 때 동시에 보내는 호출 수
+const DefaultSmokeConcurrency = 4
+
+// smokeJob : tag × try 조합 하나 (idx 는 계획 순서)
+type smokeJob struct {
+	idx   int
+	tag   string
+	try   int
+	model llm.LLMClient
+}
+
+// smokeResult : 워커가 끝낸 호출 하나. aborted 면 ctx 취소로 중단돼 기록하지 않습니다.
+type smokeResult struct {
+	job      smokeJob
+	score    TryScore
+	attempts int
+	wait     time.Duration
+	retried  []string
+	usage    llm.Usage
+	callErr  error
+	aborted  bool
+	err      error // 파일 쓰기 오류 (실행 전체를 멈춤)
+}
+
+// RunSmokeTest 는 후보 태그 × IterationsPerTier 조합을 최대 Concurrency 개씩 동시에 호출하고,
+// 출력과 calls.log, leaderboard, cost.json, manifest.json 을 .specify/_runs/<ID>/<타임스탬프> 에 씁니다.
+// ctx 가 취소되면 새 호출을 멈추고 진행 중인 호출을 취소한 뒤, 끝난 호출만으로 보고서를 씁니다
+// (SIGINT 처리는 cmd/smoke 처럼 호출하는 쪽에서 ctx 취소로 넘깁니다).
+// 파일 쓰기 오류가 나면 나머지 호출을 취소하고 그 오류를 반환합니다.
 func RunSmokeTest(
 	ctx context.Context,
 	reg *llm.ModelRegistry,
 	in SmokeTaskInput,
 ) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	man, baseDir, err := NewRunManifest(filepath.Join(".specify", "_runs"), RunKindSmoke, in.ID)
+	if err != nil {
+		return err
+	}
+	promptPath := filepath.Join(baseDir, "prompt.txt")
+	if err := writeFileAtomic(promptPath, []byte(in.Prompt)); err != nil {
+		return err
+	}
+	promptHash := PromptHash(in.Prompt)
 
-	tsDir := time.Now().Format("20060102_150405")
-	baseDir := filepath.Join(".specify", "_runs", in.ID, tsDir)
-	os.MkdirAll(baseDir, 0o755)
+	if in.Rubric != nil && in.Judge == nil && in.JudgeTag != "" {
+		j, err := NewLLMJudge(reg, in.JudgeTag)
+		if err != nil {
+			return err
+		}
+		in.Judge = j
+	}
 
+	var jobs []smokeJob
 	for _, tag := range in.CandidateModelTags {
 		model, ok := reg.GetModel(tag)
 		if !ok {
 			fmt.Printf("[SKIP] tag=%s (no model registered)\n", tag)
 			continue
 		}
-
 		for i := 1; i <= in.IterationsPerTier; i++ {
-			out, err := model.Generate(ctx, in.Prompt)
-			if err != nil {
-				out = fmt.Sprintf("ERROR calling model %s: %v", model.Name(), err)
+			jobs = append(jobs, smokeJob{idx: len(jobs), tag: tag, try: i, model: model})
+		}
+	}
+
+	man.Planned = len(jobs)
+	if err := man.Write(baseDir); err != nil {
+		return err
+	}
+
+	workers := in.Concurrency
+	if workers <= 0 {
+		workers = DefaultSmokeConcurrency
+	}
+	workers = max(1, min(workers, len(jobs)))
+
+	jobCh := make(chan smokeJob)
+	resCh := make(chan smokeResult)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobCh {
+				// 취소 뒤에 받은 작업은 보내지 않습니다 (select 가 취소보다 전달을 고를 수 있음).
+				if ctx.Err() != nil {
+					resCh <- smokeResult{job: j, aborted: true}
+					continue
+				}
+				resCh <- runSmokeCall(ctx, j, in, baseDir)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobCh)
+		for _, j := range jobs {
+			select {
+			case jobCh <- j:
+			case <-ctx.Done():
+				return
 			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(resCh)
+	}()
 
-			filePath := filepath.Join(
-				baseDir,
-				fmt.Sprintf("%s-try%d-%s.md", tag, i, model.Name()),
-			)
-			os.WriteFile(filePath, []byte(out), 0o644)
+	costs := llm.NewCostTracker(in.Prices)
+	done := make([]*smokeResult, len(jobs))
+	var runErr error
+	for r := range resCh {
+		if r.err != nil {
+			runErr = errors.Join(runErr, r.err)
+			cancel()
+			continue
+		}
+		if r.aborted {
+			continue
+		}
+		done[r.job.idx] = &r
+		// 캐시 적중은 다시 과금되지 않으므로 비용에 넣지 않습니다.
+		if r.callErr == nil && !r.score.Cached {
+			costs.Record(in.ID, r.job.tag, r.score.Model, r.usage)
+		}
+		if ev := r.score.Judge; ev != nil {
+			costs.Record(in.ID+"/judge", ev.Judge, ev.Model, ev.Usage)
+		}
+
+		status := "OK"
+		switch {
+		case r.callErr != nil:
+			status = "ERROR"
+		case !r.score.Passed:
+			status = "FAIL"
+		case r.score.Cached:
+			status = "CACHED"
+		}
+		fmt.Printf("[%s] %s try%d => saved %s (attempts=%d, latency=%s, queued=%s)\n",
+			status, r.job.tag, r.job.try, r.score.File, r.attempts, r.score.Latency.Round(time.Millisecond), r.wait.Round(time.Millisecond))
 
-			fmt.Printf("[OK] %s => saved %s\n", tag, filePath)
+		man.Steps = man.Steps[:0]
+		for _, d := range done {
+			if d != nil {
+				man.Steps = append(man.Steps, d.step(baseDir, promptPath, promptHash))
+			}
+		}
+		if err := man.Write(baseDir); err != nil {
+			runErr = errors.Join(runErr, err)
+			cancel()
 		}
 	}
 
-	return nil
+	// 끝난 호출만 계획 순서로 보고합니다.
+	var calls strings.Builder // calls.log : 호출별 시도 횟수와 재시도된 오류 기록
+	var scores []TryScore
+	for _, d := range done {
+		if d == nil {
+			continue
+		}
+		calls.WriteString(formatCallLine(d.job.tag, d.job.try, d.score.Model, d.attempts, d.score.Latency, d.wait, d.retried, d.score.Cached, d.callErr))
+		scores = append(scores, d.score)
+	}
+	if len(scores) < len(jobs) {
+		fmt.Printf("⚠️ %d of %d calls completed\n", len(scores), len(jobs))
+	}
+	if err := writeFileAtomic(filepath.Join(baseDir, "calls.log"), []byte(calls.String())); err != nil {
+		runErr = errors.Join(runErr, err)
+	}
+
+	lb := BuildLeaderboard(in.ID, checkNames(in), scores)
+	if in.Rubric != nil && in.Judge != nil {
+		lb.Judge = judgeName(in)
+	}
+	if err := lb.WriteFiles(baseDir); err != nil {
+		runErr = errors.Join(runErr, err)
+	}
+	for _, st := range lb.Tags {
+		fmt.Printf("#%d %-10s pass %3.0f%%  score %.2f±%.2f  errors %d  latency %s\n",
+			st.Rank, st.Tag, st.PassRate*100, st.MeanScore, st.ScoreStdDev, st.Errors, st.MeanLatency.Round(time.Millisecond))
+	}
+	fmt.Printf("🏁 leaderboard: %s\n", filepath.Join(baseDir, "leaderboard.html"))
+
+	summary := costs.Summary()
+	fmt.Print(summary.String())
+	if err := summary.WriteFile(filepath.Join(baseDir, "cost.json")); err != nil {
+		runErr = errors.Join(runErr, err)
+	}
+
+	cancelled := runErr == nil && ctx.Err() != nil
+	man.Finish(runErr, cancelled)
+	if cancelled {
+		runErr = context.Cause(ctx)
+	}
+	return errors.Join(runErr, man.Write(baseDir))
+}
+
+// runSmokeCall 은 호출 하나를 보내고 출력 파일을 쓴 뒤 채점합니다.
+func runSmokeCall(ctx context.Context, j smokeJob, in SmokeTaskInput, baseDir string) smokeResult {
+	r := smokeResult{job: j, attempts: 1}
+	start := time.Now()
+	resp, err := llm.Complete(ctx, j.model, llm.PromptRequest(in.Prompt))
+	latency := time.Since(start)
+	if err != nil && ctx.Err() != nil {
+		r.aborted = true
+		return r
+	}
+
+	var out string
+	ts := TryScore{Tag: j.tag, Model: j.model.Name(), Try: j.try, Latency: latency}
+	if err != nil {
+		out = llm.RedactSecrets(fmt.Sprintf("ERROR calling model %s: %v", j.model.Name(), err))
+		var re *llm.RetryError
+		if errors.As(err, &re) {
+			r.attempts, r.retried = re.Attempts, re.Retried
+		}
+		ts.Error = out
+	} else {
+		out = resp.Text
+		r.attempts, r.retried = max(resp.Attempts, 1), resp.RetriedErrors
+		r.wait, r.usage = resp.QueueWait, resp.Usage
+		ts.Model, ts.Usage, ts.Cached, ts.Output = resp.Model, resp.Usage, resp.Cached, NormalizeNewlines(out)
+		ts.Chars = len([]rune(strings.TrimSpace(ts.Output)))
+		var total int
+		ts.Failures, total = scoreChecks(out, in)
+		if in.Rubric != nil && in.Judge != nil {
+			total++
+			ev, jerr := in.Judge.Evaluate(ctx, out, *in.Rubric)
+			switch {
+			case jerr != nil && ctx.Err() != nil:
+				r.aborted = true
+				return r
+			case jerr != nil:
+				ts.Failures = append(ts.Failures, llm.RedactSecrets(jerr.Error()))
+			case !ev.Passed:
+				ts.Failures = append(ts.Failures, ev.Problem())
+			}
+			ts.Judge = ev
+		}
+		ts.Score = checkScore(total, len(ts.Failures))
+		ts.Passed = len(ts.Failures) == 0
+	}
+
+	ts.File = filepath.Join(baseDir, fmt.Sprintf("%s-try%d-%s.md", j.tag, j.try, j.model.Name()))
+	if werr := writeFileAtomic(ts.File, []byte(out)); werr != nil {
+		r.err = werr
+		return r
+	}
+	r.score, r.callErr = ts, err
+	return r
+}
+
+// judgeName : 리더보드 머리말에 적는 심사 모델
+func judgeName(in SmokeTaskInput) string {
+	if j, ok := in.Judge.(*LLMJudge); ok {
+		return j.Tag + " (" + j.Client.Name() + ")"
+	}
+	return fmt.Sprintf("%T", in.Judge)
+}
+
+// step : manifest.json 에 기록할 단계
+func (r *smokeResult) step(baseDir, promptPath, promptHash string) RunStep {
+	s := RunStep{
+		Artifact:   fmt.Sprintf("%s-try%d", r.job.tag, r.job.try),
+		Tag:        r.job.tag,
+		Try:        r.job.try,
+		Model:      r.score.Model,
+		PromptHash: promptHash,
+		Prompt:     relPath(baseDir, promptPath),
+		Output:     relPath(baseDir, r.score.File),
+		Status:     StepOK,
+		Latency:    r.score.Latency,
+		Usage:      r.usage,
+		Attempts:   r.attempts,
+		Retried:    r.retried,
+		Cached:     r.score.Cached,
+		Error:      r.score.Error,
+	}
+	switch {
+	case r.callErr != nil:
+		s.Status = StepError
+		return s
+	case !r.score.Passed:
+		s.Status = StepFail
+	case r.score.Cached:
+		s.Status = StepCached
+	}
+	s.Validation = &StepValidation{Passed: r.score.Passed, Problems: r.score.Failures, Judge: r.score.Judge}
+	return s
+}
+
+// formatCallLine : calls.log 한 줄 (tag, try, model, attempts, 지연/대기 시간, 결과, 재시도된 오류)
+// latency 는 rate limiter 대기(wait)를 포함한 전체 호출 시간이고, 캐시 적중이면 결과가 "cached" 입니다.
+func formatCallLine(tag string, try int, model string, attempts int, latency, wait time.Duration, retried []string, cached bool, err error) string {
+	status := "ok"
+	if cached {
+		status = "cached"
+	}
+	if err != nil {
+		status = "error: " + llm.RedactSecrets(err.Error())
+	}
+	line := fmt.Sprintf("%s\ttry=%d\tmodel=%s\tattempts=%d\tlatency=%s\tqueued=%s\t%s\n",
+		tag, try, model, attempts, latency.Round(time.Millisecond), wait.Round(time.Millisecond), status)
+	for _, r := range retried {
+		line += "\tretried: " + llm.RedactSecrets(r) + "\n"
+	}
+	return line
 }
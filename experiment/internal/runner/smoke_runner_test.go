@@ -0,0 +1,95 @@
+// internal/runner/smoke_runner_test.go
+package runner_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"speckit-study/internal/llm"
+	"speckit-study/internal/runner"
+)
+
+// smokeClient 는 바로 답하거나(block 이 nil), block 에 알린 뒤 ctx 가 끝날 때까지 막히는 클라이언트입니다.
+type smokeClient struct {
+	name  string
+	block chan<- struct{}
+	calls atomic.Int32
+}
+
+func (c *smokeClient) Name() string { return c.name }
+
+func (c *smokeClient) Generate(ctx context.Context, prompt string) (string, error) {
+	c.calls.Add(1)
+	if c.block == nil {
+		return "## Steps\n1. ok\n", nil
+	}
+	select {
+	case c.block <- struct{}{}:
+	case <-ctx.Done():
+	}
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+// 취소되면 새 호출을 보내지 않고 진행 중인 호출을 버린 뒤, 끝난 호출만으로 보고서를 씁니다.
+func TestSmokeCancelKeepsFinishedCalls(t *testing.T) {
+	t.Chdir(t.TempDir())
+	started := make(chan struct{})
+	fast := &smokeClient{name: "fast-model"}
+	slow := &smokeClient{name: "slow-model", block: started}
+	reg := llm.NewModelRegistry()
+	reg.RegisterModel("fast", fast)
+	reg.RegisterModel("slow", slow)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		// 워커 둘이 모두 slow 호출에 막히면 취소합니다.
+		<-started
+		<-started
+		cancel()
+	}()
+	err := runner.RunSmokeTest(ctx, reg, runner.SmokeTaskInput{
+		ID:                 "cancel",
+		Prompt:             "p",
+		CandidateModelTags: []string{"fast", "slow", "missing"},
+		IterationsPerTier:  3,
+		RequiredSections:   []string{"Steps"},
+		Concurrency:        2,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	if fast.calls.Load() != 3 || slow.calls.Load() != 2 {
+		t.Errorf("calls fast=%d slow=%d, want 3 and 2 (no new calls after cancel)", fast.calls.Load(), slow.calls.Load())
+	}
+
+	dirs, _ := filepath.Glob(filepath.Join(".specify", "_runs", "cancel", "*"))
+	if len(dirs) != 1 {
+		t.Fatalf("run dirs = %v", dirs)
+	}
+	man, err := runner.ReadManifest(dirs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if man.Status != runner.RunCancelled || man.Planned != 6 || len(man.Steps) != 3 {
+		t.Errorf("manifest status=%s planned=%d steps=%d", man.Status, man.Planned, len(man.Steps))
+	}
+	for _, s := range man.Steps {
+		if s.Tag != "fast" || s.Status != runner.StepOK {
+			t.Errorf("step %+v", s)
+		}
+	}
+	calls, _ := os.ReadFile(filepath.Join(dirs[0], "calls.log"))
+	if n := strings.Count(string(calls), "\n"); n != 3 || strings.Contains(string(calls), "slow") {
+		t.Errorf("calls.log has %d lines:\n%s", n, calls)
+	}
+	if _, err := os.Stat(filepath.Join(dirs[0], "leaderboard.html")); err != nil {
+		t.Errorf("leaderboard not written: %v", err)
+	}
+}
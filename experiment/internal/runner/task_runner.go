@@ -1,29 +1,602 @@
-﻿package runner
+// internal/runner/task_runner.go
+package runner
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
 	"speckit-study/internal/llm"
+	"speckit-study/internal/speckit"
 )
 
-func main() {
-	reg := llm.NewModelRegistry()
-	reg.RegisterModel("default", llm.NewOpenAIClient("gpt-4o-mini"))
-	reg.RegisterModel("claude", llm.NewAnthropicClient("claude-3-5-sonnet-20240620"))
-	reg.RegisterModel("gemini", llm.NewGeminiClient("gemini-2.5-flash"))
+// TaskRunOptions : 기능 디렉터리(specify.md, plan.md, tasks.yaml)의 태스크 실행 설정
+type TaskRunOptions struct {
+	FeatureDir string   // 예: ".specify/notification-service"
+	ModelTag   string   // 비우면 레지스트리 기본 모델
+	Tasks      []string // 실행할 태스크 이름 (비우면 전부)
+	OutDir     string   // 비우면 <FeatureDir 의 상위>/_runs/<기능 이름>/<타임스탬프>
+	// MaxAttempts : 태스크에 max_attempts 가 없을 때의 생성 시도 예산 (0 이면 DefaultMaxAttempts, 1 이면 재프롬프트 없음)
+	MaxAttempts int
+	// Feedback : 태스크에 feedback 이 없을 때의 재프롬프트 템플릿 (비우면 DefaultFeedbackTemplate)
+	Feedback string
+	// JudgeTag : rubric 이 있는 태스크의 최종 출력을 채점할 심사 모델 태그 (비우면 채점하지 않음)
+	JudgeTag string
+	// Judge : JudgeTag 대신 쓸 Evaluator (둘 다 있으면 Judge)
+	Judge Evaluator
+	// Resume : OutDir 의 이전 실행을 이어 합니다 (OutDir 필수). checkpoint.json 에서 통과했고 입력이 같은
+	// 태스크는 report.json 의 결과를 그대로 쓰고, 실패했거나 입력이 바뀐 태스크만 다시 실행합니다.
+	Resume bool
+	// Tools : 스펙 본문을 프롬프트에 넣지 않고, 모델이 도구(speckit.Tools)로 FeatureDir 의 파일을 직접 읽게 합니다.
+	// 초안마다 llm.RunTools 로 모델이 도구 호출을 멈출 때까지 돌립니다 (도구 호출을 지원하는 모델 필요).
+	Tools bool
+}
+
+// DefaultMaxAttempts : 자기 교정 루프의 기본 시도 예산 (첫 생성 포함)
+const DefaultMaxAttempts = 3
+
+// DefaultFeedbackTemplate : 재프롬프트 피드백 기본 템플릿 (text/template, 데이터는 FeedbackData)
+const DefaultFeedbackTemplate = `The document above does not meet the output requirements (attempt {{.Attempt}} of {{.MaxAttempts}}):
+{{range .Problems}}- {{.}}
+{{end}}
+Rewrite the complete document. Keep the content that was correct, fix every item above, and use a "## <Section>" heading for each of these sections: {{join .Required ", "}}.`
+
+// FeedbackData : 피드백 템플릿에 넘기는 값
+type FeedbackData struct {
+	Task        string
+	Attempt     int // 방금 검사한 초안의 번호 (1부터)
+	MaxAttempts int
+	Required    []string
+	Missing     []string         // 헤딩이 아예 없는 섹션
+	Problems    []SectionProblem // missing/malformed/empty 전부
+}
+
+var feedbackFuncs = template.FuncMap{"join": strings.Join}
+
+// Draft : 자기 교정 루프의 초안 한 건 (<OutDir>/<태스크>.attemptN.md)
+type Draft struct {
+	Attempt  int              `json:"attempt"`
+	File     string           `json:"file"`
+	Problems []SectionProblem `json:"problems,omitempty"`
+	Score    float64          `json:"score"` // 문제 없는 필수 섹션 비율 (0..1)
+}
+
+// TaskResult : 태스크 한 건의 실행 결과
+type TaskResult struct {
+	Name     string        `json:"name"`
+	Tag      string        `json:"tag"`
+	Model    string        `json:"model"`
+	Output   string        `json:"output,omitempty"` // 출력 파일 경로 (수렴한 초안 또는 최고 점수 초안)
+	Missing  []string      `json:"missing_sections,omitempty"`
+	Passed   bool          `json:"passed"`
+	Drafts   []Draft       `json:"drafts,omitempty"`
+	Best     int           `json:"best_attempt,omitempty"` // Output 으로 고른 초안 번호
+	Error    string        `json:"error,omitempty"`
+	Latency  time.Duration `json:"latency"`
+	Usage    llm.Usage     `json:"usage"`
+	Attempts int           `json:"attempts"` // 모든 초안의 HTTP 시도 수 합계
+	Cached   bool          `json:"cached,omitempty"`
+	// Judgement : rubric 채점 결과 (심사하지 않았으면 nil). 통과하지 못하면 Passed 도 거짓입니다.
+	Judgement  *Evaluation `json:"judgement,omitempty"`
+	JudgeError string      `json:"judge_error,omitempty"`
+	Resumed    bool        `json:"resumed,omitempty"`    // 이어 하기에서 이전 실행의 결과를 그대로 씀
+	ToolCalls  int         `json:"tool_calls,omitempty"` // 모든 초안에서 실행한 도구 호출 수 (opts.Tools)
+}
+
+// TaskReport : RunTasks 전체 결과 (report.md / report.json 으로 저장)
+type TaskReport struct {
+	Feature   string       `json:"feature"`
+	ModelTag  string       `json:"model_tag"`
+	JudgeTag  string       `json:"judge_tag,omitempty"`
+	OutDir    string       `json:"out_dir"`
+	StartedAt time.Time    `json:"started_at"`
+	Results   []TaskResult `json:"results"`
+}
+
+// Failed : 통과하지 못한 태스크 수
+func (r *TaskReport) Failed() int {
+	n := 0
+	for _, res := range r.Results {
+		if !res.Passed {
+			n++
+		}
+	}
+	return n
+}
+
+// RunTasks 는 tasks.yaml 의 태스크마다 specify.md/plan.md 와 태스크 입력으로 프롬프트를 만들어
+// 모델을 호출하고, 출력의 필수 섹션을 검사해 <OutDir>/<태스크>.md 와 보고서를 씁니다.
+// 검사에 실패하면 이전 초안과 피드백으로 시도 예산까지 다시 요청하고(runTask),
+// 모든 초안은 <태스크>.attemptN.md 로 남깁니다.
+// 모델 호출 실패나 섹션 누락은 해당 태스크의 실패로 기록하고 다음 태스크로 넘어가며,
+// 설정 오류(파일 없음, 모르는 태스크/태그)와 쓰기 오류만 error 로 반환합니다.
+// 보고서와 checkpoint.json 은 태스크가 끝날 때마다 다시 써서, 중간에 멈춘 실행도 opts.Resume 으로 이어 할 수 있습니다.
+// manifest.json(Kind "task")은 첫 태스크 전에 쓰고 태스크마다 단계를 더하므로, 아직 끝난 태스크가 없는 실행도 태스크 실행으로 보입니다.
+func RunTasks(ctx context.Context, reg *llm.ModelRegistry, opts TaskRunOptions) (*TaskReport, error) {
+	tf, err := speckit.LoadTasks(filepath.Join(opts.FeatureDir, "tasks.yaml"))
+	if err != nil {
+		return nil, err
+	}
+	specify, err := readOptional(filepath.Join(opts.FeatureDir, "specify.md"))
+	if err != nil {
+		return nil, err
+	}
+	plan, err := readOptional(filepath.Join(opts.FeatureDir, "plan.md"))
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := selectTasks(tf.Tasks, opts.Tasks)
+	if err != nil {
+		return nil, err
+	}
+
+	tag := opts.ModelTag
+	if tag == "" {
+		tag = reg.DefaultTag()
+	}
+	model, ok := reg.GetModel(tag)
+	if !ok {
+		return nil, fmt.Errorf("model not registered: %s", tag)
+	}
+	judge := opts.Judge
+	if judge == nil && opts.JudgeTag != "" {
+		j, err := NewLLMJudge(reg, opts.JudgeTag)
+		if err != nil {
+			return nil, err
+		}
+		judge = j
+	}
+
+	feature := filepath.Base(filepath.Clean(opts.FeatureDir))
+	report := &TaskReport{Feature: feature, ModelTag: reg.Resolve(tag), StartedAt: time.Now()}
+	if opts.Judge == nil && opts.JudgeTag != "" {
+		report.JudgeTag = reg.Resolve(opts.JudgeTag)
+	}
+	outDir := opts.OutDir
+	if outDir == "" {
+		outDir = filepath.Join(filepath.Dir(filepath.Clean(opts.FeatureDir)), "_runs", feature,
+			report.StartedAt.Format("20060102_150405"))
+	}
+	if err := os.MkdirAll(outDir, 0o755); err != nil {
+		return nil, err
+	}
+	report.OutDir = outDir
+
+	checkpoint := NewCheckpoint()
+	var previous map[string]TaskResult
+	if opts.Resume {
+		if opts.OutDir == "" {
+			return nil, fmt.Errorf("resume: the run directory (OutDir) is required")
+		}
+		if checkpoint, err = ReadCheckpoint(outDir); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("resume: %s has no %s", outDir, CheckpointFile)
+			}
+			return nil, fmt.Errorf("resume: %w", err)
+		}
+		if previous, err = readTaskResults(outDir); err != nil {
+			return nil, fmt.Errorf("resume: %w", err)
+		}
+	}
+
+	feedback := map[string]*template.Template{}
+	feedbackSrc := map[string]string{}
+	for _, t := range tasks {
+		src := firstNonEmpty(t.Feedback, opts.Feedback, DefaultFeedbackTemplate)
+		tmpl, err := template.New(t.Name).Funcs(feedbackFuncs).Option("missingkey=error").Parse(src)
+		if err != nil {
+			return nil, fmt.Errorf("task %s: feedback template: %w", t.Name, err)
+		}
+		feedback[t.Name], feedbackSrc[t.Name] = tmpl, src
+	}
+	var tools *llm.Toolbox
+	if opts.Tools {
+		tools = speckit.Tools(filepath.Dir(filepath.Clean(opts.FeatureDir)))
+	}
+
+	man, err := taskManifest(outDir, feature, report.StartedAt, opts.Resume)
+	if err != nil {
+		return nil, err
+	}
+	man.Planned = len(tasks)
+	if err := man.Write(outDir); err != nil {
+		return nil, err
+	}
+	// finish : 매니페스트를 끝난 상태로 쓰고 결과를 돌려줍니다 (ctx 취소는 cancelled, 그 밖의 오류는 failed).
+	finish := func(err error) (*TaskReport, error) {
+		if err != nil && ctx.Err() != nil {
+			man.Finish(nil, true)
+		} else {
+			man.Finish(err, false)
+		}
+		return report, errors.Join(err, man.Write(outDir))
+	}
+
+	for _, t := range tasks {
+		if err := ctx.Err(); err != nil {
+			return finish(err)
+		}
+		budget := t.MaxAttempts
+		if budget == 0 {
+			budget = opts.MaxAttempts
+		}
+		if budget <= 0 {
+			budget = DefaultMaxAttempts
+		}
+		req := speckit.BuildRequest(specify, plan, speckit.TaskInputs(t))
+		prompt := req.Prompt()
+		if tools != nil {
+			// 도구 요청에는 스펙 본문이 없으므로 본문이 든 원래 프롬프트에 도구 요청을 더해 해시합니다.
+			req = toolRequest(feature, t)
+			prompt += "\x00" + req.Prompt()
+		}
+		inputHash := InputHash("task", t.Name, report.ModelTag, prompt, feedbackSrc[t.Name], strconv.Itoa(budget), judgeKey(judge, report.JudgeTag, t.Rubric))
+		if prev, ok := previous[t.Name]; ok {
+			if _, reason := checkpoint.Reusable(outDir, t.Name, inputHash); reason == "" {
+				prev = prev.rebase(outDir)
+				report.Results = append(report.Results, prev)
+				man.SetStep(taskStep(prev))
+				continue
+			}
+		}
+
+		res, err := runTask(ctx, model, tools, t, req, feedback[t.Name], budget, outDir)
+		if err != nil {
+			return finish(err)
+		}
+		if res.Tag == "" {
+			res.Tag = report.ModelTag
+		}
+		if judge != nil && t.Rubric != nil && res.Output != "" {
+			if err := judgeTask(ctx, judge, *t.Rubric, &res); err != nil {
+				return finish(err)
+			}
+		}
+		report.Results = append(report.Results, res)
+
+		// 보고서를 먼저 써야 체크포인트가 가리키는 결과를 이어 하기에서 찾을 수 있습니다.
+		if err := report.write(outDir); err != nil {
+			return finish(err)
+		}
+		if err := checkpoint.Record(outDir, t.Name, inputHash, res.status(), res.Output); err != nil {
+			return finish(err)
+		}
+		if err := checkpoint.Write(outDir); err != nil {
+			return finish(err)
+		}
+		man.SetStep(taskStep(res))
+		if err := man.Write(outDir); err != nil {
+			return finish(err)
+		}
+	}
+
+	return finish(report.write(outDir))
+}
+
+// taskManifest : 이어 하기면 outDir 의 매니페스트를 running 으로 되돌리고
+// (매니페스트 없이 report.json 만 남긴 예전 실행이면 새로 만듦), 아니면 새 매니페스트를 만듭니다.
+func taskManifest(outDir, feature string, started time.Time, resume bool) (*RunManifest, error) {
+	if resume {
+		m, err := ReadManifest(outDir)
+		if err == nil {
+			m.Resume()
+			return m, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("resume: %w", err)
+		}
+	}
+	return newManifest(RunKindTask, feature, feature+"/"+filepath.Base(outDir), outDir, started), nil
+}
+
+// status : 체크포인트에 남길 단계 상태
+func (res TaskResult) status() string {
+	switch {
+	case res.Error != "":
+		return StepError
+	case !res.Passed:
+		return StepFail
+	case res.Cached:
+		return StepCached
+	}
+	return StepOK
+}
 
-	model := reg.DefaultModel()
-	if model == nil {
-		fmt.Println("⚠️ No default model registered")
-		return
+// rebase : 이어 하기로 가져온 이전 결과의 파일 경로를 dir 기준으로 바꿉니다 (실행 디렉터리를 옮겼을 수 있음).
+func (res TaskResult) rebase(dir string) TaskResult {
+	res.Resumed = true
+	if res.Output != "" {
+		res.Output = filepath.Join(dir, filepath.Base(res.Output))
+	}
+	drafts := make([]Draft, len(res.Drafts))
+	for i, d := range res.Drafts {
+		d.File = filepath.Join(dir, filepath.Base(d.File))
+		drafts[i] = d
+	}
+	res.Drafts = drafts
+	return res
+}
+
+// judgeKey : 심사 설정을 입력 해시에 넣을 문자열 (심사하지 않으면 "")
+func judgeKey(judge Evaluator, judgeTag string, rubric *speckit.Rubric) string {
+	if judge == nil || rubric == nil {
+		return ""
+	}
+	b, _ := json.Marshal(rubric)
+	if judgeTag == "" {
+		judgeTag = fmt.Sprintf("%T", judge)
 	}
+	return judgeTag + "\x00" + string(b)
+}
 
-	result, err := model.Generate(context.Background(), "Describe SpecKit in one sentence.")
+// readTaskResults : 이전 실행의 report.json 결과를 태스크 이름으로 (없으면 빈 map)
+func readTaskResults(dir string) (map[string]TaskResult, error) {
+	b, err := os.ReadFile(filepath.Join(dir, "report.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
 	if err != nil {
-		fmt.Println("❌ Error:", err)
-		return
+		return nil, err
+	}
+	var rep TaskReport
+	if err := json.Unmarshal(b, &rep); err != nil {
+		return nil, fmt.Errorf("report.json: %w", err)
+	}
+	results := make(map[string]TaskResult, len(rep.Results))
+	for _, res := range rep.Results {
+		results[res.Name] = res
 	}
+	return results, nil
+}
+
+// toolRequest : opts.Tools 일 때 태스크의 첫 요청 (스펙 본문 대신 feature 디렉터리를 도구로 읽으라는 안내)
+func toolRequest(feature string, t speckit.Task) llm.GenerateRequest {
+	inputs := speckit.TaskInputs(t)
+	delete(inputs, "task") // ## Task 섹션으로 따로 들어감
+	return speckit.BuildToolRequest(feature, firstNonEmpty(t.Description, t.Name), inputs)
+}
+
+// runTask 는 태스크 하나를 예산(budget)만큼 생성→검사→피드백 순으로 돌립니다.
+// 재요청은 같은 시스템 프롬프트와 [원래 요청, 직전 초안(assistant), 피드백] 대화로 보내 직전 초안만 문맥에 둡니다.
+// 문제가 없는 초안이 나오면 멈추고, 끝까지 실패하면 점수가 가장 높은(같으면 나중) 초안을 결과로 씁니다.
+// tools 가 있으면 초안마다 llm.RunTools 로 도구 호출이 끝날 때까지 돌리고 마지막 답을 초안으로 씁니다.
+// 반환 error 는 파일 쓰기나 템플릿 실행 오류와 ctx 취소뿐이고, 모델 호출 오류는 res.Error 에 남깁니다.
+func runTask(ctx context.Context, model llm.LLMClient, tools *llm.Toolbox, t speckit.Task, first llm.GenerateRequest,
+	feedback *template.Template, budget int, outDir string) (TaskResult, error) {
+	res := TaskResult{Name: t.Name, Model: model.Name()}
+	req := first
+	var best string
+	for attempt := 1; attempt <= budget; attempt++ {
+		callCtx := llm.WithCallInfo(ctx, llm.CallInfo{Task: t.Name, Artifact: t.Name + ".md"})
+		start := time.Now()
+		resp, calls, err := complete(callCtx, model, tools, req)
+		res.Latency += time.Since(start)
+		res.ToolCalls += calls
+		if err != nil {
+			if ctx.Err() != nil {
+				return res, ctx.Err() // 취소로 끊긴 호출은 태스크 결과로 남기지 않습니다
+			}
+			res.Error = llm.RedactSecrets(err.Error())
+			var re *llm.RetryError
+			if errors.As(err, &re) {
+				res.Attempts += re.Attempts
+			}
+			break
+		}
+		if resp.Tag != "" {
+			res.Tag = resp.Tag
+		}
+		res.Model = resp.Model
+		res.Cached = resp.Cached && (attempt == 1 || res.Cached) // 모든 초안이 캐시 적중일 때만
+		res.Usage.InputTokens += resp.Usage.InputTokens
+		res.Usage.OutputTokens += resp.Usage.OutputTokens
+		res.Attempts += max(resp.Attempts, 1)
+
+		text := NormalizeNewlines(resp.Text)
+		d := Draft{Attempt: attempt, File: filepath.Join(outDir, fmt.Sprintf("%s.attempt%d.md", t.Name, attempt))}
+		if err := os.WriteFile(d.File, []byte(text), 0o644); err != nil {
+			return res, fmt.Errorf("write %s: %w", d.File, err)
+		}
+		d.Problems = CheckSections(text, t.RequiredSections)
+		d.Score = 1
+		if len(t.RequiredSections) > 0 {
+			d.Score = float64(len(t.RequiredSections)-len(d.Problems)) / float64(len(t.RequiredSections))
+		}
+		res.Drafts = append(res.Drafts, d)
+		if res.Best == 0 || d.Score >= res.Drafts[res.Best-1].Score {
+			res.Best, best = attempt, text
+		}
+		if len(d.Problems) == 0 || attempt == budget {
+			break
+		}
+
+		var fb strings.Builder
+		data := FeedbackData{Task: t.Name, Attempt: attempt, MaxAttempts: budget,
+			Required: t.RequiredSections, Problems: d.Problems}
+		for _, p := range d.Problems {
+			if p.Kind == "missing" {
+				data.Missing = append(data.Missing, p.Section)
+			}
+		}
+		if err := feedback.Execute(&fb, data); err != nil {
+			return res, fmt.Errorf("task %s: feedback template: %w", t.Name, err)
+		}
+		req = llm.GenerateRequest{System: first.System, Messages: append(slices.Clone(first.Messages),
+			llm.Message{Role: llm.RoleAssistant, Content: text},
+			llm.Message{Role: llm.RoleUser, Content: fb.String()},
+		)}
+	}
+	if res.Best == 0 {
+		return res, nil
+	}
+
+	res.Output = filepath.Join(outDir, t.Name+".md")
+	if err := os.WriteFile(res.Output, []byte(best), 0o644); err != nil {
+		return res, fmt.Errorf("write %s: %w", res.Output, err)
+	}
+	// 통과 여부는 기존 규칙(ValidateRequiredSections)을 따르고, 빈 섹션은 재프롬프트 사유로만 씁니다.
+	res.Missing = ValidateRequiredSections(best, t.RequiredSections)
+	res.Passed = len(res.Missing) == 0 && res.Error == ""
+	return res, nil
+}
+
+// complete : tools 가 없으면 한 번 호출하고, 있으면 RunTools 로 도구 호출이 끝날 때까지 돌립니다.
+// calls 는 실행한 도구 호출 수입니다 (실패해도 그때까지의 수).
+func complete(ctx context.Context, model llm.LLMClient, tools *llm.Toolbox, req llm.GenerateRequest) (*llm.Response, int, error) {
+	if tools == nil {
+		resp, err := llm.Complete(ctx, model, req)
+		return resp, 0, err
+	}
+	run, err := llm.RunTools(ctx, model, req, tools, 0)
+	if err != nil {
+		return nil, len(run.Calls), err
+	}
+	return run.Response, len(run.Calls), nil
+}
 
-	fmt.Println("✅ Model:", model.Name())
-	fmt.Println("🧠 Result:", result)
+// judgeTask 는 태스크의 최종 출력을 rubric 으로 채점해 res 에 더합니다.
+// 심사 호출이 실패하면 태스크 실패로 기록하고, ctx 취소만 error 로 반환합니다.
+func judgeTask(ctx context.Context, judge Evaluator, rubric speckit.Rubric, res *TaskResult) error {
+	b, err := os.ReadFile(res.Output)
+	if err != nil {
+		return err
+	}
+	ev, err := judge.Evaluate(ctx, string(b), rubric)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		res.JudgeError, res.Passed = llm.RedactSecrets(err.Error()), false
+		return nil
+	}
+	res.Judgement = ev
+	res.Passed = res.Passed && ev.Passed
+	return nil
+}
+
+func firstNonEmpty(vals ...string) string {
+	for _, v := range vals {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// selectTasks : names 순서가 아닌 tasks.yaml 순서로 고름 (모르는 이름은 오류)
+func selectTasks(all []speckit.Task, names []string) ([]speckit.Task, error) {
+	if len(names) == 0 {
+		return all, nil
+	}
+	var picked []speckit.Task
+	for _, t := range all {
+		if slices.Contains(names, t.Name) {
+			picked = append(picked, t)
+		}
+	}
+	for _, n := range names {
+		if !slices.ContainsFunc(all, func(t speckit.Task) bool { return t.Name == n }) {
+			return nil, fmt.Errorf("task %q not found in tasks.yaml", n)
+		}
+	}
+	return picked, nil
+}
+
+func readOptional(path string) (string, error) {
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// write : report.md (사람용 표) 와 report.json 을 씁니다.
+func (r *TaskReport) write(dir string) error {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# Task report: %s\n\n", r.Feature)
+	fmt.Fprintf(&sb, "- model: %s\n", r.ModelTag)
+	if r.JudgeTag != "" {
+		fmt.Fprintf(&sb, "- judge: %s\n", r.JudgeTag)
+	}
+	fmt.Fprintf(&sb, "- started: %s\n- passed: %d/%d\n\n",
+		r.StartedAt.Format(time.RFC3339), len(r.Results)-r.Failed(), len(r.Results))
+	sb.WriteString("| task | result | model | drafts | judge | latency | tokens (in/out) | detail |\n")
+	sb.WriteString("|---|---|---|---|---|---|---|---|\n")
+	for _, res := range r.Results {
+		result, detail := "✅ pass", ""
+		switch {
+		case res.Error != "":
+			result, detail = "❌ error", res.Error
+		case len(res.Missing) > 0:
+			result, detail = "❌ fail", "missing: "+strings.Join(res.Missing, ", ")
+		case res.JudgeError != "":
+			result, detail = "❌ fail", res.JudgeError
+		case !res.Passed && res.Judgement != nil:
+			result, detail = "❌ fail", res.Judgement.Problem()
+		}
+		judged := "-"
+		if res.Judgement != nil {
+			judged = fmt.Sprintf("%.2f", res.Judgement.Score)
+		}
+		switch {
+		case res.Resumed:
+			result += " (resumed)"
+		case res.Cached:
+			result += " (cached)"
+		}
+		drafts := "-"
+		if len(res.Drafts) > 0 {
+			drafts = fmt.Sprintf("%d (best #%d)", len(res.Drafts), res.Best)
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | %s | %d/%d | %s |\n",
+			res.Name, result, res.Model, drafts, judged, res.Latency.Round(time.Millisecond),
+			res.Usage.InputTokens, res.Usage.OutputTokens, strings.ReplaceAll(detail, "|", `\|`))
+	}
+	for _, res := range r.Results {
+		if len(res.Drafts) < 2 {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n## %s drafts\n\n", res.Name)
+		for _, d := range res.Drafts {
+			fmt.Fprintf(&sb, "- #%d %s score=%.2f", d.Attempt, filepath.Base(d.File), d.Score)
+			for _, p := range d.Problems {
+				fmt.Fprintf(&sb, "\n  - %s", p)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	for _, res := range r.Results {
+		ev := res.Judgement
+		if ev == nil {
+			continue
+		}
+		fmt.Fprintf(&sb, "\n## %s judgement\n\n", res.Name)
+		fmt.Fprintf(&sb, "%s (%s): %.2f (pass %.2f)\n\n", ev.Judge, ev.Model, ev.Score, ev.PassScore)
+		sb.WriteString("| criterion | weight | score | rationale |\n|---|---|---|---|\n")
+		for _, c := range ev.Criteria {
+			fmt.Fprintf(&sb, "| %s | %g | %d/%d | %s |\n", mdCell(c.Name), c.Weight, c.Score, MaxCriterionScore, mdCell(c.Rationale))
+		}
+		if ev.Summary != "" {
+			fmt.Fprintf(&sb, "\n%s\n", ev.Summary)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "report.md"), []byte(sb.String()), 0o644); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "report.json"), b, 0o644)
 }
@@ -0,0 +1,240 @@
+// internal/runner/task_runner_test.go
+package runner_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"speckit-study/internal/fakellm"
+	"speckit-study/internal/llm"
+	"speckit-study/internal/runner"
+)
+
+// writeFeature 는 tasks.yaml 하나만 있는 기능 디렉터리를 만듭니다.
+func writeFeature(t *testing.T, tasks string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "feature")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "tasks.yaml"), []byte(tasks), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return dir
+}
+
+// 초안 응답들 (필수 섹션: Steps, Failure Handling)
+const (
+	halfDraft  = "## Steps\n1. copy the partition\n"
+	emptyDraft = "I could not produce the document.\n"
+	fullDraft  = "## Steps\n1. copy the partition\n\n## Failure Handling\n- retry the copy, never drop first\n"
+)
+
+// runRetention 은 retention 태스크를 replies 순서로 답하는 서버에 대해 돌립니다.
+func runRetention(t *testing.T, tasks string, replies ...string) (*runner.TaskReport, *fakellm.TestServer) {
+	t.Helper()
+	script := fakellm.Script{Default: fakellm.Reply{Text: replies[len(replies)-1]}}
+	for _, r := range replies[:len(replies)-1] {
+		script.Rules = append(script.Rules, fakellm.Rule{Times: 1, Reply: fakellm.Reply{Text: r}})
+	}
+	srv := fakellm.NewTestServer(script)
+	t.Cleanup(srv.Close)
+	reg := llm.NewModelRegistry()
+	reg.RegisterModel("writer", llm.NewOpenAICompatibleClient(srv.OpenAIBaseURL(), "writer-model"))
+
+	report, err := runner.RunTasks(context.Background(), reg, runner.TaskRunOptions{
+		FeatureDir: writeFeature(t, tasks),
+		ModelTag:   "writer",
+		OutDir:     t.TempDir(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Results) != 1 {
+		t.Fatalf("got %d results", len(report.Results))
+	}
+	return report, srv
+}
+
+const retentionTask = `tasks:
+  - name: retention
+    description: Describe the retention job
+    required_sections: ["Steps", "Failure Handling"]
+    max_attempts: %d
+`
+
+func retention(budget int, extra string) string {
+	return fmt.Sprintf(retentionTask, budget) + extra
+}
+
+// 검사에 실패한 초안은 피드백과 함께 다시 요청하고, 통과하는 초안이 나오면 멈춥니다.
+func TestSelfCorrectionConverges(t *testing.T) {
+	report, srv := runRetention(t, retention(3, ""), halfDraft, emptyDraft, fullDraft)
+	res := report.Results[0]
+	if !res.Passed || res.Best != 3 || len(res.Drafts) != 3 {
+		t.Fatalf("passed=%v best=%d drafts=%d, want the third draft to pass", res.Passed, res.Best, len(res.Drafts))
+	}
+	if scores := []float64{res.Drafts[0].Score, res.Drafts[1].Score, res.Drafts[2].Score}; scores[0] != 0.5 || scores[1] != 0 || scores[2] != 1 {
+		t.Errorf("draft scores = %v", scores)
+	}
+	out, _ := os.ReadFile(res.Output)
+	if string(out) != fullDraft {
+		t.Errorf("output = %q", out)
+	}
+	for _, d := range res.Drafts {
+		if _, err := os.Stat(d.File); err != nil {
+			t.Errorf("draft %d not kept: %v", d.Attempt, err)
+		}
+	}
+
+	calls := srv.Fake.Calls()
+	if len(calls) != 3 {
+		t.Fatalf("server saw %d calls", len(calls))
+	}
+	// 재요청의 마지막 사용자 메시지는 기본 피드백입니다.
+	fb := calls[1].Prompt
+	if !strings.Contains(fb, "attempt 1 of 3") || !strings.Contains(fb, "Failure Handling") || !strings.Contains(fb, "## <Section>") {
+		t.Errorf("feedback prompt = %q", fb)
+	}
+}
+
+// 예산을 다 써도 통과하지 못하면 점수가 가장 높은 초안을 결과로 씁니다.
+func TestSelfCorrectionKeepsBestDraft(t *testing.T) {
+	report, srv := runRetention(t, retention(2, ""), halfDraft, emptyDraft)
+	res := report.Results[0]
+	if res.Passed || res.Best != 1 || len(res.Drafts) != 2 || len(srv.Fake.Calls()) != 2 {
+		t.Fatalf("passed=%v best=%d drafts=%d calls=%d", res.Passed, res.Best, len(res.Drafts), len(srv.Fake.Calls()))
+	}
+	out, _ := os.ReadFile(res.Output)
+	if string(out) != halfDraft {
+		t.Errorf("output = %q, want the higher-scoring first draft", out)
+	}
+	if len(res.Missing) != 1 || res.Missing[0] != "Failure Handling" {
+		t.Errorf("missing = %v", res.Missing)
+	}
+
+	// 점수가 같으면 나중 초안을 고릅니다.
+	report, _ = runRetention(t, retention(2, ""), halfDraft, "## Steps\n1. second try\n")
+	if res := report.Results[0]; res.Best != 2 {
+		t.Errorf("tie: best = %d, want the later draft", res.Best)
+	}
+}
+
+// 태스크의 feedback 템플릿이 기본 피드백을 대신하고, 예산 1 이면 재요청하지 않습니다.
+func TestSelfCorrectionFeedbackTemplate(t *testing.T) {
+	_, srv := runRetention(t, retention(2, `    feedback: "Add these sections: {{join .Missing \", \"}}"`+"\n"), halfDraft, fullDraft)
+	calls := srv.Fake.Calls()
+	if len(calls) != 2 || calls[1].Prompt != "Add these sections: Failure Handling" {
+		t.Errorf("calls=%d feedback=%q", len(calls), calls[len(calls)-1].Prompt)
+	}
+
+	report, srv := runRetention(t, retention(1, ""), halfDraft, fullDraft)
+	if len(srv.Fake.Calls()) != 1 || report.Results[0].Passed {
+		t.Errorf("budget 1: %d calls, passed=%v", len(srv.Fake.Calls()), report.Results[0].Passed)
+	}
+}
+
+// -tools 실행은 스펙 본문 대신 도구 안내를 보내고, 모델이 read_file 로 읽은 내용을 도구 결과로 돌려줍니다.
+func TestRunTasksTools(t *testing.T) {
+	srv := fakellm.NewTestServer(fakellm.Script{
+		Rules: []fakellm.Rule{{Times: 1, Reply: fakellm.Reply{ToolCalls: []fakellm.ToolCall{
+			{Name: "read_file", Args: `{"path":"feature/specify.md"}`},
+		}}}},
+		Default: fakellm.Reply{Text: fullDraft},
+	})
+	defer srv.Close()
+	reg := llm.NewModelRegistry()
+	reg.RegisterModel("writer", llm.NewOpenAICompatibleClient(srv.OpenAIBaseURL(), "writer-model"))
+	dir := writeFeature(t, retention(1, ""))
+	if err := os.WriteFile(filepath.Join(dir, "specify.md"), []byte("keep 30 days of events\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := runner.RunTasks(context.Background(), reg, runner.TaskRunOptions{
+		FeatureDir: dir, ModelTag: "writer", OutDir: t.TempDir(), Tools: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	res := report.Results[0]
+	if !res.Passed || res.ToolCalls != 1 {
+		t.Fatalf("passed=%v tool calls=%d error=%q", res.Passed, res.ToolCalls, res.Error)
+	}
+	calls := srv.Fake.Calls()
+	if len(calls) != 2 {
+		t.Fatalf("server saw %d calls, want a tool step and the answer", len(calls))
+	}
+	first := calls[0]
+	if strings.Contains(first.Prompt, "keep 30 days") || !strings.Contains(first.Prompt, "`feature/`") ||
+		!strings.Contains(first.Prompt, "Describe the retention job") {
+		t.Errorf("first prompt = %q, want tool guidance without the spec body", first.Prompt)
+	}
+	if strings.Join(first.Tools, ",") != "list_specs,read_file,validate_tasks" {
+		t.Errorf("declared tools = %v", first.Tools)
+	}
+	if calls[1].ToolResult != "keep 30 days of events\n" {
+		t.Errorf("tool result = %q", calls[1].ToolResult)
+	}
+}
+
+// probeClient 는 호출될 때마다 onCall 을 부르고 fullDraft 로 답합니다 (그사이 취소됐으면 ctx 오류).
+type probeClient struct{ onCall func() }
+
+func (c probeClient) Name() string { return "probe-model" }
+
+func (c probeClient) Generate(ctx context.Context, prompt string) (string, error) {
+	c.onCall()
+	return fullDraft, ctx.Err()
+}
+
+// manifest.json 은 첫 태스크 전에 Kind "task" 로 쓰이고, 취소되면 끝난 태스크까지만 남깁니다.
+func TestRunTasksManifest(t *testing.T) {
+	runsDir := t.TempDir()
+	outDir := filepath.Join(runsDir, "feature", "20240601_120000")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var seen []*runner.RunManifest
+	reg := llm.NewModelRegistry()
+	reg.RegisterModel("writer", probeClient{onCall: func() {
+		// 태스크가 아직 하나도 끝나지 않았을 때도 기록은 태스크 실행으로 보여야 합니다.
+		runs, err := runner.ScanRuns(runsDir)
+		if err != nil || len(runs) != 1 {
+			t.Errorf("scan during the run: %v, %v", runs, err)
+			return
+		}
+		seen = append(seen, runs[0].Manifest)
+		if len(seen) == 2 {
+			cancel()
+		}
+	}})
+
+	_, err := runner.RunTasks(ctx, reg, runner.TaskRunOptions{
+		FeatureDir: writeFeature(t, retention(1, "")+"  - name: rollout\n    description: Describe the rollout\n    max_attempts: 1\n"),
+		ModelTag:   "writer",
+		OutDir:     outDir,
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want context.Canceled", err)
+	}
+	first := seen[0]
+	if first.Kind != runner.RunKindTask || first.RunID != "feature/20240601_120000" || first.Status != runner.RunRunning ||
+		first.Planned != 2 || len(first.Steps) != 0 {
+		t.Errorf("manifest before the first task = %+v", first)
+	}
+	if len(seen[1].Steps) != 1 {
+		t.Errorf("manifest before the second task has %d steps", len(seen[1].Steps))
+	}
+
+	man, err := runner.ReadManifest(outDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if man.Status != runner.RunCancelled || len(man.Steps) != 1 || man.Steps[0].Artifact != "retention.md" || man.Steps[0].Status != runner.StepOK {
+		t.Errorf("manifest after cancel = %+v", man)
+	}
+}
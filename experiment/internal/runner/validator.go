@@ -1,6 +1,7 @@
 ﻿package runner
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 )
@@ -34,3 +35,72 @@ func NormalizeNewlines(s string) string {
 	s = strings.ReplaceAll(s, "\r", "\n")
 	return s
 }
+
+// SectionProblem : 필수 섹션 하나의 문제 (재프롬프트 피드백과 보고서에 씁니다)
+type SectionProblem struct {
+	Section string `json:"section"`
+	Kind    string `json:"kind"`             // "missing" | "malformed" | "empty"
+	Detail  string `json:"detail,omitempty"` // malformed 일 때 실제로 쓰인 줄
+}
+
+func (p SectionProblem) String() string {
+	switch p.Kind {
+	case "malformed":
+		return fmt.Sprintf("section %q is not a \"## %s\" heading (found %q)", p.Section, p.Section, p.Detail)
+	case "empty":
+		return fmt.Sprintf("section %q has no content", p.Section)
+	}
+	return fmt.Sprintf("section %q is missing", p.Section)
+}
+
+// CheckSections 는 ValidateRequiredSections 보다 자세히 필수 섹션을 검사합니다.
+// 헤딩이 없으면 missing, "# Goal" 이나 "**Goal**" 처럼 이름은 있지만 ## 헤딩이 아니면 malformed,
+// 헤딩 다음에 다음 헤딩까지 내용이 없으면 empty 입니다. 문제가 없으면 nil 입니다.
+func CheckSections(markdown string, required []string) []SectionProblem {
+	lines := strings.Split(NormalizeNewlines(markdown), "\n")
+	var problems []SectionProblem
+	for _, sec := range required {
+		name := regexp.QuoteMeta(sec)
+		heading := regexp.MustCompile(`(?i)^\s{0,3}#{2,6}\s*` + name + `(\s|$)`)
+		loose := regexp.MustCompile(`(?i)^\s{0,3}(#\s*|\*\*|__)?` + name + `(\*\*|__)?\s*:?\s*$`)
+
+		at, malformed := -1, ""
+		for i, l := range lines {
+			if heading.MatchString(l) {
+				at = i
+				break
+			}
+			if malformed == "" && loose.MatchString(l) {
+				malformed = strings.TrimSpace(l)
+			}
+		}
+		switch {
+		case at >= 0 && sectionEmpty(lines[at], lines[at+1:]):
+			problems = append(problems, SectionProblem{Section: sec, Kind: "empty"})
+		case at >= 0:
+		case malformed != "":
+			problems = append(problems, SectionProblem{Section: sec, Kind: "malformed", Detail: malformed})
+		default:
+			problems = append(problems, SectionProblem{Section: sec, Kind: "missing"})
+		}
+	}
+	return problems
+}
+
+var anyHeading = regexp.MustCompile(`^\s{0,3}(#{1,6})\s`)
+
+// sectionEmpty : 같거나 높은 수준의 다음 헤딩(또는 문서 끝)까지 공백이 아닌 줄이 없는지.
+// 하위 헤딩(### ...)은 내용으로 칩니다.
+func sectionEmpty(heading string, rest []string) bool {
+	h := strings.TrimSpace(heading)
+	level := len(h) - len(strings.TrimLeft(h, "#"))
+	for _, l := range rest {
+		if m := anyHeading.FindStringSubmatch(l); m != nil && len(m[1]) <= level {
+			return true
+		}
+		if strings.TrimSpace(l) != "" {
+			return false
+		}
+	}
+	return true
+}
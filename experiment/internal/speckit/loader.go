@@ -1,23 +1,57 @@
 ﻿package speckit
 
 import (
+	"bytes"
 	"fmt"
 	"os"
+	"regexp"
+	"strings"
+	"text/template"
 
 	"gopkg.in/yaml.v3"
 )
 
 // Task 한 건의 태스크 정의
+// json 태그와 desc 는 구조화 출력(llm.CompleteAs)으로 tasks.yaml 을 생성할 때 스키마로 쓰입니다.
 type Task struct {
-	Name             string            `yaml:"name"`
-	Description      string            `yaml:"description"`
-	Inputs           map[string]string `yaml:"inputs"`
-	RequiredSections []string          `yaml:"required_sections"`
+	Name             string            `yaml:"name" json:"name" desc:"snake_case task identifier"`
+	Description      string            `yaml:"description" json:"description"`
+	Inputs           map[string]string `yaml:"inputs" json:"inputs,omitempty" desc:"prompt template inputs"`
+	RequiredSections []string          `yaml:"required_sections" json:"required_sections" desc:"markdown section titles the output must contain"`
+	// 자기 교정: 필수 섹션 검사에 실패하면 이전 초안과 피드백으로 다시 요청합니다.
+	MaxAttempts int    `yaml:"max_attempts,omitempty" json:"max_attempts,omitempty" desc:"generation attempts including re-prompts (0 = runner default)"`
+	Feedback    string `yaml:"feedback,omitempty" json:"feedback,omitempty" desc:"Go text/template for the re-prompt feedback (empty = runner default)"`
+	// Rubric 이 있으면 실행 시 심사 모델(judge)이 기준별로 채점합니다.
+	Rubric *Rubric `yaml:"rubric,omitempty" json:"rubric,omitempty" desc:"criteria for an LLM judge"`
+}
+
+// DefaultPassScore 는 Rubric.PassScore 를 비웠을 때의 통과 기준(가중 평균 0..1)입니다.
+const DefaultPassScore = 0.6
+
+// Rubric : LLM 심사 기준. 기준마다 0~10 점을 받아 Weight 로 가중 평균(0..1)합니다.
+type Rubric struct {
+	Criteria  []Criterion `yaml:"criteria" json:"criteria"`
+	PassScore float64     `yaml:"pass_score,omitempty" json:"pass_score,omitempty" desc:"weighted score (0..1) needed to pass; 0 = default 0.6"`
+}
+
+// Criterion : 심사 기준 하나
+type Criterion struct {
+	Name        string  `yaml:"name" json:"name" desc:"short criterion name"`
+	Description string  `yaml:"description" json:"description" desc:"what a full score looks like"`
+	Weight      float64 `yaml:"weight,omitempty" json:"weight,omitempty" desc:"relative weight; 0 = 1"`
+}
+
+// Threshold 는 통과 기준 점수입니다 (비우면 DefaultPassScore).
+func (r Rubric) Threshold() float64 {
+	if r.PassScore > 0 {
+		return r.PassScore
+	}
+	return DefaultPassScore
 }
 
 // TaskFile tasks.yaml 최상위 구조
 type TaskFile struct {
-	Tasks []Task `yaml:"tasks"`
+	Tasks []Task `yaml:"tasks" json:"tasks"`
 }
 
 // LoadTasks 는 tasks.yaml 파일 경로를 받아 TaskFile 을 반환합니다.
@@ -32,3 +66,89 @@ func LoadTasks(path string) (*TaskFile, error) {
 	}
 	return &tf, nil
 }
+
+// MarshalTasks 는 TaskFile 을 tasks.yaml 형식(2칸 들여쓰기)으로 직렬화합니다.
+func MarshalTasks(tf *TaskFile) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(tf); err != nil {
+		return nil, fmt.Errorf("marshal tasks.yaml: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var taskNameRe = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// ValidateTasks 는 TaskFile 의 내용 문제를 모두 모아 반환합니다 (없으면 nil).
+// 태스크가 하나 이상이고, 이름은 중복 없는 snake_case, 설명과 필수 섹션 이름은 비어 있지 않아야 합니다.
+func ValidateTasks(tf *TaskFile) []string {
+	if tf == nil || len(tf.Tasks) == 0 {
+		return []string{"tasks: at least one task is required"}
+	}
+	var problems []string
+	seen := map[string]bool{}
+	for i, t := range tf.Tasks {
+		at := fmt.Sprintf("tasks[%d]", i)
+		switch {
+		case t.Name == "":
+			problems = append(problems, at+".name: required")
+		case !taskNameRe.MatchString(t.Name):
+			problems = append(problems, fmt.Sprintf("%s.name: %q is not snake_case", at, t.Name))
+		case seen[t.Name]:
+			problems = append(problems, fmt.Sprintf("%s.name: duplicate %q", at, t.Name))
+		}
+		seen[t.Name] = true
+		if strings.TrimSpace(t.Description) == "" {
+			problems = append(problems, at+".description: required")
+		}
+		for j, sec := range t.RequiredSections {
+			if strings.TrimSpace(sec) == "" {
+				problems = append(problems, fmt.Sprintf("%s.required_sections[%d]: empty", at, j))
+			}
+		}
+		if t.MaxAttempts < 0 {
+			problems = append(problems, fmt.Sprintf("%s.max_attempts: must not be negative (got %d)", at, t.MaxAttempts))
+		}
+		if t.Feedback != "" {
+			if _, err := template.New(t.Name).Parse(t.Feedback); err != nil {
+				problems = append(problems, fmt.Sprintf("%s.feedback: %v", at, err))
+			}
+		}
+		if t.Rubric != nil {
+			problems = append(problems, validateRubric(at+".rubric", t.Rubric)...)
+		}
+	}
+	return problems
+}
+
+func validateRubric(at string, r *Rubric) []string {
+	var problems []string
+	if len(r.Criteria) == 0 {
+		problems = append(problems, at+".criteria: at least one criterion is required")
+	}
+	if r.PassScore < 0 || r.PassScore > 1 {
+		problems = append(problems, fmt.Sprintf("%s.pass_score: must be between 0 and 1 (got %g)", at, r.PassScore))
+	}
+	seen := map[string]bool{}
+	for i, c := range r.Criteria {
+		cat := fmt.Sprintf("%s.criteria[%d]", at, i)
+		switch name := strings.TrimSpace(c.Name); {
+		case name == "":
+			problems = append(problems, cat+".name: required")
+		case seen[name]:
+			problems = append(problems, fmt.Sprintf("%s.name: duplicate %q", cat, name))
+		}
+		seen[strings.TrimSpace(c.Name)] = true
+		if strings.TrimSpace(c.Description) == "" {
+			problems = append(problems, cat+".description: required")
+		}
+		if c.Weight < 0 {
+			problems = append(problems, fmt.Sprintf("%s.weight: must not be negative (got %g)", cat, c.Weight))
+		}
+	}
+	return problems
+}
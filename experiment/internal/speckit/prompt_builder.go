@@ -4,26 +4,91 @@ import (
 	"fmt"
 	"sort"
 	"strings"
+
+	"speckit-study/internal/llm"
 )
 
+// SystemPrompt 는 SpecKit 프롬프트의 시스템 지시문입니다.
+const SystemPrompt = "You are a senior software engineer helping to materialize a SpecKit plan."
+
 // BuildPrompt 는 specify.md + plan.md + inputs 를 하나의 프롬프트로 합칩니다.
+// 시스템 지시문을 "# System" 섹션으로 본문 앞에 붙입니다 (단일 텍스트만 받는 모델용).
 func BuildPrompt(specify string, plan string, inputs map[string]string) string {
+	return "# System\n" + SystemPrompt + "\n\n" + buildUserPrompt(specify, plan, inputs)
+}
+
+// TaskInputs 는 태스크 Inputs 에 설명("task")과 필수 섹션("required_sections")을 더한 BuildPrompt 입력입니다.
+// Inputs 에 같은 키가 있으면 Inputs 의 값을 그대로 둡니다. run_task 가 태스크마다 보내는 프롬프트가 이 입력으로 만들어집니다.
+func TaskInputs(t Task) map[string]string {
+	inputs := make(map[string]string, len(t.Inputs)+2)
+	if t.Description != "" {
+		inputs["task"] = t.Description
+	}
+	if len(t.RequiredSections) > 0 {
+		inputs["required_sections"] = strings.Join(t.RequiredSections, ", ")
+	}
+	for k, v := range t.Inputs {
+		inputs[k] = v
+	}
+	return inputs
+}
+
+// BuildRequest 는 BuildPrompt 와 같은 내용을 시스템 프롬프트와 user 메시지로 나눈 요청으로 만듭니다.
+func BuildRequest(specify string, plan string, inputs map[string]string) llm.GenerateRequest {
+	return llm.GenerateRequest{
+		System: SystemPrompt,
+		Messages: []llm.Message{
+			{Role: llm.RoleUser, Content: buildUserPrompt(specify, plan, inputs)},
+		},
+	}
+}
+
+// BuildToolRequest 는 스펙 본문을 넣지 않고, 모델이 Tools 로 필요한 파일을 직접 읽도록 안내하는 요청을 만듭니다.
+// llm.RunTools 에 Tools(root) 와 함께 넘깁니다.
+func BuildToolRequest(spec string, task string, inputs map[string]string) llm.GenerateRequest {
 	var sb strings.Builder
-	sb.WriteString("# System\nYou are a senior software engineer helping to materialize a SpecKit plan.\n\n")
+	sb.WriteString(buildInputs(inputs))
+	sb.WriteString("## Task\n")
+	sb.WriteString(task)
+	sb.WriteString("\n\n")
+	sb.WriteString("## Context\n")
+	sb.WriteString(fmt.Sprintf("- The spec lives in `%s/` under the spec root.\n", spec))
+	sb.WriteString("- Use list_specs and read_file to read specify.md and plan.md before answering.\n")
+	sb.WriteString("- Use validate_tasks to check any tasks.yaml you write.\n\n")
+	sb.WriteString(outputRequirements)
+	return llm.GenerateRequest{
+		System:   SystemPrompt,
+		Messages: []llm.Message{{Role: llm.RoleUser, Content: sb.String()}},
+	}
+}
 
-	// Inputs (정렬 출력)
-	if len(inputs) > 0 {
-		keys := make([]string, 0, len(inputs))
-		for k := range inputs {
-			keys = append(keys, k)
-		}
-		sort.Strings(keys)
-		sb.WriteString("## Inputs\n")
-		for _, k := range keys {
-			sb.WriteString(fmt.Sprintf("- %s: %s\n", k, inputs[k]))
-		}
-		sb.WriteString("\n")
+const outputRequirements = "## Output Requirements\n" +
+	"- Keep the answer concise and directly usable by developers.\n" +
+	"- Use markdown when appropriate.\n"
+
+func buildInputs(inputs map[string]string) string {
+	if len(inputs) == 0 {
+		return ""
+	}
+	var sb strings.Builder
+	keys := make([]string, 0, len(inputs))
+	for k := range inputs {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	sb.WriteString("## Inputs\n")
+	for _, k := range keys {
+		sb.WriteString(fmt.Sprintf("- %s: %s\n", k, inputs[k]))
 	}
+	sb.WriteString("\n")
+	return sb.String()
+}
+
+func buildUserPrompt(specify string, plan string, inputs map[string]string) string {
+	var sb strings.Builder
+
+	// Inputs (정렬 출력)
+	sb.WriteString(buildInputs(inputs))
 
 	// specify
 	if strings.TrimSpace(specify) != "" {
@@ -40,8 +105,6 @@ func BuildPrompt(specify string, plan string, inputs map[string]string) string {
 	}
 
 	// Output format 힌트
-	sb.WriteString("## Output Requirements\n")
-	sb.WriteString("- Keep the answer concise and directly usable by developers.\n")
-	sb.WriteString("- Use markdown when appropriate.\n")
+	sb.WriteString(outputRequirements)
 	return sb.String()
 }
@@ -0,0 +1,67 @@
+// internal/speckit/prompt_builder_test.go
+package speckit_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"speckit-study/internal/golden"
+	"speckit-study/internal/llm"
+	"speckit-study/internal/runner"
+	"speckit-study/internal/speckit"
+)
+
+// testdata/features/<기능>/ 의 tasks.yaml 태스크마다 run_task 가 보내는 요청(BuildRequest + TaskInputs)을
+// testdata/golden/<기능>/<태스크>.prompt.md 와 비교합니다 (specify.md, plan.md 는 없어도 됨).
+// 골든 파일은 BuildPrompt 와 같은 모양으로, 시스템 프롬프트를 "# System" 섹션으로 앞에 둡니다.
+// 문구나 입력 순서를 바꿨다면 -update 로 골든 파일을 다시 쓰고 diff 를 함께 커밋하세요.
+func TestBuildPromptGolden(t *testing.T) {
+	features, err := filepath.Glob(filepath.Join("testdata", "features", "*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(features) == 0 {
+		t.Fatal("no fixtures under testdata/features")
+	}
+	for _, dir := range features {
+		feature := filepath.Base(dir)
+		tf, err := speckit.LoadTasks(filepath.Join(dir, "tasks.yaml"))
+		if err != nil {
+			t.Fatalf("%s: %v", feature, err)
+		}
+		if problems := speckit.ValidateTasks(tf); len(problems) > 0 {
+			t.Fatalf("%s: invalid tasks.yaml: %v", feature, problems)
+		}
+		specify := readFixture(t, filepath.Join(dir, "specify.md"))
+		plan := readFixture(t, filepath.Join(dir, "plan.md"))
+
+		for _, task := range tf.Tasks {
+			t.Run(feature+"/"+task.Name, func(t *testing.T) {
+				req := speckit.BuildRequest(specify, plan, speckit.TaskInputs(task))
+				if len(req.Messages) != 1 || req.Messages[0].Role != llm.RoleUser {
+					t.Fatalf("messages = %+v, want a single user message", req.Messages)
+				}
+				got := "# System\n" + req.System + "\n\n" + req.Messages[0].Content
+				if want := speckit.BuildPrompt(specify, plan, speckit.TaskInputs(task)); got != want {
+					t.Errorf("BuildRequest and BuildPrompt disagree:\n%s", runner.UnifiedDiff("BuildPrompt", "BuildRequest", want, got, 3))
+				}
+				golden.Assert(t, filepath.Join("testdata", "golden", feature, task.Name+".prompt.md"), got)
+			})
+		}
+	}
+}
+
+// readFixture : 없는 파일은 "" (BuildPrompt 의 빈 섹션 생략 경로를 함께 검사)
+func readFixture(t *testing.T, path string) string {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ""
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
@@ -0,0 +1,151 @@
+// internal/speckit/tools.go
+package speckit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"speckit-study/internal/llm"
+)
+
+// MaxToolFileBytes 는 read_file 도구가 돌려주는 최대 바이트 수입니다. 넘으면 잘라서 표시합니다.
+const MaxToolFileBytes = 64 * 1024
+
+// Tools 는 root(보통 .specify 디렉터리) 아래 스펙을 모델이 직접 읽고 검사할 수 있는 도구 모음입니다.
+//
+//   - list_specs: 스펙 디렉터리와 파일 목록
+//   - read_file: root 기준 상대 경로의 파일 내용 (root 밖은 거부)
+//   - validate_tasks: tasks.yaml 내용(또는 파일) 검사 결과
+func Tools(root string) *llm.Toolbox {
+	box := llm.NewToolbox()
+	llm.AddFunc(box, "list_specs", "List spec directories and their files under the spec root.",
+		func(ctx context.Context, _ struct{}) (string, error) {
+			return listSpecs(root)
+		})
+	llm.AddFunc(box, "read_file", "Read a file under the spec root, e.g. notification-service/plan.md.",
+		func(ctx context.Context, a struct {
+			Path string `json:"path" desc:"path relative to the spec root"`
+		}) (string, error) {
+			return readSpecFile(root, a.Path)
+		})
+	llm.AddFunc(box, "validate_tasks", "Validate tasks.yaml content, or the file at path when content is empty.",
+		func(ctx context.Context, a struct {
+			Content string `json:"content,omitempty" desc:"tasks.yaml content to check"`
+			Path    string `json:"path,omitempty" desc:"tasks.yaml path relative to the spec root"`
+		}) (string, error) {
+			content := a.Content
+			if strings.TrimSpace(content) == "" {
+				if a.Path == "" {
+					return "", errors.New("content or path is required")
+				}
+				p, err := realSpecPath(root, a.Path)
+				if err != nil {
+					return "", err
+				}
+				b, err := os.ReadFile(p)
+				if err != nil {
+					return "", fmt.Errorf("read %s: %w", a.Path, err)
+				}
+				content = string(b)
+			}
+			return validateTasksYAML(content), nil
+		})
+	return box
+}
+
+// specPath 는 root 기준 상대 경로를 검사해 실제 경로로 바꿉니다. 절대 경로나 root 를 벗어나는 경로는 거부합니다.
+func specPath(root, rel string) (string, error) {
+	rel = filepath.FromSlash(strings.TrimSpace(rel))
+	if rel == "" {
+		return "", errors.New("path is required")
+	}
+	if filepath.IsAbs(rel) || !filepath.IsLocal(rel) {
+		return "", fmt.Errorf("path %q is outside the spec root", rel)
+	}
+	return filepath.Join(root, rel), nil
+}
+
+// realSpecPath 는 specPath 에 더해 심볼릭 링크를 따라간 실제 경로도 root 안에 있는지 확인합니다.
+func realSpecPath(root, rel string) (string, error) {
+	p, err := specPath(root, rel)
+	if err != nil {
+		return "", err
+	}
+	real, err := filepath.EvalSymlinks(p)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", rel, err)
+	}
+	realRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", err
+	}
+	if r, err := filepath.Rel(realRoot, real); err != nil || !filepath.IsLocal(r) {
+		return "", fmt.Errorf("path %q is outside the spec root", rel)
+	}
+	return real, nil
+}
+
+func readSpecFile(root, rel string) (string, error) {
+	real, err := realSpecPath(root, rel)
+	if err != nil {
+		return "", err
+	}
+	b, err := os.ReadFile(real)
+	if err != nil {
+		return "", fmt.Errorf("read %s: %w", rel, err)
+	}
+	if len(b) > MaxToolFileBytes {
+		return string(b[:MaxToolFileBytes]) + fmt.Sprintf("\n... (truncated, %d bytes total)", len(b)), nil
+	}
+	return string(b), nil
+}
+
+// listSpecs 는 root 바로 아래 디렉터리(스펙)마다 파일 목록을 한 줄씩 씁니다. "_" 로 시작하는 디렉터리(_runs 등)는 건너뜁니다.
+func listSpecs(root string) (string, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return "", fmt.Errorf("list specs: %w", err)
+	}
+	var sb strings.Builder
+	for _, e := range entries {
+		if !e.IsDir() || strings.HasPrefix(e.Name(), "_") || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		var files []string
+		dir := filepath.Join(root, e.Name())
+		filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+			rel, _ := filepath.Rel(root, p)
+			files = append(files, filepath.ToSlash(rel))
+			return nil
+		})
+		sort.Strings(files)
+		fmt.Fprintf(&sb, "%s: %s\n", e.Name(), strings.Join(files, ", "))
+	}
+	if sb.Len() == 0 {
+		return "no specs found", nil
+	}
+	return sb.String(), nil
+}
+
+func validateTasksYAML(content string) string {
+	var tf TaskFile
+	if err := yaml.Unmarshal([]byte(content), &tf); err != nil {
+		return "invalid YAML: " + err.Error()
+	}
+	problems := ValidateTasks(&tf)
+	if len(problems) == 0 {
+		return fmt.Sprintf("ok: %d task(s)", len(tf.Tasks))
+	}
+	return "problems:\n- " + strings.Join(problems, "\n- ")
+}
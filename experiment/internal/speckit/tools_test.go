@@ -0,0 +1,147 @@
+// internal/speckit/tools_test.go
+package speckit_test
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"speckit-study/internal/llm"
+	"speckit-study/internal/speckit"
+)
+
+// specRoot 는 demo/specify.md, demo/tasks.yaml 과 _runs/ 를 가진 스펙 루트, 그리고 루트 밖의 secret.txt 를 만듭니다.
+func specRoot(t *testing.T, tasks string) (root, outside string) {
+	t.Helper()
+	base := t.TempDir()
+	root = filepath.Join(base, "specs")
+	for name, content := range map[string]string{
+		"demo/specify.md":      "# Demo\nsend notifications\n",
+		"demo/tasks.yaml":      tasks,
+		"_runs/demo/report.md": "old run\n",
+	} {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	outside = filepath.Join(base, "secret.txt")
+	if err := os.WriteFile(outside, []byte("api key\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return root, outside
+}
+
+// callTool 은 box 의 name 도구를 args 로 부릅니다.
+func callTool(t *testing.T, box *llm.Toolbox, name string, args any) (string, error) {
+	t.Helper()
+	b, err := json.Marshal(args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return box.Call(context.Background(), llm.ToolCall{ID: "call_1", Name: name, Arguments: b})
+}
+
+func TestToolsListAndRead(t *testing.T) {
+	root, _ := specRoot(t, "tasks: []\n")
+	box := speckit.Tools(root)
+
+	out, err := callTool(t, box, "list_specs", struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// _runs 같은 "_" 디렉터리는 스펙이 아닙니다.
+	if out != "demo: demo/specify.md, demo/tasks.yaml\n" {
+		t.Errorf("list_specs = %q", out)
+	}
+	out, err = callTool(t, box, "read_file", map[string]string{"path": "demo/specify.md"})
+	if err != nil || out != "# Demo\nsend notifications\n" {
+		t.Errorf("read_file = %q, %v", out, err)
+	}
+}
+
+// root 밖을 가리키는 경로는 상대 경로(..), 절대 경로, 심볼릭 링크 모두 거부합니다.
+func TestToolsRejectPathsOutsideRoot(t *testing.T) {
+	root, outside := specRoot(t, "tasks: []\n")
+	if err := os.Symlink(outside, filepath.Join(root, "demo", "leak.md")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if err := os.Symlink(filepath.Dir(outside), filepath.Join(root, "up")); err != nil {
+		t.Fatal(err)
+	}
+	box := speckit.Tools(root)
+
+	for _, path := range []string{
+		"../secret.txt",
+		"demo/../../secret.txt",
+		outside,
+		"demo/leak.md",  // 파일 링크
+		"up/secret.txt", // 디렉터리 링크
+	} {
+		out, err := callTool(t, box, "read_file", map[string]string{"path": path})
+		if err == nil || !strings.Contains(err.Error(), "outside the spec root") {
+			t.Errorf("read_file %q: got %q, %v; want it rejected", path, out, err)
+		}
+		if strings.Contains(out, "api key") {
+			t.Errorf("read_file %q leaked the file outside the root", path)
+		}
+	}
+	for _, path := range []string{"../secret.txt", "demo/leak.md"} {
+		if _, err := callTool(t, box, "validate_tasks", map[string]string{"path": path}); err == nil || !strings.Contains(err.Error(), "outside the spec root") {
+			t.Errorf("validate_tasks %q: got %v, want it rejected", path, err)
+		}
+	}
+	// 링크라도 root 안을 가리키면 읽을 수 있습니다.
+	if err := os.Symlink(filepath.Join(root, "demo", "specify.md"), filepath.Join(root, "demo", "alias.md")); err != nil {
+		t.Fatal(err)
+	}
+	if out, err := callTool(t, box, "read_file", map[string]string{"path": "demo/alias.md"}); err != nil || !strings.HasPrefix(out, "# Demo") {
+		t.Errorf("read_file via an inner link = %q, %v", out, err)
+	}
+}
+
+func TestToolsValidateTasks(t *testing.T) {
+	root, _ := specRoot(t, `tasks:
+  - name: BadName
+    required_sections: ["Steps", ""]
+    max_attempts: -1
+`)
+	box := speckit.Tools(root)
+
+	out, err := callTool(t, box, "validate_tasks", map[string]string{"path": "demo/tasks.yaml"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`tasks[0].name: "BadName" is not snake_case`,
+		"tasks[0].description: required",
+		"tasks[0].required_sections[1]: empty",
+		"tasks[0].max_attempts: must not be negative",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("validate_tasks output misses %q:\n%s", want, out)
+		}
+	}
+	if !strings.HasPrefix(out, "problems:\n") {
+		t.Errorf("validate_tasks = %q, want a problem list", out)
+	}
+
+	// 내용을 직접 넘기면 파일 대신 그 내용을 검사합니다. 문법 오류도 오류가 아닌 결과로 돌려줍니다.
+	out, err = callTool(t, box, "validate_tasks", map[string]string{"content": "tasks: [\n"})
+	if err != nil || !strings.HasPrefix(out, "invalid YAML: ") {
+		t.Errorf("broken YAML: got %q, %v", out, err)
+	}
+	out, err = callTool(t, box, "validate_tasks", map[string]string{"content": "tasks:\n  - name: ok_task\n    description: fine\n"})
+	if err != nil || out != "ok: 1 task(s)" {
+		t.Errorf("valid content: got %q, %v", out, err)
+	}
+	if _, err := callTool(t, box, "validate_tasks", struct{}{}); err == nil {
+		t.Error("validate_tasks without content or path: want an error")
+	}
+}